@@ -0,0 +1,60 @@
+package cose
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// newAEAD returns the AEAD cipher of a content encryption algorithm with a
+// given symmetric key.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-4
+func newAEAD(alg Algorithm, key []byte) (cipher.AEAD, error) {
+	switch alg {
+	case AlgorithmA128GCM, AlgorithmA192GCM, AlgorithmA256GCM:
+		if size := alg.keySize(); len(key) != size {
+			return nil, fmt.Errorf("%v: require %d-byte key, got %d", alg, size, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		// RFC 9053 4.1 requires a 96-bit nonce and a 128-bit tag.
+		return cipher.NewGCM(block)
+	case AlgorithmChaCha20Poly1305:
+		if size := alg.keySize(); len(key) != size {
+			return nil, fmt.Errorf("%v: require %d-byte key, got %d", alg, size, len(key))
+		}
+		return chacha20poly1305.New(key)
+	default:
+		return nil, ErrAlgorithmNotSupported
+	}
+}
+
+// encStructure constructs Enc_structure and returns its encoding, which is
+// used as the additional authenticated data of the AEAD cipher.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.3
+func encStructure(context string, protected cbor.RawMessage, external []byte) ([]byte, error) {
+	// create an Enc_structure and populate it with the appropriate fields.
+	//
+	//   Enc_structure = [
+	//       context : "Encrypt" / "Encrypt0" / "Enc_Recipient" /
+	//           "Mac_Recipient" / "Rec_Recipient",
+	//       protected : empty_or_serialized_map,
+	//       external_aad : bstr
+	//   ]
+	if external == nil {
+		external = []byte{}
+	}
+	encStructure := []interface{}{
+		context,   // context
+		protected, // protected
+		external,  // external_aad
+	}
+	return encMode.Marshal(encStructure)
+}
@@ -0,0 +1,57 @@
+package cose
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_newAEAD(t *testing.T) {
+	tests := []struct {
+		name     string
+		alg      Algorithm
+		keySize  int
+		wantErr  bool
+		overhead int
+	}{
+		{name: "A128GCM", alg: AlgorithmA128GCM, keySize: 16, overhead: 16},
+		{name: "A192GCM", alg: AlgorithmA192GCM, keySize: 24, overhead: 16},
+		{name: "A256GCM", alg: AlgorithmA256GCM, keySize: 32, overhead: 16},
+		{name: "ChaCha20/Poly1305", alg: AlgorithmChaCha20Poly1305, keySize: 32, overhead: 16},
+		{name: "A128GCM with 256-bit key", alg: AlgorithmA128GCM, keySize: 32, wantErr: true},
+		{name: "ChaCha20/Poly1305 with 128-bit key", alg: AlgorithmChaCha20Poly1305, keySize: 16, wantErr: true},
+		{name: "signature algorithm", alg: AlgorithmES256, keySize: 32, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newAEAD(tt.alg, make([]byte, tt.keySize))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newAEAD() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.NonceSize() != 12 {
+				t.Errorf("newAEAD() nonce size = %d, want 12", got.NonceSize())
+			}
+			if got.Overhead() != tt.overhead {
+				t.Errorf("newAEAD() overhead = %d, want %d", got.Overhead(), tt.overhead)
+			}
+		})
+	}
+}
+
+func Test_encStructure(t *testing.T) {
+	got, err := encStructure("Encrypt0", []byte{0x43, 0xa1, 0x01, 0x01}, nil)
+	if err != nil {
+		t.Fatalf("encStructure() error = %v", err)
+	}
+	want := []byte{
+		0x83,                                                 // array of length 3
+		0x68, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x30, // context
+		0x43, 0xa1, 0x01, 0x01, // protected
+		0x40, // external_aad
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encStructure() = %x, want %x", got, want)
+	}
+}
@@ -2,7 +2,10 @@ package cose
 
 import (
 	"crypto"
+	"errors"
+	"fmt"
 	"strconv"
+	"sync"
 )
 
 // Algorithms supported by this library.
@@ -36,6 +39,106 @@ const (
 
 	// PureEdDSA by RFC 8152.
 	AlgorithmEd25519 Algorithm = -8
+
+	// HMAC w/ SHA-256 truncated to 64 bits by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmHMAC256_64 Algorithm = 4
+
+	// HMAC w/ SHA-256 by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmHMAC256_256 Algorithm = 5
+
+	// HMAC w/ SHA-384 by RFC 9053.
+	// Requires an available crypto.SHA384.
+	AlgorithmHMAC384_384 Algorithm = 6
+
+	// HMAC w/ SHA-512 by RFC 9053.
+	// Requires an available crypto.SHA512.
+	AlgorithmHMAC512_512 Algorithm = 7
+
+	// AES-GCM mode w/ 128-bit key, 128-bit tag by RFC 9053.
+	AlgorithmA128GCM Algorithm = 1
+
+	// AES-GCM mode w/ 192-bit key, 128-bit tag by RFC 9053.
+	AlgorithmA192GCM Algorithm = 2
+
+	// AES-GCM mode w/ 256-bit key, 128-bit tag by RFC 9053.
+	AlgorithmA256GCM Algorithm = 3
+
+	// ChaCha20/Poly1305 w/ 256-bit key, 128-bit tag by RFC 9053.
+	AlgorithmChaCha20Poly1305 Algorithm = 24
+
+	// Direct use of CEK by RFC 9053.
+	AlgorithmDirect Algorithm = -6
+
+	// AES Key Wrap w/ 128-bit key by RFC 9053.
+	AlgorithmA128KW Algorithm = -3
+
+	// AES Key Wrap w/ 192-bit key by RFC 9053.
+	AlgorithmA192KW Algorithm = -4
+
+	// AES Key Wrap w/ 256-bit key by RFC 9053.
+	AlgorithmA256KW Algorithm = -5
+
+	// ECDH-ES w/ HKDF-SHA-256 by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmECDH_ES_HKDF_256 Algorithm = -25
+
+	// ECDH-ES w/ HKDF-SHA-512 by RFC 9053.
+	// Requires an available crypto.SHA512.
+	AlgorithmECDH_ES_HKDF_512 Algorithm = -26
+
+	// ECDH-SS w/ HKDF-SHA-256 by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmECDH_SS_HKDF_256 Algorithm = -27
+
+	// ECDH-SS w/ HKDF-SHA-512 by RFC 9053.
+	// Requires an available crypto.SHA512.
+	AlgorithmECDH_SS_HKDF_512 Algorithm = -28
+
+	// ECDH-ES w/ HKDF-SHA-256 and AES Key Wrap w/ 128-bit key by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmECDH_ES_A128KW Algorithm = -29
+
+	// ECDH-ES w/ HKDF-SHA-256 and AES Key Wrap w/ 192-bit key by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmECDH_ES_A192KW Algorithm = -30
+
+	// ECDH-ES w/ HKDF-SHA-256 and AES Key Wrap w/ 256-bit key by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmECDH_ES_A256KW Algorithm = -31
+
+	// ECDH-SS w/ HKDF-SHA-256 and AES Key Wrap w/ 128-bit key by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmECDH_SS_A128KW Algorithm = -32
+
+	// ECDH-SS w/ HKDF-SHA-256 and AES Key Wrap w/ 192-bit key by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmECDH_SS_A192KW Algorithm = -33
+
+	// ECDH-SS w/ HKDF-SHA-256 and AES Key Wrap w/ 256-bit key by RFC 9053.
+	// Requires an available crypto.SHA256.
+	AlgorithmECDH_SS_A256KW Algorithm = -34
+
+	// SHA-2 256-bit hash truncated to 64 bits by RFC 9054.
+	// Requires an available crypto.SHA256.
+	AlgorithmSHA256_64 Algorithm = -15
+
+	// SHA-2 256-bit hash by RFC 9054.
+	// Requires an available crypto.SHA256.
+	AlgorithmSHA256 Algorithm = -16
+
+	// SHA-2 512-bit hash truncated to 256 bits by RFC 9054.
+	// Requires an available crypto.SHA512_256.
+	AlgorithmSHA512_256 Algorithm = -17
+
+	// SHA-2 384-bit hash by RFC 9054.
+	// Requires an available crypto.SHA384.
+	AlgorithmSHA384 Algorithm = -43
+
+	// SHA-2 512-bit hash by RFC 9054.
+	// Requires an available crypto.SHA512.
+	AlgorithmSHA512 Algorithm = -44
 )
 
 // Algorithm represents an IANA algorithm entry in the COSE Algorithms registry.
@@ -48,43 +151,269 @@ const (
 // RFC 8152 16.4: https://datatracker.ietf.org/doc/html/rfc8152#section-16.4
 type Algorithm int64
 
-// String returns the name of the algorithm
-func (a Algorithm) String() string {
-	switch a {
-	case AlgorithmPS256:
-		return "PS256"
-	case AlgorithmPS384:
-		return "PS384"
-	case AlgorithmPS512:
-		return "PS512"
-	case AlgorithmES256:
-		return "ES256"
-	case AlgorithmES384:
-		return "ES384"
-	case AlgorithmES512:
-		return "ES512"
-	case AlgorithmEd25519:
+// AlgorithmInfo describes an algorithm known to this library, either built-in
+// or registered at runtime by RegisterAlgorithm.
+type AlgorithmInfo struct {
+	// Name of the algorithm, as returned by Algorithm.String().
+	Name string
+
+	// Hash is the hash function associated with the algorithm.
+	// Zero if the algorithm does not hash the content, or if the hash is
+	// handled internally by the Signer and the Verifier.
+	Hash crypto.Hash
+
+	// KeySize is the size in bytes of the symmetric key used by the algorithm.
+	// Zero if the algorithm does not use a symmetric key of fixed size.
+	KeySize int
+
+	// CheckKey validates the public key to be used with the algorithm.
+	// It is called by NewSigner and NewVerifier before invoking the factories
+	// below. Return an error wrapping ErrAlgorithmMismatch if the key type does
+	// not match the algorithm.
+	// Optional.
+	CheckKey func(key crypto.PublicKey) error
+
+	// NewSigner creates a Signer for the algorithm with the given key.
+	// If nil, NewSigner returns ErrAlgorithmNotSupported for the algorithm.
+	NewSigner func(alg Algorithm, key crypto.Signer) (Signer, error)
+
+	// NewVerifier creates a Verifier for the algorithm with the given key.
+	// If nil, NewVerifier returns ErrAlgorithmNotSupported for the algorithm.
+	NewVerifier func(alg Algorithm, key crypto.PublicKey) (Verifier, error)
+}
+
+// builtInAlgorithms contains the algorithms implemented by this library.
+// It is never modified after initialization.
+var builtInAlgorithms = map[Algorithm]AlgorithmInfo{
+	AlgorithmPS256: {
+		Name:        "PS256",
+		Hash:        crypto.SHA256,
+		CheckKey:    checkRSAKey,
+		NewSigner:   newRSASigner,
+		NewVerifier: newRSAVerifier,
+	},
+	AlgorithmPS384: {
+		Name:        "PS384",
+		Hash:        crypto.SHA384,
+		CheckKey:    checkRSAKey,
+		NewSigner:   newRSASigner,
+		NewVerifier: newRSAVerifier,
+	},
+	AlgorithmPS512: {
+		Name:        "PS512",
+		Hash:        crypto.SHA512,
+		CheckKey:    checkRSAKey,
+		NewSigner:   newRSASigner,
+		NewVerifier: newRSAVerifier,
+	},
+	AlgorithmES256: {
+		Name:        "ES256",
+		Hash:        crypto.SHA256,
+		CheckKey:    checkECDSAKey,
+		NewSigner:   newECDSASigner,
+		NewVerifier: newECDSAVerifier,
+	},
+	AlgorithmES384: {
+		Name:        "ES384",
+		Hash:        crypto.SHA384,
+		CheckKey:    checkECDSAKey,
+		NewSigner:   newECDSASigner,
+		NewVerifier: newECDSAVerifier,
+	},
+	AlgorithmES512: {
+		Name:        "ES512",
+		Hash:        crypto.SHA512,
+		CheckKey:    checkECDSAKey,
+		NewSigner:   newECDSASigner,
+		NewVerifier: newECDSAVerifier,
+	},
+	AlgorithmEd25519: {
 		// As stated in RFC 8152 8.2, only the pure EdDSA version is used for
 		// COSE.
-		return "EdDSA"
-	default:
-		return "unknown algorithm value " + strconv.Itoa(int(a))
+		Name:        "EdDSA",
+		CheckKey:    checkEd25519Key,
+		NewSigner:   newEd25519Signer,
+		NewVerifier: newEd25519Verifier,
+	},
+	AlgorithmHMAC256_64: {
+		Name:    "HMAC 256/64",
+		Hash:    crypto.SHA256,
+		KeySize: 32,
+	},
+	AlgorithmHMAC256_256: {
+		Name:    "HMAC 256/256",
+		Hash:    crypto.SHA256,
+		KeySize: 32,
+	},
+	AlgorithmHMAC384_384: {
+		Name:    "HMAC 384/384",
+		Hash:    crypto.SHA384,
+		KeySize: 48,
+	},
+	AlgorithmHMAC512_512: {
+		Name:    "HMAC 512/512",
+		Hash:    crypto.SHA512,
+		KeySize: 64,
+	},
+	AlgorithmA128GCM: {
+		Name:    "A128GCM",
+		KeySize: 16,
+	},
+	AlgorithmA192GCM: {
+		Name:    "A192GCM",
+		KeySize: 24,
+	},
+	AlgorithmA256GCM: {
+		Name:    "A256GCM",
+		KeySize: 32,
+	},
+	AlgorithmChaCha20Poly1305: {
+		Name:    "ChaCha20/Poly1305",
+		KeySize: 32,
+	},
+	AlgorithmDirect: {
+		Name: "direct",
+	},
+	AlgorithmA128KW: {
+		Name:    "A128KW",
+		KeySize: 16,
+	},
+	AlgorithmA192KW: {
+		Name:    "A192KW",
+		KeySize: 24,
+	},
+	AlgorithmA256KW: {
+		Name:    "A256KW",
+		KeySize: 32,
+	},
+	AlgorithmECDH_ES_HKDF_256: {
+		Name: "ECDH-ES + HKDF-256",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmECDH_ES_HKDF_512: {
+		Name: "ECDH-ES + HKDF-512",
+		Hash: crypto.SHA512,
+	},
+	AlgorithmECDH_SS_HKDF_256: {
+		Name: "ECDH-SS + HKDF-256",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmECDH_SS_HKDF_512: {
+		Name: "ECDH-SS + HKDF-512",
+		Hash: crypto.SHA512,
+	},
+	AlgorithmECDH_ES_A128KW: {
+		Name: "ECDH-ES + A128KW",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmECDH_ES_A192KW: {
+		Name: "ECDH-ES + A192KW",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmECDH_ES_A256KW: {
+		Name: "ECDH-ES + A256KW",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmECDH_SS_A128KW: {
+		Name: "ECDH-SS + A128KW",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmECDH_SS_A192KW: {
+		Name: "ECDH-SS + A192KW",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmECDH_SS_A256KW: {
+		Name: "ECDH-SS + A256KW",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmSHA256_64: {
+		Name: "SHA-256/64",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmSHA256: {
+		Name: "SHA-256",
+		Hash: crypto.SHA256,
+	},
+	AlgorithmSHA512_256: {
+		Name: "SHA-512/256",
+		Hash: crypto.SHA512_256,
+	},
+	AlgorithmSHA384: {
+		Name: "SHA-384",
+		Hash: crypto.SHA384,
+	},
+	AlgorithmSHA512: {
+		Name: "SHA-512",
+		Hash: crypto.SHA512,
+	},
+}
+
+// extAlgorithms contains the algorithms registered by RegisterAlgorithm.
+var (
+	extAlgorithms     = make(map[Algorithm]AlgorithmInfo)
+	extAlgorithmsLock sync.RWMutex
+)
+
+// RegisterAlgorithm provides extensibility for the cose library to support
+// private algorithms or algorithms not yet registered in IANA.
+// The existing algorithms cannot be re-registered.
+// The parameter `info.Name` must not be empty.
+//
+// RegisterAlgorithm is safe for concurrent use. It is typically called from
+// an init function.
+func RegisterAlgorithm(alg Algorithm, info AlgorithmInfo) error {
+	if info.Name == "" {
+		return errors.New("algorithm name is required")
+	}
+	if _, ok := builtInAlgorithms[alg]; ok {
+		return fmt.Errorf("%w: %v", ErrAlgorithmRegistered, alg)
+	}
+	extAlgorithmsLock.Lock()
+	defer extAlgorithmsLock.Unlock()
+	if existing, ok := extAlgorithms[alg]; ok {
+		// alg.String() cannot be used here as the lock is being held.
+		return fmt.Errorf("%w: %s", ErrAlgorithmRegistered, existing.Name)
+	}
+	extAlgorithms[alg] = info
+	return nil
+}
+
+// lookupAlgorithm returns the description of a built-in or registered
+// algorithm.
+func lookupAlgorithm(alg Algorithm) (AlgorithmInfo, bool) {
+	if info, ok := builtInAlgorithms[alg]; ok {
+		return info, true
 	}
+	extAlgorithmsLock.RLock()
+	defer extAlgorithmsLock.RUnlock()
+	info, ok := extAlgorithms[alg]
+	return info, ok
+}
+
+// String returns the name of the algorithm
+func (a Algorithm) String() string {
+	if info, ok := lookupAlgorithm(a); ok {
+		return info.Name
+	}
+	return "unknown algorithm value " + strconv.Itoa(int(a))
 }
 
 // hashFunc returns the hash associated with the algorithm supported by this
 // library.
 func (a Algorithm) hashFunc() crypto.Hash {
-	switch a {
-	case AlgorithmPS256, AlgorithmES256:
-		return crypto.SHA256
-	case AlgorithmPS384, AlgorithmES384:
-		return crypto.SHA384
-	case AlgorithmPS512, AlgorithmES512:
-		return crypto.SHA512
-	default:
-		return 0
+	if info, ok := lookupAlgorithm(a); ok {
+		return info.Hash
+	}
+	return 0
+}
+
+// keySize returns the size in bytes of the symmetric key associated with the
+// algorithm supported by this library.
+func (a Algorithm) keySize() int {
+	if info, ok := lookupAlgorithm(a); ok {
+		return info.KeySize
 	}
+	return 0
 }
 
 // computeHash computes the digest using the hash specified in the algorithm.
@@ -2,7 +2,9 @@ package cose
 
 import (
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/sha256"
+	"errors"
 	"hash"
 	"io"
 	"reflect"
@@ -51,6 +53,16 @@ func TestAlgorithm_String(t *testing.T) {
 			alg:  AlgorithmEd25519,
 			want: "EdDSA",
 		},
+		{
+			name: "SHA-256",
+			alg:  AlgorithmSHA256,
+			want: "SHA-256",
+		},
+		{
+			name: "SHA-256/64",
+			alg:  AlgorithmSHA256_64,
+			want: "SHA-256/64",
+		},
 		{
 			name: "unknown algorithm",
 			alg:  0,
@@ -186,3 +198,111 @@ func Test_computeHash(t *testing.T) {
 		t.Fatalf("computeHash() error = %v, wantErr %v", err, io.EOF)
 	}
 }
+
+func TestRegisterAlgorithm(t *testing.T) {
+	const alg Algorithm = -0x7265_6769 // private use
+	defer func() {
+		extAlgorithmsLock.Lock()
+		delete(extAlgorithms, alg)
+		extAlgorithmsLock.Unlock()
+	}()
+
+	if got, want := alg.String(), "unknown algorithm value -1919248233"; got != want {
+		t.Fatalf("Algorithm.String() = %v, want %v", got, want)
+	}
+
+	// register
+	err := RegisterAlgorithm(alg, AlgorithmInfo{
+		Name: "ES256-custom",
+		Hash: crypto.SHA256,
+		CheckKey: func(key crypto.PublicKey) error {
+			if _, ok := key.(*ecdsa.PublicKey); !ok {
+				return ErrAlgorithmMismatch
+			}
+			return nil
+		},
+		NewSigner:   newECDSASigner,
+		NewVerifier: newECDSAVerifier,
+	})
+	if err != nil {
+		t.Fatalf("RegisterAlgorithm() error = %v", err)
+	}
+	if got, want := alg.String(), "ES256-custom"; got != want {
+		t.Errorf("Algorithm.String() = %v, want %v", got, want)
+	}
+	if got, want := alg.hashFunc(), crypto.SHA256; got != want {
+		t.Errorf("Algorithm.hashFunc() = %v, want %v", got, want)
+	}
+
+	// sign / verify round trip with the registered algorithm
+	key := generateTestECDSAKey(t)
+	content, sig := signTestData(t, alg, key)
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	if err := verifier.Verify(content, sig); err != nil {
+		t.Errorf("Verifier.Verify() error = %v", err)
+	}
+
+	// key check
+	_, ed25519Key := generateTestEd25519Key(t)
+	if _, err := NewSigner(alg, ed25519Key); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Errorf("NewSigner() error = %v, wantErr %v", err, ErrAlgorithmMismatch)
+	}
+
+	// re-registration
+	if err := RegisterAlgorithm(alg, AlgorithmInfo{Name: "foo"}); !errors.Is(err, ErrAlgorithmRegistered) {
+		t.Errorf("RegisterAlgorithm() error = %v, wantErr %v", err, ErrAlgorithmRegistered)
+	}
+}
+
+func TestRegisterAlgorithm_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		info    AlgorithmInfo
+		wantErr error
+	}{
+		{
+			name:    "built-in algorithm",
+			alg:     AlgorithmES256,
+			info:    AlgorithmInfo{Name: "foo"},
+			wantErr: ErrAlgorithmRegistered,
+		},
+		{
+			name: "missing name",
+			alg:  -0x7265_6769,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterAlgorithm(tt.alg, tt.info)
+			if err == nil {
+				t.Fatal("RegisterAlgorithm() error = nil, wantErr true")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("RegisterAlgorithm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSigner_NoFactory(t *testing.T) {
+	const alg Algorithm = -0x7265_6770 // private use
+	if err := RegisterAlgorithm(alg, AlgorithmInfo{Name: "verify only"}); err != nil {
+		t.Fatalf("RegisterAlgorithm() error = %v", err)
+	}
+	defer func() {
+		extAlgorithmsLock.Lock()
+		delete(extAlgorithms, alg)
+		extAlgorithmsLock.Unlock()
+	}()
+	key := generateTestECDSAKey(t)
+	if _, err := NewSigner(alg, key); err != ErrAlgorithmNotSupported {
+		t.Errorf("NewSigner() error = %v, wantErr %v", err, ErrAlgorithmNotSupported)
+	}
+	if _, err := NewVerifier(alg, key.Public()); err != ErrAlgorithmNotSupported {
+		t.Errorf("NewVerifier() error = %v, wantErr %v", err, ErrAlgorithmNotSupported)
+	}
+}
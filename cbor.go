@@ -2,27 +2,38 @@ package cose
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 
 	"github.com/fxamacker/cbor/v2"
 )
 
-// CBOR Tags for COSE signatures registered in the IANA "CBOR Tags" registry.
+// CBOR Tags for COSE messages registered in the IANA "CBOR Tags" registry.
 //
 // Reference: https://www.iana.org/assignments/cbor-tags/cbor-tags.xhtml#tags
 const (
-	CBORTagSignMessage  = 98
-	CBORTagSign1Message = 18
+	CBORTagSignMessage     = 98
+	CBORTagSign1Message    = 18
+	CBORTagMac0Message     = 17
+	CBORTagMacMessage      = 97
+	CBORTagEncrypt0Message = 16
+	CBORTagEncryptMessage  = 96
 )
 
 // Pre-configured modes for CBOR encoding and decoding.
 var (
 	encMode                  cbor.EncMode
+	encModeCoreDeterministic cbor.EncMode
 	decMode                  cbor.DecMode
 	decModeWithTagsForbidden cbor.DecMode
 )
 
+// defaultDecoder decodes COSE objects with the limits set by SetDecOptions.
+var defaultDecoder *decoder
+
 func init() {
 	var err error
 
@@ -35,22 +46,140 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+	encModeCoreDeterministic, err = cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
 
 	// init decode mode
+	if err := SetDecOptions(DecOptions{}); err != nil {
+		panic(err)
+	}
+}
+
+// DecOptions specifies the limits applied when decoding COSE objects, which
+// prevent hostile input from causing excessive allocations.
+// A zero field selects the default limit.
+type DecOptions struct {
+	// MaxNestedLevels is the maximum nesting depth of CBOR arrays, maps and
+	// tags, in the range [4, 256]. The default is 32.
+	MaxNestedLevels int
+
+	// MaxArrayElements is the maximum number of elements of CBOR arrays, in
+	// the range [16, 2147483647]. The default is 131072.
+	MaxArrayElements int
+
+	// MaxMapPairs is the maximum number of key-value pairs of CBOR maps, in
+	// the range [16, 2147483647]. The default is 131072.
+	MaxMapPairs int
+
+	// MaxMessageSize is the maximum size in bytes of an encoded COSE message
+	// or header. The default is no limit.
+	MaxMessageSize int
+
+	// MaxHeaders is the maximum number of header parameters in a protected or
+	// an unprotected header. The default is no limit.
+	MaxHeaders int
+
+	// Deterministic requires COSE messages and headers, including the content
+	// of protected headers, to be in core deterministic encoding, i.e. to use
+	// the shortest form of integers, lengths and floats, and sorted map keys.
+	// Otherwise, ErrNonDeterministicEncoding is returned.
+	//
+	// Reference: https://datatracker.ietf.org/doc/html/rfc8949#section-4.2.1
+	Deterministic bool
+}
+
+// SetDecOptions sets the limits applied when decoding COSE objects without
+// explicit options, e.g. by UnmarshalCBOR.
+//
+// SetDecOptions is not safe for concurrent use with decoding. It is typically
+// called from an init function.
+func SetDecOptions(opts DecOptions) error {
+	d, err := opts.decoder()
+	if err != nil {
+		return err
+	}
+	defaultDecoder = d
+	decMode = d.mode
+	decModeWithTagsForbidden = d.modeWithTagsForbidden
+	return nil
+}
+
+// decoder decodes COSE objects with the limits of DecOptions.
+type decoder struct {
+	mode                  cbor.DecMode
+	modeWithTagsForbidden cbor.DecMode
+	maxMessageSize        int
+	maxHeaders            int
+	deterministic         bool
+}
+
+// decoder returns a decoder with the limits of opts.
+func (opts DecOptions) decoder() (*decoder, error) {
+	if opts.MaxMessageSize < 0 {
+		return nil, fmt.Errorf("invalid MaxMessageSize %d", opts.MaxMessageSize)
+	}
+	if opts.MaxHeaders < 0 {
+		return nil, fmt.Errorf("invalid MaxHeaders %d", opts.MaxHeaders)
+	}
 	decOpts := cbor.DecOptions{
-		DupMapKey:   cbor.DupMapKeyEnforcedAPF, // duplicated key not allowed
-		IndefLength: cbor.IndefLengthForbidden, // no streaming
-		IntDec:      cbor.IntDecConvertSigned,  // decode CBOR uint/int to Go int64
+		DupMapKey:        cbor.DupMapKeyEnforcedAPF, // duplicated key not allowed
+		IndefLength:      cbor.IndefLengthForbidden, // no streaming
+		IntDec:           cbor.IntDecConvertSigned,  // decode CBOR uint/int to Go int64
+		MaxNestedLevels:  opts.MaxNestedLevels,
+		MaxArrayElements: opts.MaxArrayElements,
+		MaxMapPairs:      opts.MaxMapPairs,
 	}
-	decMode, err = decOpts.DecMode()
+	mode, err := decOpts.DecMode()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	decOpts.TagsMd = cbor.TagsForbidden
-	decModeWithTagsForbidden, err = decOpts.DecMode()
+	modeWithTagsForbidden, err := decOpts.DecMode()
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	return &decoder{
+		mode:                  mode,
+		modeWithTagsForbidden: modeWithTagsForbidden,
+		maxMessageSize:        opts.MaxMessageSize,
+		maxHeaders:            opts.MaxHeaders,
+		deterministic:         opts.Deterministic,
+	}, nil
+}
+
+// validate ensures the encoded object is within the size limit, and is in
+// core deterministic encoding if required.
+func (d *decoder) validate(data []byte) error {
+	if d.maxMessageSize > 0 && len(data) > d.maxMessageSize {
+		return fmt.Errorf("cbor: object size %d exceeds limit %d", len(data), d.maxMessageSize)
+	}
+	return d.checkDeterministic(data)
+}
+
+// checkDeterministic ensures the encoded object is in core deterministic
+// encoding if required.
+func (d *decoder) checkDeterministic(data []byte) error {
+	if !d.deterministic {
+		return nil
+	}
+
+	// ensure the data is a single well-formed item within the limits before
+	// walking through it.
+	if err := d.mode.Valid(data); err != nil {
+		return err
 	}
+	_, err := checkDeterministicItem(data)
+	return err
+}
+
+// checkHeaders ensures the number of header parameters is within the limit.
+func (d *decoder) checkHeaders(count int) error {
+	if d.maxHeaders > 0 && count > d.maxHeaders {
+		return fmt.Errorf("cbor: %d header parameters exceed limit %d", count, d.maxHeaders)
+	}
+	return nil
 }
 
 // byteString represents a "bstr / nil" type.
@@ -82,3 +211,152 @@ func (s *byteString) UnmarshalCBOR(data []byte) error {
 	}
 	return decModeWithTagsForbidden.Unmarshal(data, (*[]byte)(s))
 }
+
+// encodeBstrHead returns the head of a CBOR byte string of the given length,
+// which precedes the content of the byte string.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8949#section-3
+func encodeBstrHead(length uint64) []byte {
+	const majorTypeBstr = 2 << 5
+	switch {
+	case length < 24:
+		return []byte{majorTypeBstr | byte(length)}
+	case length <= math.MaxUint8:
+		return []byte{majorTypeBstr | 24, byte(length)}
+	case length <= math.MaxUint16:
+		head := []byte{majorTypeBstr | 25, 0, 0}
+		binary.BigEndian.PutUint16(head[1:], uint16(length))
+		return head
+	case length <= math.MaxUint32:
+		head := []byte{majorTypeBstr | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(head[1:], uint32(length))
+		return head
+	}
+	head := []byte{majorTypeBstr | 27, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint64(head[1:], length)
+	return head
+}
+
+// decodeTagHead decodes the head of a CBOR tag, and returns the tag number and
+// the tag content following the head.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8949#section-3.4
+func decodeTagHead(data []byte) (uint64, []byte, error) {
+	const majorTypeTag = 6
+	if len(data) == 0 {
+		return 0, nil, io.EOF // same error as returned by cbor.Unmarshal()
+	}
+	if data[0]>>5 != majorTypeTag {
+		return 0, nil, errors.New("cbor: require tag type")
+	}
+	info := data[0] & 0x1f
+	data = data[1:]
+	if info < 24 {
+		return uint64(info), data, nil
+	}
+	if info > 27 {
+		return 0, nil, errors.New("cbor: invalid tag head")
+	}
+	size := 1 << (info - 24) // 1, 2, 4 or 8 bytes
+	if len(data) < size {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	var tag uint64
+	for _, b := range data[:size] {
+		tag = tag<<8 | uint64(b)
+	}
+	return tag, data[size:], nil
+}
+
+// checkDeterministicItem ensures the well-formed CBOR data item at the start of
+// data is in core deterministic encoding, and returns the remaining data.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8949#section-4.2.1
+func checkDeterministicItem(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	majorType := data[0] >> 5
+	info := data[0] & 0x1f
+	rest := data[1:]
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24) // 1, 2, 4 or 8 bytes
+		if len(rest) < size {
+			return nil, io.ErrUnexpectedEOF
+		}
+		for _, b := range rest[:size] {
+			arg = arg<<8 | uint64(b)
+		}
+		rest = rest[size:]
+		if majorType == 7 {
+			if info == 24 { // simple value
+				return rest, nil
+			}
+			return rest, checkDeterministicFloat(data[:1+size])
+		}
+		if info == 24 && arg < 24 || info > 24 && arg>>(4*size) == 0 {
+			return nil, fmt.Errorf("%w: non-shortest argument %d", ErrNonDeterministicEncoding, arg)
+		}
+	case info == 31:
+		return nil, fmt.Errorf("%w: indefinite length", ErrNonDeterministicEncoding)
+	default:
+		return nil, errors.New("cbor: invalid additional information")
+	}
+
+	var err error
+	switch majorType {
+	case 2, 3: // bstr, tstr
+		if uint64(len(rest)) < arg {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return rest[arg:], nil
+	case 4: // array
+		for i := uint64(0); i < arg; i++ {
+			if rest, err = checkDeterministicItem(rest); err != nil {
+				return nil, err
+			}
+		}
+	case 5: // map
+		var prevKey []byte
+		for i := uint64(0); i < arg; i++ {
+			key := rest
+			if rest, err = checkDeterministicItem(rest); err != nil {
+				return nil, err
+			}
+			key = key[:len(key)-len(rest)]
+			if prevKey != nil && bytes.Compare(prevKey, key) >= 0 {
+				return nil, fmt.Errorf("%w: map keys not sorted", ErrNonDeterministicEncoding)
+			}
+			prevKey = key
+			if rest, err = checkDeterministicItem(rest); err != nil {
+				return nil, err
+			}
+		}
+	case 6: // tag
+		return checkDeterministicItem(rest)
+	}
+	return rest, nil
+}
+
+// checkDeterministicFloat ensures the encoded float is in its shortest form
+// preserving the value, with NaN encoded as 0xf97e00.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8949#section-4.2.2
+func checkDeterministicFloat(data []byte) error {
+	var f float64
+	if err := decMode.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	want, err := encModeCoreDeterministic.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, want) {
+		return fmt.Errorf("%w: non-preferred float", ErrNonDeterministicEncoding)
+	}
+	return nil
+}
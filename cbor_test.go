@@ -2,6 +2,8 @@ package cose
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"testing"
 )
 
@@ -75,3 +77,245 @@ func Test_byteString_UnmarshalCBOR(t *testing.T) {
 		})
 	}
 }
+
+func Test_encodeBstrHead(t *testing.T) {
+	// compare against the encoder for lengths that are cheap to allocate
+	for _, length := range []uint64{0, 1, 23, 24, 255, 256, 65535, 65536} {
+		want, err := encMode.Marshal(make([]byte, length))
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		want = want[:len(want)-int(length)]
+		if got := encodeBstrHead(length); !bytes.Equal(got, want) {
+			t.Errorf("encodeBstrHead(%d) = %x, want %x", length, got, want)
+		}
+	}
+
+	tests := []struct {
+		length uint64
+		want   []byte
+	}{
+		{1<<32 - 1, []byte{0x5a, 0xff, 0xff, 0xff, 0xff}},
+		{1 << 32, []byte{0x5b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		if got := encodeBstrHead(tt.length); !bytes.Equal(got, tt.want) {
+			t.Errorf("encodeBstrHead(%d) = %x, want %x", tt.length, got, tt.want)
+		}
+	}
+}
+
+func Test_checkDeterministicItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name: "small int",
+			data: []byte{0x17},
+		},
+		{
+			name: "uint8",
+			data: []byte{0x18, 0x18},
+		},
+		{
+			name: "uint64",
+			data: []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name: "negative int",
+			data: []byte{0x39, 0x01, 0x00},
+		},
+		{
+			name: "bstr",
+			data: []byte{0x43, 0x66, 0x6f, 0x6f},
+		},
+		{
+			name: "tagged array",
+			data: []byte{0xd2, 0x82, 0x01, 0x61, 0x61},
+		},
+		{
+			name: "sorted map",
+			data: []byte{0xa3, 0x01, 0x00, 0x18, 0x18, 0x00, 0x20, 0x00},
+		},
+		{
+			name: "sorted map with bstr keys",
+			data: []byte{0xa2, 0x41, 0x01, 0x00, 0x41, 0x02, 0x00},
+		},
+		{
+			name: "half float",
+			data: []byte{0xf9, 0x3c, 0x00},
+		},
+		{
+			name: "single float",
+			data: []byte{0xfa, 0x47, 0xc3, 0x50, 0x00},
+		},
+		{
+			name: "simple value",
+			data: []byte{0xf8, 0xff},
+		},
+		{
+			name:    "non-shortest uint8",
+			data:    []byte{0x18, 0x17},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest uint16",
+			data:    []byte{0x19, 0x00, 0xff},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest uint32",
+			data:    []byte{0x1a, 0x00, 0x00, 0xff, 0xff},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest uint64",
+			data:    []byte{0x1b, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest bstr length",
+			data:    []byte{0x58, 0x03, 0x66, 0x6f, 0x6f},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest tag",
+			data:    []byte{0xd8, 0x12, 0x80},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest item in array",
+			data:    []byte{0x82, 0x01, 0x18, 0x01},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "unsorted map",
+			data:    []byte{0xa2, 0x20, 0x00, 0x01, 0x00},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "duplicated map keys",
+			data:    []byte{0xa2, 0x01, 0x00, 0x01, 0x00},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest map value",
+			data:    []byte{0xa1, 0x01, 0x18, 0x00},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest float",
+			data:    []byte{0xfa, 0x3f, 0x80, 0x00, 0x00},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-shortest double",
+			data:    []byte{0xfb, 0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "non-canonical NaN",
+			data:    []byte{0xf9, 0x7e, 0x01},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "indefinite length",
+			data:    []byte{0x5f, 0x41, 0x00, 0xff},
+			wantErr: ErrNonDeterministicEncoding,
+		},
+		{
+			name:    "truncated bstr",
+			data:    []byte{0x43, 0x66, 0x6f},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "truncated head",
+			data:    []byte{0x19, 0x01},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, err := checkDeterministicItem(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkDeterministicItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(rest) != 0 {
+				t.Errorf("checkDeterministicItem() rest = %x, want empty", rest)
+			}
+		})
+	}
+}
+
+func TestSetDecOptions(t *testing.T) {
+	t.Cleanup(func() {
+		if err := SetDecOptions(DecOptions{}); err != nil {
+			t.Fatalf("SetDecOptions() error = %v", err)
+		}
+	})
+
+	// invalid options do not change the limits in effect
+	for _, opts := range []DecOptions{
+		{MaxNestedLevels: 1},
+		{MaxArrayElements: 1},
+		{MaxMapPairs: 1},
+		{MaxMessageSize: -1},
+		{MaxHeaders: -1},
+	} {
+		if err := SetDecOptions(opts); err == nil {
+			t.Errorf("SetDecOptions(%+v) error = nil, wantErr true", opts)
+		}
+	}
+	data := []byte{
+		0xd2, // tag
+		0x84,
+		0x43, 0xa1, 0x01, 0x26, // protected
+		0xa1, 0x03, 0x18, 0x2a, // unprotected
+		0x43, 0x66, 0x6f, 0x6f, // payload
+		0x43, 0x62, 0x61, 0x72, // signature
+	}
+	var msg Sign1Message
+	if err := msg.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("Sign1Message.UnmarshalCBOR() error = %v", err)
+	}
+
+	// limits apply to all decoding
+	if err := SetDecOptions(DecOptions{MaxMessageSize: len(data) - 1}); err != nil {
+		t.Fatalf("SetDecOptions() error = %v", err)
+	}
+	if err := msg.UnmarshalCBOR(data); err == nil {
+		t.Error("Sign1Message.UnmarshalCBOR() error = nil, wantErr true")
+	}
+	if _, err := Unmarshal(data); err == nil {
+		t.Error("Unmarshal() error = nil, wantErr true")
+	}
+	if err := SetDecOptions(DecOptions{MaxHeaders: 1}); err != nil {
+		t.Fatalf("SetDecOptions() error = %v", err)
+	}
+	if err := msg.UnmarshalCBOR(data); err != nil {
+		t.Errorf("Sign1Message.UnmarshalCBOR() error = %v", err)
+	}
+	unprotected := []byte{0xa2, 0x03, 0x18, 0x2a, 0x04, 0x40}
+	if err := decMode.Unmarshal(unprotected, &msg.Headers.Unprotected); err == nil {
+		t.Error("UnprotectedHeader.UnmarshalCBOR() error = nil, wantErr true")
+	}
+	if err := SetDecOptions(DecOptions{Deterministic: true}); err != nil {
+		t.Fatalf("SetDecOptions() error = %v", err)
+	}
+	if _, err := Unmarshal(data); err != nil {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+	data = []byte{
+		0xd2, // tag
+		0x84,
+		0x43, 0xa1, 0x01, 0x26, // protected
+		0xa1, 0x03, 0x19, 0x00, 0x2a, // unprotected
+		0x43, 0x66, 0x6f, 0x6f, // payload
+		0x43, 0x62, 0x61, 0x72, // signature
+	}
+	if _, err := Unmarshal(data); !errors.Is(err, ErrNonDeterministicEncoding) {
+		t.Errorf("Unmarshal() error = %v, wantErr %v", err, ErrNonDeterministicEncoding)
+	}
+}
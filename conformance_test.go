@@ -2,17 +2,12 @@ package cose_test
 
 import (
 	"bytes"
-	"crypto"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rsa"
 	_ "crypto/sha256"
 	_ "crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"math/big"
 	"os"
 	"path/filepath"
 	"strings"
@@ -155,7 +150,7 @@ func testSign1(t *testing.T, tc *TestCase, deterministic bool) {
 	}
 	sig := tc.Sign1
 	sigMsg := cose.NewSign1Message()
-	sigMsg.Payload = mustHexToBytes(sig.Payload)
+	payload := mustHexToBytes(sig.Payload)
 	sigMsg.Headers, err = decodeHeaders(mustHexToBytes(sig.ProtectedHeaders.CBORHex), mustHexToBytes(sig.UnprotectedHeaders.CBORHex))
 	if err != nil {
 		t.Fatal(err)
@@ -164,11 +159,20 @@ func testSign1(t *testing.T, tc *TestCase, deterministic bool) {
 	if sig.External != "" {
 		external = mustHexToBytes(sig.External)
 	}
-	err = sigMsg.Sign(new(zeroSource), external, signer)
+	if sig.Detached {
+		err = sigMsg.SignDetached(new(zeroSource), payload, external, signer)
+	} else {
+		sigMsg.Payload = payload
+		err = sigMsg.Sign(new(zeroSource), external, signer)
+	}
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = sigMsg.Verify(external, verifier)
+	if sig.Detached {
+		err = sigMsg.VerifyDetached(payload, external, verifier)
+	} else {
+		err = sigMsg.Verify(external, verifier)
+	}
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -187,69 +191,81 @@ func testSign1(t *testing.T, tc *TestCase, deterministic bool) {
 }
 
 func getSigner(tc *TestCase, private bool) (cose.Signer, cose.Verifier, error) {
-	pkey, err := getKey(tc.Key, private)
+	key, err := getKey(tc.Key, private)
 	if err != nil {
 		return nil, nil, err
 	}
-	alg := mustNameToAlg(tc.Alg)
-	signer, err := cose.NewSigner(alg, pkey)
-	if err != nil {
-		return nil, nil, err
+	key.Algorithm = mustNameToAlg(tc.Alg)
+	var signer cose.Signer
+	if private {
+		signer, err = key.Signer()
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	verifier, err := cose.NewVerifier(alg, pkey.Public())
+	verifier, err := key.Verifier()
 	if err != nil {
 		return nil, nil, err
 	}
 	return signer, verifier, nil
 }
 
-func getKey(key Key, private bool) (crypto.Signer, error) {
+// getKey converts the JWK-style test key into a COSE_Key.
+func getKey(key Key, private bool) (*cose.Key, error) {
+	var params map[interface{}]interface{}
+	var privateLabels map[int64]string
 	switch key["kty"] {
 	case "RSA":
-		pkey := &rsa.PrivateKey{
-			PublicKey: rsa.PublicKey{
-				N: mustBase64ToBigInt(key["n"]),
-				E: mustBase64ToInt(key["e"]),
-			},
+		params = map[interface{}]interface{}{
+			cose.KeyLabelRSAN: mustBase64ToBytes(key["n"]),
+			cose.KeyLabelRSAE: mustBase64ToBytes(key["e"]),
 		}
-		if private {
-			pkey.D = mustBase64ToBigInt(key["d"])
-			pkey.Primes = []*big.Int{mustBase64ToBigInt(key["p"]), mustBase64ToBigInt(key["q"])}
-			pkey.Precomputed = rsa.PrecomputedValues{
-				Dp:        mustBase64ToBigInt(key["dp"]),
-				Dq:        mustBase64ToBigInt(key["dq"]),
-				Qinv:      mustBase64ToBigInt(key["qi"]),
-				CRTValues: make([]rsa.CRTValue, 0),
-			}
+		privateLabels = map[int64]string{
+			cose.KeyLabelRSAD:    "d",
+			cose.KeyLabelRSAP:    "p",
+			cose.KeyLabelRSAQ:    "q",
+			cose.KeyLabelRSADP:   "dp",
+			cose.KeyLabelRSADQ:   "dq",
+			cose.KeyLabelRSAQInv: "qi",
 		}
-		return pkey, nil
 	case "EC":
-		var c elliptic.Curve
+		var crv cose.Curve
 		switch key["crv"] {
-		case "P-224":
-			c = elliptic.P224()
 		case "P-256":
-			c = elliptic.P256()
+			crv = cose.CurveP256
 		case "P-384":
-			c = elliptic.P384()
+			crv = cose.CurveP384
 		case "P-521":
-			c = elliptic.P521()
+			crv = cose.CurveP521
 		default:
 			return nil, errors.New("unsupported EC curve: " + key["crv"])
 		}
-		pkey := &ecdsa.PrivateKey{
-			PublicKey: ecdsa.PublicKey{
-				X:     mustBase64ToBigInt(key["x"]),
-				Y:     mustBase64ToBigInt(key["y"]),
-				Curve: c,
-			},
+		params = map[interface{}]interface{}{
+			cose.KeyLabelEC2Curve: int64(crv),
+			cose.KeyLabelEC2X:     mustBase64ToBytes(key["x"]),
+			cose.KeyLabelEC2Y:     mustBase64ToBytes(key["y"]),
+		}
+		privateLabels = map[int64]string{
+			cose.KeyLabelEC2D: "d",
 		}
-		if private {
-			pkey.D = mustBase64ToBigInt(key["d"])
+	default:
+		return nil, errors.New("unsupported key type: " + key["kty"])
+	}
+	if private {
+		for label, name := range privateLabels {
+			params[label] = mustBase64ToBytes(key[name])
 		}
-		return pkey, nil
 	}
-	return nil, errors.New("unsupported key type: " + key["kty"])
+	var kty cose.KeyType
+	if key["kty"] == "RSA" {
+		kty = cose.KeyTypeRSA
+	} else {
+		kty = cose.KeyTypeEC2
+	}
+	return &cose.Key{
+		Type:   kty,
+		Params: params,
+	}, nil
 }
 
 // zeroSource is an io.Reader that returns an unlimited number of zero bytes.
@@ -277,10 +293,6 @@ func decodeHeaders(protected, unprotected []byte) (hdr cose.Headers, err error)
 	return hdr, err
 }
 
-func mustBase64ToInt(s string) int {
-	return int(mustBase64ToBigInt(s).Int64())
-}
-
 func mustHexToBytes(s string) []byte {
 	b, err := hex.DecodeString(s)
 	if err != nil {
@@ -289,12 +301,12 @@ func mustHexToBytes(s string) []byte {
 	return b
 }
 
-func mustBase64ToBigInt(s string) *big.Int {
+func mustBase64ToBytes(s string) []byte {
 	val, err := base64.RawURLEncoding.DecodeString(s)
 	if err != nil {
 		panic(err)
 	}
-	return new(big.Int).SetBytes(val)
+	return val
 }
 
 // mustNameToAlg returns the algorithm associated to name.
@@ -0,0 +1,352 @@
+package cose
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CountersignatureV2 represents a decoded COSE_Countersignature carried by the
+// Countersignature version 2 header parameter:
+//
+//	COSE_Countersignature = COSE_Signature
+//
+// A countersignature is computed over a target structure, which is either a
+// Sign1Message, a SignMessage, a Signature, or another CountersignatureV2.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+type CountersignatureV2 struct {
+	Headers   Headers
+	Signature []byte
+}
+
+// NewCountersignatureV2 returns a CountersignatureV2 with header initialized.
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func NewCountersignatureV2() *CountersignatureV2 {
+	return &CountersignatureV2{
+		Headers: Headers{
+			Protected:   ProtectedHeader{},
+			Unprotected: UnprotectedHeader{},
+		},
+	}
+}
+
+// MarshalCBOR encodes CountersignatureV2 into a COSE_Countersignature object.
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (s *CountersignatureV2) MarshalCBOR() ([]byte, error) {
+	if s == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil CountersignatureV2 pointer")
+	}
+	return (*Signature)(s).MarshalCBOR()
+}
+
+// UnmarshalCBOR decodes a COSE_Countersignature object into
+// CountersignatureV2.
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (s *CountersignatureV2) UnmarshalCBOR(data []byte) error {
+	if s == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil CountersignatureV2 pointer")
+	}
+	return (*Signature)(s).UnmarshalCBOR(data)
+}
+
+// Sign signs the target structure using the provided Signer.
+// The target is one of *Sign1Message, *SignMessage, *Signature or
+// *CountersignatureV2, and is required to be signed already.
+// The countersignature is stored in s.Signature, and is not attached to the
+// target.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.3
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (s *CountersignatureV2) Sign(rand io.Reader, signer Signer, target interface{}, external []byte) error {
+	if s == nil {
+		return errors.New("signing nil CountersignatureV2")
+	}
+	if len(s.Signature) > 0 {
+		return errors.New("CountersignatureV2 already has signature bytes")
+	}
+
+	// check algorithm if present.
+	// `alg` header MUST be present if there is no externally supplied data.
+	alg := signer.Algorithm()
+	if err := s.Headers.ensureSigningAlgorithm(alg, external); err != nil {
+		return err
+	}
+
+	// sign the target
+	toBeSigned, err := s.toBeSigned(target, external)
+	if err != nil {
+		return err
+	}
+	sig, err := signContent(rand, signer, toBeSigned)
+	if err != nil {
+		return err
+	}
+
+	s.Signature = sig
+	return nil
+}
+
+// Verify verifies the countersignature over the target structure, returning
+// nil on success or a suitable error if verification fails.
+// The target is one of *Sign1Message, *SignMessage, *Signature or
+// *CountersignatureV2.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.3
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (s *CountersignatureV2) Verify(verifier Verifier, target interface{}, external []byte) error {
+	if s == nil {
+		return errors.New("verifying nil CountersignatureV2")
+	}
+	if len(s.Signature) == 0 {
+		return ErrEmptySignature
+	}
+
+	// check algorithm if present.
+	// `alg` header MUST present if there is no externally supplied data.
+	alg := verifier.Algorithm()
+	if err := s.Headers.ensureVerificationAlgorithm(alg, external); err != nil {
+		return err
+	}
+
+	// verify the target
+	toBeSigned, err := s.toBeSigned(target, external)
+	if err != nil {
+		return err
+	}
+	return verifyContent(verifier, toBeSigned, s.Signature)
+}
+
+// toBeSigned constructs Countersign_structure, computes and returns ToBeSigned.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.3
+func (s *CountersignatureV2) toBeSigned(target interface{}, external []byte) ([]byte, error) {
+	var signProtected cbor.RawMessage
+	signProtected, err := s.Headers.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+	return countersignToBeSigned("CounterSignature", signProtected, target, external)
+}
+
+// countersign0ToBeSigned constructs the abbreviated Countersign_structure,
+// computes and returns ToBeSigned.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.3
+func countersign0ToBeSigned(target interface{}, external []byte) ([]byte, error) {
+	return countersignToBeSigned("CounterSignature0", nil, target, external)
+}
+
+// countersignToBeSigned constructs Countersign_structure, computes and returns
+// ToBeSigned. The sign_protected field is omitted if signProtected is nil.
+// The context is suffixed with "V2" if the other_fields field is present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.3
+func countersignToBeSigned(context string, signProtected cbor.RawMessage, target interface{}, external []byte) ([]byte, error) {
+	// create a Countersign_structure and populate it with the appropriate
+	// fields.
+	//
+	//   Countersign_structure = [
+	//       context : "CounterSignature" / "CounterSignature0" /
+	//                 "CounterSignatureV2" / "CounterSignature0V2",
+	//       body_protected : empty_or_serialized_map,
+	//       ? sign_protected : empty_or_serialized_map,
+	//       external_aad : bstr,
+	//       payload : bstr,
+	//       ? other_fields : [+ bstr ]
+	//   ]
+	bodyProtected, payload, otherFields, err := countersignTargetFields(target)
+	if err != nil {
+		return nil, err
+	}
+	if len(otherFields) > 0 {
+		context += "V2"
+	}
+	if external == nil {
+		external = []byte{}
+	}
+	countersignStructure := []interface{}{
+		context,       // context
+		bodyProtected, // body_protected
+	}
+	if signProtected != nil {
+		countersignStructure = append(countersignStructure, signProtected) // sign_protected
+	}
+	countersignStructure = append(countersignStructure,
+		external, // external_aad
+		payload,  // payload
+	)
+	if len(otherFields) > 0 {
+		countersignStructure = append(countersignStructure, otherFields) // other_fields
+	}
+
+	// create the value ToBeSigned by encoding the Countersign_structure to a
+	// byte string.
+	return encMode.Marshal(countersignStructure)
+}
+
+// countersignTargetFields returns the bstr fields of the target structure: the
+// encoded protected header, the payload, and the remaining bstr fields.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.3
+func countersignTargetFields(target interface{}) (cbor.RawMessage, []byte, [][]byte, error) {
+	var headers *Headers
+	var payload []byte
+	var otherFields [][]byte
+	switch t := target.(type) {
+	case *Sign1Message:
+		if t == nil {
+			return nil, nil, nil, errors.New("countersigning nil Sign1Message")
+		}
+		if t.Payload == nil {
+			return nil, nil, nil, ErrMissingPayload
+		}
+		if len(t.Signature) == 0 {
+			return nil, nil, nil, ErrEmptySignature
+		}
+		headers, payload, otherFields = &t.Headers, t.Payload, [][]byte{t.Signature}
+	case *SignMessage:
+		if t == nil {
+			return nil, nil, nil, errors.New("countersigning nil SignMessage")
+		}
+		if t.Payload == nil {
+			return nil, nil, nil, ErrMissingPayload
+		}
+		headers, payload = &t.Headers, t.Payload
+	case *Signature:
+		if t == nil {
+			return nil, nil, nil, errors.New("countersigning nil Signature")
+		}
+		if len(t.Signature) == 0 {
+			return nil, nil, nil, ErrEmptySignature
+		}
+		headers, payload = &t.Headers, t.Signature
+	case *CountersignatureV2:
+		if t == nil {
+			return nil, nil, nil, errors.New("countersigning nil CountersignatureV2")
+		}
+		if len(t.Signature) == 0 {
+			return nil, nil, nil, ErrEmptySignature
+		}
+		headers, payload = &t.Headers, t.Signature
+	default:
+		return nil, nil, nil, fmt.Errorf("countersigning unsupported target type %T", target)
+	}
+	var protected cbor.RawMessage
+	protected, err := headers.MarshalProtected()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return protected, payload, otherFields, nil
+}
+
+// countersign signs countersig over the target and appends it to the
+// Countersignature version 2 header parameter of headers.
+func countersign(rand io.Reader, headers *Headers, target interface{}, external []byte, signer Signer, countersig *CountersignatureV2) error {
+	sigs, err := headers.CountersignaturesV2()
+	if err != nil {
+		return err
+	}
+	if err := countersig.Sign(rand, signer, target, external); err != nil {
+		return err
+	}
+	headers.SetCountersignaturesV2(append(sigs, countersig)...)
+
+	// the unprotected header is not covered by signatures, and is re-encoded
+	// to include the new countersignature.
+	headers.RawUnprotected = nil
+	return nil
+}
+
+// countersign0 signs the target and sets the abbreviated countersignature to
+// the Countersignature0 version 2 header parameter of headers.
+func countersign0(rand io.Reader, headers *Headers, target interface{}, external []byte, signer Signer) error {
+	if _, ok := headers.lookup(HeaderLabelCounterSignature0V2); ok {
+		return errors.New("abbreviated countersignature already present")
+	}
+	toBeSigned, err := countersign0ToBeSigned(target, external)
+	if err != nil {
+		return err
+	}
+	sig, err := signContent(rand, signer, toBeSigned)
+	if err != nil {
+		return err
+	}
+	headers.SetCountersignature0V2(sig)
+
+	// the unprotected header is not covered by signatures, and is re-encoded
+	// to include the new countersignature.
+	headers.RawUnprotected = nil
+	return nil
+}
+
+// verifyCountersignatures verifies the countersignatures of the
+// Countersignature version 2 header parameter of headers over the target
+// against the corresponding verifier.
+func verifyCountersignatures(headers *Headers, target interface{}, external []byte, verifiers []Verifier) error {
+	sigs, err := headers.CountersignaturesV2()
+	if err != nil {
+		return err
+	}
+	switch len(sigs) {
+	case 0:
+		return ErrNoCountersignatures
+	case len(verifiers):
+		// no ops
+	default:
+		return fmt.Errorf("%d verifiers for %d countersignatures", len(verifiers), len(sigs))
+	}
+	for i, sig := range sigs {
+		if err := sig.Verify(verifiers[i], target, external); err != nil {
+			return fmt.Errorf("countersignature %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// verifyCountersignature0 verifies the abbreviated countersignature of the
+// Countersignature0 version 2 header parameter of headers over the target.
+func verifyCountersignature0(headers *Headers, target interface{}, external []byte, verifier Verifier) error {
+	sig, err := headers.Countersignature0V2()
+	if err != nil {
+		return err
+	}
+	if sig == nil {
+		return ErrNoCountersignatures
+	}
+	if len(sig) == 0 {
+		return ErrEmptySignature
+	}
+	toBeSigned, err := countersign0ToBeSigned(target, external)
+	if err != nil {
+		return err
+	}
+	return verifyContent(verifier, toBeSigned, sig)
+}
@@ -0,0 +1,383 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCountersignatureV2_MarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       *CountersignatureV2
+		want    []byte
+		wantErr string
+	}{
+		{
+			name: "valid countersignature",
+			s: &CountersignatureV2{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmES256,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelKeyID: []byte("42"),
+					},
+				},
+				Signature: []byte("bar"),
+			},
+			want: []byte{
+				0x83,                   // array of size 3
+				0x43, 0xa1, 0x01, 0x26, // protected
+				0xa1, 0x04, 0x42, 0x34, 0x32, // unprotected
+				0x43, 0x62, 0x61, 0x72, // signature
+			},
+		},
+		{
+			name:    "nil countersignature",
+			s:       nil,
+			wantErr: "cbor: MarshalCBOR on nil CountersignatureV2 pointer",
+		},
+		{
+			name: "empty signature",
+			s: &CountersignatureV2{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmES256,
+					},
+				},
+			},
+			wantErr: ErrEmptySignature.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.s.MarshalCBOR()
+			if err != nil && (err.Error() != tt.wantErr) {
+				t.Errorf("CountersignatureV2.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err == nil && (tt.wantErr != "") {
+				t.Errorf("CountersignatureV2.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("CountersignatureV2.MarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountersignatureV2_UnmarshalCBOR(t *testing.T) {
+	data := []byte{
+		0x83,                   // array of size 3
+		0x43, 0xa1, 0x01, 0x26, // protected
+		0xa1, 0x04, 0x42, 0x34, 0x32, // unprotected
+		0x43, 0x62, 0x61, 0x72, // signature
+	}
+	var got CountersignatureV2
+	if err := got.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("CountersignatureV2.UnmarshalCBOR() error = %v", err)
+	}
+	want := CountersignatureV2{
+		Headers: Headers{
+			RawProtected: []byte{0x43, 0xa1, 0x01, 0x26},
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+			RawUnprotected: []byte{0xa1, 0x04, 0x42, 0x34, 0x32},
+			Unprotected: UnprotectedHeader{
+				HeaderLabelKeyID: []byte("42"),
+			},
+		},
+		Signature: []byte("bar"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CountersignatureV2.UnmarshalCBOR() = %v, want %v", got, want)
+	}
+
+	// invalid object
+	if err := got.UnmarshalCBOR([]byte{0x82, 0x40, 0xa0}); err == nil {
+		t.Error("CountersignatureV2.UnmarshalCBOR() error = nil, wantErr true")
+	}
+
+	// nil pointer
+	var nilSig *CountersignatureV2
+	if err := nilSig.UnmarshalCBOR(data); err == nil {
+		t.Error("CountersignatureV2.UnmarshalCBOR() error = nil, wantErr true")
+	}
+}
+
+func TestCountersignatureV2_Sign(t *testing.T) {
+	newCountersignature := func() *CountersignatureV2 {
+		return &CountersignatureV2{
+			Headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: algorithmMock,
+				},
+			},
+		}
+	}
+	sign1 := &Sign1Message{
+		Headers: Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: algorithmMock,
+			},
+		},
+		Payload:   []byte("foo"),
+		Signature: []byte{0x01, 0x02},
+	}
+	tests := []struct {
+		name       string
+		target     interface{}
+		toBeSigned []byte
+	}{
+		{
+			name:   "Sign1Message target",
+			target: sign1,
+			toBeSigned: []byte{
+				0x86,                                                                                                             // array of size 6
+				0x72, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x56, 0x32, // context
+				0x47, 0xa1, 0x01, 0x3a, 0x6d, 0x6f, 0x63, 0x6a, // body_protected
+				0x47, 0xa1, 0x01, 0x3a, 0x6d, 0x6f, 0x63, 0x6a, // sign_protected
+				0x40,                   // external_aad
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x81, 0x42, 0x01, 0x02, // other_fields
+			},
+		},
+		{
+			name: "SignMessage target",
+			target: &SignMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: algorithmMock,
+					},
+				},
+				Payload: []byte("foo"),
+			},
+			toBeSigned: []byte{
+				0x85,                                                                                                 // array of size 5
+				0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, // context
+				0x47, 0xa1, 0x01, 0x3a, 0x6d, 0x6f, 0x63, 0x6a, // body_protected
+				0x47, 0xa1, 0x01, 0x3a, 0x6d, 0x6f, 0x63, 0x6a, // sign_protected
+				0x40,                   // external_aad
+				0x43, 0x66, 0x6f, 0x6f, // payload
+			},
+		},
+		{
+			name: "Signature target",
+			target: &Signature{
+				Signature: []byte{0x01, 0x02},
+			},
+			toBeSigned: []byte{
+				0x85,                                                                                                 // array of size 5
+				0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, // context
+				0x40,                                           // body_protected
+				0x47, 0xa1, 0x01, 0x3a, 0x6d, 0x6f, 0x63, 0x6a, // sign_protected
+				0x40,             // external_aad
+				0x42, 0x01, 0x02, // payload
+			},
+		},
+		{
+			name: "CountersignatureV2 target",
+			target: &CountersignatureV2{
+				Signature: []byte{0x01, 0x02},
+			},
+			toBeSigned: []byte{
+				0x85,                                                                                                 // array of size 5
+				0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, // context
+				0x40,                                           // body_protected
+				0x47, 0xa1, 0x01, 0x3a, 0x6d, 0x6f, 0x63, 0x6a, // sign_protected
+				0x40,             // external_aad
+				0x42, 0x01, 0x02, // payload
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := make([]byte, 64)
+			if _, err := rand.Read(sig); err != nil {
+				t.Fatalf("rand.Read() error = %v", err)
+			}
+			signer := newMockSigner(t)
+			signer.setup(tt.toBeSigned, sig)
+
+			s := newCountersignature()
+			if err := s.Sign(rand.Reader, signer, tt.target, nil); err != nil {
+				t.Fatalf("CountersignatureV2.Sign() error = %v", err)
+			}
+			if !bytes.Equal(s.Signature, sig) {
+				t.Errorf("CountersignatureV2.Sign() signature = %v, want %v", s.Signature, sig)
+			}
+		})
+	}
+
+	// abbreviated countersignature
+	sig := []byte{0x03, 0x04}
+	signer := newMockSigner(t)
+	signer.setup([]byte{
+		0x85,                                                                                                                   // array of size 5
+		0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x30, 0x56, 0x32, // context
+		0x47, 0xa1, 0x01, 0x3a, 0x6d, 0x6f, 0x63, 0x6a, // body_protected
+		0x40,                   // external_aad
+		0x43, 0x66, 0x6f, 0x6f, // payload
+		0x81, 0x42, 0x01, 0x02, // other_fields
+	}, sig)
+	msg := *sign1
+	if err := msg.Countersign0(rand.Reader, nil, signer); err != nil {
+		t.Fatalf("Sign1Message.Countersign0() error = %v", err)
+	}
+	if got, err := msg.Headers.Countersignature0V2(); err != nil || !bytes.Equal(got, sig) {
+		t.Errorf("Headers.Countersignature0V2() = %v, %v, want %v", got, err, sig)
+	}
+}
+
+func TestCountersignatureV2_Sign_Invalid(t *testing.T) {
+	_, key := generateTestEd25519Key(t)
+	signer, err := NewSigner(AlgorithmEd25519, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		s       *CountersignatureV2
+		target  interface{}
+		wantErr error
+	}{
+		{
+			name:   "nil countersignature",
+			target: &Signature{Signature: []byte{0x01}},
+		},
+		{
+			name:   "already signed",
+			s:      &CountersignatureV2{Signature: []byte{0x01}},
+			target: &Signature{Signature: []byte{0x01}},
+		},
+		{
+			name:   "unsupported target",
+			s:      NewCountersignatureV2(),
+			target: []byte("foo"),
+		},
+		{
+			name:   "nil target",
+			s:      NewCountersignatureV2(),
+			target: (*Sign1Message)(nil),
+		},
+		{
+			name:    "unsigned Sign1Message",
+			s:       NewCountersignatureV2(),
+			target:  &Sign1Message{Payload: []byte("foo")},
+			wantErr: ErrEmptySignature,
+		},
+		{
+			name:    "detached Sign1Message",
+			s:       NewCountersignatureV2(),
+			target:  &Sign1Message{Signature: []byte{0x01}},
+			wantErr: ErrMissingPayload,
+		},
+		{
+			name:    "unsigned Signature",
+			s:       NewCountersignatureV2(),
+			target:  &Signature{},
+			wantErr: ErrEmptySignature,
+		},
+		{
+			name: "algorithm mismatch",
+			s: &CountersignatureV2{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmES256,
+					},
+				},
+			},
+			target:  &Signature{Signature: []byte{0x01}},
+			wantErr: ErrAlgorithmMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.s.Sign(rand.Reader, signer, tt.target, nil)
+			if err == nil {
+				t.Fatal("CountersignatureV2.Sign() error = nil, wantErr true")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("CountersignatureV2.Sign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCountersignatureV2_Verify(t *testing.T) {
+	alg := AlgorithmES256
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(alg, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	target := &Signature{
+		Headers: Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmEd25519,
+			},
+		},
+		Signature: []byte("signature"),
+	}
+	s := NewCountersignatureV2()
+	s.Headers.Protected.SetAlgorithm(alg)
+	if err := s.Sign(rand.Reader, signer, target, []byte("external")); err != nil {
+		t.Fatalf("CountersignatureV2.Sign() error = %v", err)
+	}
+	if err := s.Verify(verifier, target, []byte("external")); err != nil {
+		t.Errorf("CountersignatureV2.Verify() error = %v", err)
+	}
+
+	// countersignature over the countersignature
+	s2 := NewCountersignatureV2()
+	s2.Headers.Protected.SetAlgorithm(alg)
+	if err := s2.Sign(rand.Reader, signer, s, nil); err != nil {
+		t.Fatalf("CountersignatureV2.Sign() error = %v", err)
+	}
+	if err := s2.Verify(verifier, s, nil); err != nil {
+		t.Errorf("CountersignatureV2.Verify() error = %v", err)
+	}
+
+	// wrong external
+	if err := s.Verify(verifier, target, nil); err != ErrVerification {
+		t.Errorf("CountersignatureV2.Verify() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// tampered target
+	tampered := *target
+	tampered.Signature = []byte("tampered")
+	if err := s.Verify(verifier, &tampered, []byte("external")); err != ErrVerification {
+		t.Errorf("CountersignatureV2.Verify() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// tampered body protected header
+	tampered = *target
+	tampered.Headers = Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: AlgorithmES256,
+		},
+	}
+	if err := s.Verify(verifier, &tampered, []byte("external")); err != ErrVerification {
+		t.Errorf("CountersignatureV2.Verify() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// empty signature
+	if err := NewCountersignatureV2().Verify(verifier, target, nil); err != ErrEmptySignature {
+		t.Errorf("CountersignatureV2.Verify() error = %v, wantErr %v", err, ErrEmptySignature)
+	}
+
+	// nil countersignature
+	var nilSig *CountersignatureV2
+	if err := nilSig.Verify(verifier, target, nil); err == nil {
+		t.Error("CountersignatureV2.Verify() error = nil, wantErr true")
+	}
+}
@@ -0,0 +1,44 @@
+package cwt
+
+import "github.com/fxamacker/cbor/v2"
+
+// CBORTagCWT is the CBOR tag of a CWT.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-6
+const CBORTagCWT = 61
+
+// cwtTagPrefix represents the encoded CBOR tag of a CWT.
+var cwtTagPrefix = []byte{
+	0xd8, 0x3d, // #6.61
+}
+
+// Pre-configured modes for CBOR encoding and decoding.
+var (
+	encMode cbor.EncMode
+	decMode cbor.DecMode
+)
+
+func init() {
+	var err error
+
+	// init encode mode
+	encOpts := cbor.EncOptions{
+		Sort:        cbor.SortCoreDeterministic, // sort map keys
+		IndefLength: cbor.IndefLengthForbidden,  // no streaming
+	}
+	encMode, err = encOpts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+
+	// init decode mode
+	decOpts := cbor.DecOptions{
+		DupMapKey:   cbor.DupMapKeyEnforcedAPF, // duplicated key not allowed
+		IndefLength: cbor.IndefLengthForbidden, // no streaming
+		IntDec:      cbor.IntDecConvertSigned,  // decode CBOR uint/int to Go int64
+	}
+	decMode, err = decOpts.DecMode()
+	if err != nil {
+		panic(err)
+	}
+}
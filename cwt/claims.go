@@ -0,0 +1,284 @@
+package cwt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/veraison/go-cose"
+)
+
+// Confirmation methods registered in the IANA "CWT Confirmation Methods"
+// registry.
+//
+// Reference: https://www.iana.org/assignments/cwt/cwt.xhtml#confirmation-methods
+const (
+	ConfirmationKeyCOSEKey          int64 = 1
+	ConfirmationKeyEncryptedCOSEKey int64 = 2
+	ConfirmationKeyKeyID            int64 = 3
+)
+
+// Claims represents the claims set of a CWT.
+//
+// The registered claims, keyed by the cose.CWTClaim constants, are decoded
+// into the typed fields, where zero values stand for absent claims. The time
+// claims are NumericDate values, encoded as integer seconds since the Unix
+// epoch.
+// Extra holds any other claim, including private claims and claims with string
+// keys. Integer keys of Extra are stored as int64.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-3
+type Claims struct {
+	Issuer         string
+	Subject        string
+	Audience       string
+	ExpirationTime time.Time
+	NotBefore      time.Time
+	IssuedAt       time.Time
+	CWTID          []byte
+	Confirmation   *Confirmation
+	Extra          map[interface{}]interface{}
+}
+
+// MarshalCBOR encodes Claims into a CBOR map.
+func (c *Claims) MarshalCBOR() ([]byte, error) {
+	if c == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil Claims pointer")
+	}
+	m := make(map[interface{}]interface{}, len(c.Extra)+8)
+	for key, value := range c.Extra {
+		key, ok := normalizeKey(key)
+		if !ok {
+			return nil, errors.New("cwt: claim key: require int / tstr type")
+		}
+		if k, ok := key.(int64); ok && k >= cose.CWTClaimIssuer && k <= cose.CWTClaimConfirmation {
+			return nil, fmt.Errorf("cwt: registered claim %d in extra claims", k)
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("cwt: duplicated claim key: %v", key)
+		}
+		m[key] = value
+	}
+	if c.Issuer != "" {
+		m[cose.CWTClaimIssuer] = c.Issuer
+	}
+	if c.Subject != "" {
+		m[cose.CWTClaimSubject] = c.Subject
+	}
+	if c.Audience != "" {
+		m[cose.CWTClaimAudience] = c.Audience
+	}
+	if !c.ExpirationTime.IsZero() {
+		m[cose.CWTClaimExpirationTime] = c.ExpirationTime.Unix()
+	}
+	if !c.NotBefore.IsZero() {
+		m[cose.CWTClaimNotBefore] = c.NotBefore.Unix()
+	}
+	if !c.IssuedAt.IsZero() {
+		m[cose.CWTClaimIssuedAt] = c.IssuedAt.Unix()
+	}
+	if c.CWTID != nil {
+		m[cose.CWTClaimCWTID] = c.CWTID
+	}
+	if c.Confirmation != nil {
+		m[cose.CWTClaimConfirmation] = c.Confirmation
+	}
+	return encMode.Marshal(m)
+}
+
+// UnmarshalCBOR decodes a CBOR map into Claims.
+func (c *Claims) UnmarshalCBOR(data []byte) error {
+	if c == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil Claims pointer")
+	}
+	var m map[interface{}]cbor.RawMessage
+	if err := decMode.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	var claims Claims
+	for key, raw := range m {
+		var err error
+		switch key {
+		case cose.CWTClaimIssuer:
+			claims.Issuer, err = decodeText(raw)
+		case cose.CWTClaimSubject:
+			claims.Subject, err = decodeText(raw)
+		case cose.CWTClaimAudience:
+			claims.Audience, err = decodeText(raw)
+		case cose.CWTClaimExpirationTime:
+			claims.ExpirationTime, err = decodeNumericDate(raw)
+		case cose.CWTClaimNotBefore:
+			claims.NotBefore, err = decodeNumericDate(raw)
+		case cose.CWTClaimIssuedAt:
+			claims.IssuedAt, err = decodeNumericDate(raw)
+		case cose.CWTClaimCWTID:
+			claims.CWTID, err = decodeBytes(raw)
+		case cose.CWTClaimConfirmation:
+			claims.Confirmation = &Confirmation{}
+			err = claims.Confirmation.UnmarshalCBOR(raw)
+		default:
+			switch key.(type) {
+			case int64, string:
+			default:
+				return errors.New("cwt: claim key: require int / tstr type")
+			}
+			var value interface{}
+			if err = decMode.Unmarshal(raw, &value); err == nil {
+				if claims.Extra == nil {
+					claims.Extra = make(map[interface{}]interface{})
+				}
+				claims.Extra[key] = value
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("cwt: claim %v: %w", key, err)
+		}
+	}
+	*c = claims
+	return nil
+}
+
+// Confirmation represents the `cnf` claim, which declares the
+// proof-of-possession key of the presenter of a CWT.
+// Exactly one of the confirmation methods is expected to be present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8747#section-3
+type Confirmation struct {
+	// Key is the COSE_Key of the proof-of-possession key.
+	Key *cose.Key
+
+	// EncryptedKey is the encoded COSE_Encrypt or COSE_Encrypt0 object of the
+	// encrypted COSE_Key of the proof-of-possession key.
+	EncryptedKey cbor.RawMessage
+
+	// KeyID is the key identifier of the proof-of-possession key.
+	KeyID []byte
+}
+
+// MarshalCBOR encodes Confirmation into a CBOR map.
+func (c *Confirmation) MarshalCBOR() ([]byte, error) {
+	if c == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil Confirmation pointer")
+	}
+	m := make(map[int64]interface{}, 1)
+	if c.Key != nil {
+		m[ConfirmationKeyCOSEKey] = c.Key
+	}
+	if len(c.EncryptedKey) > 0 {
+		m[ConfirmationKeyEncryptedCOSEKey] = c.EncryptedKey
+	}
+	if c.KeyID != nil {
+		m[ConfirmationKeyKeyID] = c.KeyID
+	}
+	if len(m) == 0 {
+		return nil, errors.New("cwt: cnf: missing confirmation method")
+	}
+	return encMode.Marshal(m)
+}
+
+// UnmarshalCBOR decodes a CBOR map into Confirmation.
+func (c *Confirmation) UnmarshalCBOR(data []byte) error {
+	if c == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil Confirmation pointer")
+	}
+	var m map[int64]cbor.RawMessage
+	if err := decMode.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if len(m) == 0 {
+		return errors.New("missing confirmation method")
+	}
+	var cnf Confirmation
+	for key, raw := range m {
+		var err error
+		switch key {
+		case ConfirmationKeyCOSEKey:
+			cnf.Key = &cose.Key{}
+			err = cnf.Key.UnmarshalCBOR(raw)
+		case ConfirmationKeyEncryptedCOSEKey:
+			cnf.EncryptedKey = raw
+		case ConfirmationKeyKeyID:
+			cnf.KeyID, err = decodeBytes(raw)
+		default:
+			return fmt.Errorf("unsupported confirmation method %d", key)
+		}
+		if err != nil {
+			return fmt.Errorf("confirmation method %d: %w", key, err)
+		}
+	}
+	*c = cnf
+	return nil
+}
+
+// decodeText decodes a CBOR text string.
+func decodeText(data []byte) (string, error) {
+	if len(data) == 0 || data[0]>>5 != 3 { // major type 3: tstr
+		return "", errors.New("require tstr type")
+	}
+	var s string
+	err := decMode.Unmarshal(data, &s)
+	return s, err
+}
+
+// decodeBytes decodes a CBOR byte string.
+func decodeBytes(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0]>>5 != 2 { // major type 2: bstr
+		return nil, errors.New("require bstr type")
+	}
+	var b []byte
+	err := decMode.Unmarshal(data, &b)
+	return b, err
+}
+
+// decodeNumericDate decodes a NumericDate, which is an untagged CBOR integer or
+// floating-point number of seconds since the Unix epoch.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-2
+func decodeNumericDate(data []byte) (time.Time, error) {
+	var value interface{}
+	if err := decMode.Unmarshal(data, &value); err != nil {
+		return time.Time{}, err
+	}
+	switch v := value.(type) {
+	case int64:
+		return time.Unix(v, 0), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return time.Time{}, errors.New("invalid NumericDate")
+		}
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+	return time.Time{}, errors.New("require int / float type")
+}
+
+// normalizeKey converts the integer types of a claim key to int64.
+func normalizeKey(key interface{}) (interface{}, bool) {
+	switch k := key.(type) {
+	case int:
+		return int64(k), true
+	case int8:
+		return int64(k), true
+	case int16:
+		return int64(k), true
+	case int32:
+		return int64(k), true
+	case int64:
+		return k, true
+	case uint:
+		return int64(k), uint64(k) <= math.MaxInt64
+	case uint8:
+		return int64(k), true
+	case uint16:
+		return int64(k), true
+	case uint32:
+		return int64(k), true
+	case uint64:
+		return int64(k), k <= math.MaxInt64
+	case string:
+		return k, true
+	}
+	return nil, false
+}
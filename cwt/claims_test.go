@@ -0,0 +1,285 @@
+package cwt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/veraison/go-cose"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex.DecodeString() error = %v", err)
+	}
+	return b
+}
+
+// rfc8392ClaimsHex is the claims set of RFC 8392 Appendix A.1.
+const rfc8392ClaimsHex = "a70175636f61703a2f2f61732e6578616d706c652e636f6d02656572696b77" +
+	"037818636f61703a2f2f6c696768742e6578616d706c652e636f6d041a5612ae" +
+	"b0051a5610d9f0061a5610d9f007420b71"
+
+// rfc8392Claims is the decoded claims set of RFC 8392 Appendix A.1.
+var rfc8392Claims = Claims{
+	Issuer:         "coap://as.example.com",
+	Subject:        "erikw",
+	Audience:       "coap://light.example.com",
+	ExpirationTime: time.Unix(1444064944, 0),
+	NotBefore:      time.Unix(1443944944, 0),
+	IssuedAt:       time.Unix(1443944944, 0),
+	CWTID:          []byte{0x0b, 0x71},
+}
+
+func TestClaims_MarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  *Claims
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:   "RFC 8392 claims set",
+			claims: &rfc8392Claims,
+			want:   mustDecodeHex(t, rfc8392ClaimsHex),
+		},
+		{
+			name:   "empty claims set",
+			claims: &Claims{},
+			want:   []byte{0xa0},
+		},
+		{
+			name: "extra claims",
+			claims: &Claims{
+				Subject: "foo",
+				Extra: map[interface{}]interface{}{
+					int8(-1): "bar",
+					"baz":    true,
+				},
+			},
+			want: []byte{
+				0xa3,                      // map
+				0x02, 0x63, 'f', 'o', 'o', // sub
+				0x20, 0x63, 'b', 'a', 'r', // -1
+				0x63, 'b', 'a', 'z', 0xf5, // baz
+			},
+		},
+		{
+			name: "confirmation key ID",
+			claims: &Claims{
+				Confirmation: &Confirmation{
+					KeyID: []byte{0x01},
+				},
+			},
+			want: []byte{
+				0xa1,       // map
+				0x08, 0xa1, // cnf
+				0x03, 0x41, 0x01, // kid
+			},
+		},
+		{
+			name:    "nil claims",
+			claims:  nil,
+			wantErr: true,
+		},
+		{
+			name: "registered claim in extra claims",
+			claims: &Claims{
+				Extra: map[interface{}]interface{}{
+					1: "foo",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated extra claims",
+			claims: &Claims{
+				Extra: map[interface{}]interface{}{
+					int(-1):   "foo",
+					int64(-1): "bar",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid extra claim key",
+			claims: &Claims{
+				Extra: map[interface{}]interface{}{
+					1.5: "foo",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty confirmation",
+			claims: &Claims{
+				Confirmation: &Confirmation{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.claims.MarshalCBOR()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Claims.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Claims.MarshalCBOR() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaims_UnmarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Claims
+		wantErr bool
+	}{
+		{
+			name: "RFC 8392 claims set",
+			data: mustDecodeHex(t, rfc8392ClaimsHex),
+			want: rfc8392Claims,
+		},
+		{
+			name: "empty claims set",
+			data: []byte{0xa0},
+			want: Claims{},
+		},
+		{
+			name: "floating-point NumericDate",
+			data: []byte{
+				0xa1,                                                       // map
+				0x04, 0xfb, 0x41, 0xd5, 0x84, 0xab, 0xac, 0x20, 0x00, 0x00, // exp: 1444064944.5
+			},
+			want: Claims{
+				ExpirationTime: time.Unix(1444064944, 500000000),
+			},
+		},
+		{
+			name: "extra claims",
+			data: []byte{
+				0xa2,                      // map
+				0x20, 0x63, 'b', 'a', 'r', // -1
+				0x63, 'b', 'a', 'z', 0x01, // baz
+			},
+			want: Claims{
+				Extra: map[interface{}]interface{}{
+					int64(-1): "bar",
+					"baz":     int64(1),
+				},
+			},
+		},
+		{
+			name: "confirmation key",
+			data: []byte{
+				0xa1,       // map
+				0x08, 0xa1, // cnf
+				0x01, 0xa2, // COSE_Key
+				0x01, 0x04, // kty: Symmetric
+				0x20, 0x41, 0x01, // k
+			},
+			want: Claims{
+				Confirmation: &Confirmation{
+					Key: &cose.Key{
+						Type: cose.KeyTypeSymmetric,
+						Params: map[interface{}]interface{}{
+							int64(-1): []byte{0x01},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "confirmation encrypted key",
+			data: []byte{
+				0xa1,       // map
+				0x08, 0xa1, // cnf
+				0x02, 0x84, 0x40, 0xa0, 0x41, 0x01, 0xf6, // COSE_Encrypt0
+			},
+			want: Claims{
+				Confirmation: &Confirmation{
+					EncryptedKey: []byte{0x84, 0x40, 0xa0, 0x41, 0x01, 0xf6},
+				},
+			},
+		},
+		{
+			name:    "not a map",
+			data:    []byte{0x80},
+			wantErr: true,
+		},
+		{
+			name:    "invalid issuer",
+			data:    []byte{0xa1, 0x01, 0x41, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "invalid CWT ID",
+			data:    []byte{0xa1, 0x07, 0x61, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "tagged NumericDate",
+			data:    []byte{0xa1, 0x04, 0xc1, 0x1a, 0x56, 0x12, 0xae, 0xb0},
+			wantErr: true,
+		},
+		{
+			name:    "string NumericDate",
+			data:    []byte{0xa1, 0x04, 0x61, 0x31},
+			wantErr: true,
+		},
+		{
+			name:    "NaN NumericDate",
+			data:    []byte{0xa1, 0x04, 0xf9, 0x7e, 0x00},
+			wantErr: true,
+		},
+		{
+			name:    "invalid claim key",
+			data:    []byte{0xa1, 0xf5, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "empty confirmation",
+			data:    []byte{0xa1, 0x08, 0xa0},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported confirmation method",
+			data:    []byte{0xa1, 0x08, 0xa1, 0x04, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "invalid confirmation key",
+			data:    []byte{0xa1, 0x08, 0xa1, 0x01, 0xa0},
+			wantErr: true,
+		},
+		{
+			name:    "invalid confirmation key ID",
+			data:    []byte{0xa1, 0x08, 0xa1, 0x03, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "duplicated claim",
+			data:    []byte{0xa2, 0x02, 0x61, 0x61, 0x02, 0x61, 0x62},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Claims
+			if err := got.UnmarshalCBOR(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("Claims.UnmarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Claims.UnmarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package cwt implements CBOR Web Tokens (CWT) secured by COSE_Sign1 and
+// COSE_Mac0 messages.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392
+package cwt
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/veraison/go-cose"
+)
+
+// Sign encodes the claims as the payload of a COSE_Sign1 message signed by the
+// provided Signer, and returns the encoded CWT.
+// The returned CWT is a COSE_Sign1_Tagged object. Use Tag to prefix it with the
+// CWT tag if required by the application.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-7.1
+func Sign(rand io.Reader, signer cose.Signer, headers cose.Headers, claims *Claims) ([]byte, error) {
+	payload, err := claims.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+	return cose.Sign1(rand, signer, headers, payload, nil)
+}
+
+// Parse verifies a CWT secured by a COSE_Sign1 message using the provided
+// Verifier, and returns its claims.
+// The CWT may be prefixed with the CWT tag.
+//
+// Parse does not validate the claims. See Validator.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-7.2
+func Parse(data []byte, verifier cose.Verifier) (*Claims, error) {
+	var msg cose.Sign1Message
+	if err := msg.UnmarshalCBOR(Untag(data)); err != nil {
+		return nil, err
+	}
+	if err := msg.Verify(nil, verifier); err != nil {
+		return nil, err
+	}
+	var claims Claims
+	if err := claims.UnmarshalCBOR(msg.Payload); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// MAC encodes the claims as the payload of a COSE_Mac0 message authenticated by
+// the provided MACer, and returns the encoded CWT.
+// The returned CWT is a COSE_Mac0_Tagged object. Use Tag to prefix it with the
+// CWT tag if required by the application.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-7.1
+func MAC(macer cose.MACer, headers cose.Headers, claims *Claims) ([]byte, error) {
+	payload, err := claims.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+	return cose.Mac0(macer, headers, payload, nil)
+}
+
+// ParseMAC verifies a CWT secured by a COSE_Mac0 message using the provided
+// MACVerifier, and returns its claims.
+// The CWT may be prefixed with the CWT tag.
+//
+// ParseMAC does not validate the claims. See Validator.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-7.2
+func ParseMAC(data []byte, verifier cose.MACVerifier) (*Claims, error) {
+	var msg cose.Mac0Message
+	if err := msg.UnmarshalCBOR(Untag(data)); err != nil {
+		return nil, err
+	}
+	if err := msg.Verify(nil, verifier); err != nil {
+		return nil, err
+	}
+	var claims Claims
+	if err := claims.UnmarshalCBOR(msg.Payload); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// Tag prefixes an encoded CWT with the CWT tag.
+// The CWT is returned unchanged if it is already tagged.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-6
+func Tag(data []byte) []byte {
+	if bytes.HasPrefix(data, cwtTagPrefix) {
+		return data
+	}
+	tagged := make([]byte, 0, len(cwtTagPrefix)+len(data))
+	tagged = append(tagged, cwtTagPrefix...)
+	return append(tagged, data...)
+}
+
+// Untag removes the CWT tag from an encoded CWT if present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-6
+func Untag(data []byte) []byte {
+	return bytes.TrimPrefix(data, cwtTagPrefix)
+}
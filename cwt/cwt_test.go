@@ -0,0 +1,283 @@
+package cwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	_ "crypto/sha256"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/veraison/go-cose"
+)
+
+// rfc8392SignedCWTHex is the signed CWT of RFC 8392 Appendix A.3.
+const rfc8392SignedCWTHex = "d28443a10126a104524173796d6d657472696345434453413235365850a70175" +
+	"636f61703a2f2f61732e6578616d706c652e636f6d02656572696b7703781863" +
+	"6f61703a2f2f6c696768742e6578616d706c652e636f6d041a5612aeb0051a56" +
+	"10d9f0061a5610d9f007420b7158405427c1ff28d23fbad1f29c4c7c6a555e60" +
+	"1d6fa29f9179bc3d7438bacaca5acd08c8d4d4f96131680c429a01f85951ecee" +
+	"743a52b9b63632c57209120e1c9e30"
+
+// rfc8392MACedCWTHex is the tagged MACed CWT of RFC 8392 Appendix A.4.
+const rfc8392MACedCWTHex = "d83dd18443a10104a1044c53796d6d65747269633235365850a70175636f6170" +
+	"3a2f2f61732e6578616d706c652e636f6d02656572696b77037818636f61703a" +
+	"2f2f6c696768742e6578616d706c652e636f6d041a5612aeb0051a5610d9f006" +
+	"1a5610d9f007420b7148093101ef6d789200"
+
+func rfc8392Verifier(t *testing.T) cose.Verifier {
+	pub := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(mustDecodeHex(t, "143329cce7868e416927599cf65a34f3ce2ffda55a7eca69ed8919a394d42f0f")),
+		Y:     new(big.Int).SetBytes(mustDecodeHex(t, "60f7f1a780d8a783bfb7a2dd6b2796e8128dbbcef9d3d168db9529971a36e7b9")),
+	}
+	verifier, err := cose.NewVerifier(cose.AlgorithmES256, pub)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	return verifier
+}
+
+func rfc8392MACVerifier(t *testing.T) cose.MACVerifier {
+	key := mustDecodeHex(t, "403697de87af64611c1d32a05dab0fe1fcb715a86ab435f1ec99192d79569388")
+	verifier, err := cose.NewMACVerifier(cose.AlgorithmHMAC256_64, key)
+	if err != nil {
+		t.Fatalf("NewMACVerifier() error = %v", err)
+	}
+	return verifier
+}
+
+func TestParse(t *testing.T) {
+	data := mustDecodeHex(t, rfc8392SignedCWTHex)
+	verifier := rfc8392Verifier(t)
+
+	// untagged
+	got, err := Parse(data, verifier)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, rfc8392Claims) {
+		t.Errorf("Parse() = %v, want %v", *got, rfc8392Claims)
+	}
+
+	// tagged
+	got, err = Parse(Tag(data), verifier)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, rfc8392Claims) {
+		t.Errorf("Parse() = %v, want %v", *got, rfc8392Claims)
+	}
+
+	// tampered
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1]++
+	if _, err := Parse(tampered, verifier); err != cose.ErrVerification {
+		t.Errorf("Parse() error = %v, wantErr %v", err, cose.ErrVerification)
+	}
+
+	// not a COSE_Sign1 message
+	if _, err := Parse(mustDecodeHex(t, rfc8392MACedCWTHex), verifier); err == nil {
+		t.Error("Parse() error = nil, wantErr true")
+	}
+}
+
+func TestParseMAC(t *testing.T) {
+	data := mustDecodeHex(t, rfc8392MACedCWTHex)
+	verifier := rfc8392MACVerifier(t)
+
+	got, err := ParseMAC(data, verifier)
+	if err != nil {
+		t.Fatalf("ParseMAC() error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, rfc8392Claims) {
+		t.Errorf("ParseMAC() = %v, want %v", *got, rfc8392Claims)
+	}
+
+	// untagged
+	got, err = ParseMAC(Untag(data), verifier)
+	if err != nil {
+		t.Fatalf("ParseMAC() error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, rfc8392Claims) {
+		t.Errorf("ParseMAC() = %v, want %v", *got, rfc8392Claims)
+	}
+
+	// tampered
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1]++
+	if _, err := ParseMAC(tampered, verifier); err != cose.ErrVerification {
+		t.Errorf("ParseMAC() error = %v, wantErr %v", err, cose.ErrVerification)
+	}
+}
+
+func TestSign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	signer, err := cose.NewSigner(cose.AlgorithmES256, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := cose.NewVerifier(cose.AlgorithmES256, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	headers := cose.Headers{
+		Protected: cose.ProtectedHeader{
+			cose.HeaderLabelAlgorithm: cose.AlgorithmES256,
+		},
+	}
+	claims := &Claims{
+		Issuer:         "issuer",
+		ExpirationTime: time.Unix(1444064944, 0),
+		Confirmation: &Confirmation{
+			KeyID: []byte("kid"),
+		},
+		Extra: map[interface{}]interface{}{
+			int64(-65537): "private",
+		},
+	}
+
+	data, err := Sign(rand.Reader, signer, headers, claims)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	got, err := Parse(data, verifier)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, claims) {
+		t.Errorf("Parse() = %v, want %v", got, claims)
+	}
+
+	// wrong key
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	otherVerifier, err := cose.NewVerifier(cose.AlgorithmES256, otherKey.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	if _, err := Parse(data, otherVerifier); err != cose.ErrVerification {
+		t.Errorf("Parse() error = %v, wantErr %v", err, cose.ErrVerification)
+	}
+
+	// invalid claims
+	if _, err := Sign(rand.Reader, signer, headers, nil); err == nil {
+		t.Error("Sign() error = nil, wantErr true")
+	}
+}
+
+func TestMAC(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read() error = %v", err)
+	}
+	macer, err := cose.NewMACer(cose.AlgorithmHMAC256_256, key)
+	if err != nil {
+		t.Fatalf("NewMACer() error = %v", err)
+	}
+	verifier, err := cose.NewMACVerifier(cose.AlgorithmHMAC256_256, key)
+	if err != nil {
+		t.Fatalf("NewMACVerifier() error = %v", err)
+	}
+	headers := cose.Headers{
+		Protected: cose.ProtectedHeader{
+			cose.HeaderLabelAlgorithm: cose.AlgorithmHMAC256_256,
+		},
+	}
+	claims := &Claims{
+		Subject:  "subject",
+		Audience: "audience",
+		IssuedAt: time.Unix(1443944944, 0),
+	}
+
+	data, err := MAC(macer, headers, claims)
+	if err != nil {
+		t.Fatalf("MAC() error = %v", err)
+	}
+	got, err := ParseMAC(Tag(data), verifier)
+	if err != nil {
+		t.Fatalf("ParseMAC() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, claims) {
+		t.Errorf("ParseMAC() = %v, want %v", got, claims)
+	}
+
+	// invalid payload
+	invalid, err := cose.Mac0(macer, headers, []byte("foo"), nil)
+	if err != nil {
+		t.Fatalf("Mac0() error = %v", err)
+	}
+	if _, err := ParseMAC(invalid, verifier); err == nil {
+		t.Error("ParseMAC() error = nil, wantErr true")
+	}
+}
+
+func TestTag(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			name: "untagged",
+			data: []byte{0xd2, 0x84},
+			want: []byte{0xd8, 0x3d, 0xd2, 0x84},
+		},
+		{
+			name: "tagged",
+			data: []byte{0xd8, 0x3d, 0xd2, 0x84},
+			want: []byte{0xd8, 0x3d, 0xd2, 0x84},
+		},
+		{
+			name: "empty",
+			data: nil,
+			want: []byte{0xd8, 0x3d},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tag(tt.data); !bytes.Equal(got, tt.want) {
+				t.Errorf("Tag() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUntag(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			name: "tagged",
+			data: []byte{0xd8, 0x3d, 0xd2, 0x84},
+			want: []byte{0xd2, 0x84},
+		},
+		{
+			name: "untagged",
+			data: []byte{0xd2, 0x84},
+			want: []byte{0xd2, 0x84},
+		},
+		{
+			name: "other tag",
+			data: []byte{0xd8, 0x3e, 0xd2, 0x84},
+			want: []byte{0xd8, 0x3e, 0xd2, 0x84},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Untag(tt.data); !bytes.Equal(got, tt.want) {
+				t.Errorf("Untag() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
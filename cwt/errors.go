@@ -0,0 +1,10 @@
+package cwt
+
+import "errors"
+
+// Common errors
+var (
+	ErrAudienceMismatch = errors.New("audience mismatch")
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+)
@@ -0,0 +1,71 @@
+package cwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	_ "crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/veraison/go-cose"
+	"github.com/veraison/go-cose/cwt"
+)
+
+// This example demonstrates issuing, parsing and validating a signed CWT.
+func Example() {
+	// create a signer
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	signer, err := cose.NewSigner(cose.AlgorithmES256, privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	// issue the token
+	now := time.Now()
+	claims := &cwt.Claims{
+		Issuer:         "coap://as.example.com",
+		Subject:        "erikw",
+		Audience:       "coap://light.example.com",
+		ExpirationTime: now.Add(time.Hour),
+		NotBefore:      now,
+		IssuedAt:       now,
+	}
+	headers := cose.Headers{
+		Protected: cose.ProtectedHeader{
+			cose.HeaderLabelAlgorithm: cose.AlgorithmES256,
+		},
+	}
+	token, err := cwt.Sign(rand.Reader, signer, headers, claims)
+	if err != nil {
+		panic(err)
+	}
+	token = cwt.Tag(token)
+	fmt.Println("token issued")
+
+	// create a verifier from a trusted public key
+	verifier, err := cose.NewVerifier(cose.AlgorithmES256, privateKey.Public())
+	if err != nil {
+		panic(err)
+	}
+
+	// parse and validate the token
+	parsed, err := cwt.Parse(token, verifier)
+	if err != nil {
+		panic(err)
+	}
+	validator := cwt.Validator{
+		Audience:  "coap://light.example.com",
+		ClockSkew: time.Minute,
+	}
+	if err := validator.Validate(parsed); err != nil {
+		panic(err)
+	}
+	fmt.Println("token validated for", parsed.Subject)
+	// Output:
+	// token issued
+	// token validated for erikw
+}
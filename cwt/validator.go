@@ -0,0 +1,50 @@
+package cwt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Validator validates the claims of a CWT.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-3.1
+type Validator struct {
+	// Audience is the expected audience of the CWT. If not empty, the `aud`
+	// claim is required to be equal to Audience.
+	Audience string
+
+	// ClockSkew is the tolerated clock skew between the issuer and the
+	// validator when checking the `exp` and `nbf` claims.
+	ClockSkew time.Duration
+
+	// Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// Validate validates the claims, returning nil on success.
+//
+// The claims are rejected with ErrTokenExpired if the `exp` claim is present
+// and the current time is at or after it, or with ErrTokenNotValidYet if the
+// `nbf` claim is present and the current time is before it, both with
+// ClockSkew tolerance.
+// It returns ErrAudienceMismatch if the audience is not the expected one.
+func (v *Validator) Validate(claims *Claims) error {
+	if claims == nil {
+		return errors.New("validating nil Claims")
+	}
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if !claims.ExpirationTime.IsZero() && !now.Before(claims.ExpirationTime.Add(v.ClockSkew)) {
+		return fmt.Errorf("%w: expired at %v", ErrTokenExpired, claims.ExpirationTime)
+	}
+	if !claims.NotBefore.IsZero() && now.Before(claims.NotBefore.Add(-v.ClockSkew)) {
+		return fmt.Errorf("%w: valid from %v", ErrTokenNotValidYet, claims.NotBefore)
+	}
+	if v.Audience != "" && claims.Audience != v.Audience {
+		return fmt.Errorf("%w: %q", ErrAudienceMismatch, claims.Audience)
+	}
+	return nil
+}
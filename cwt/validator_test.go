@@ -0,0 +1,115 @@
+package cwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	now := time.Unix(1443944944, 0)
+	tests := []struct {
+		name      string
+		validator Validator
+		claims    *Claims
+		wantErr   error
+	}{
+		{
+			name:      "no claims",
+			validator: Validator{},
+			claims:    &Claims{},
+		},
+		{
+			name:      "valid",
+			validator: Validator{Audience: "aud"},
+			claims: &Claims{
+				Audience:       "aud",
+				ExpirationTime: now.Add(time.Second),
+				NotBefore:      now,
+			},
+		},
+		{
+			name:      "expired",
+			validator: Validator{},
+			claims: &Claims{
+				ExpirationTime: now,
+			},
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:      "expired within clock skew",
+			validator: Validator{ClockSkew: time.Minute},
+			claims: &Claims{
+				ExpirationTime: now.Add(-59 * time.Second),
+			},
+		},
+		{
+			name:      "expired beyond clock skew",
+			validator: Validator{ClockSkew: time.Minute},
+			claims: &Claims{
+				ExpirationTime: now.Add(-time.Minute),
+			},
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:      "not valid yet",
+			validator: Validator{},
+			claims: &Claims{
+				NotBefore: now.Add(time.Second),
+			},
+			wantErr: ErrTokenNotValidYet,
+		},
+		{
+			name:      "not valid yet within clock skew",
+			validator: Validator{ClockSkew: time.Minute},
+			claims: &Claims{
+				NotBefore: now.Add(time.Minute),
+			},
+		},
+		{
+			name:      "not valid yet beyond clock skew",
+			validator: Validator{ClockSkew: time.Minute},
+			claims: &Claims{
+				NotBefore: now.Add(time.Minute + time.Second),
+			},
+			wantErr: ErrTokenNotValidYet,
+		},
+		{
+			name:      "audience mismatch",
+			validator: Validator{Audience: "aud"},
+			claims: &Claims{
+				Audience: "other",
+			},
+			wantErr: ErrAudienceMismatch,
+		},
+		{
+			name:      "missing audience",
+			validator: Validator{Audience: "aud"},
+			claims:    &Claims{},
+			wantErr:   ErrAudienceMismatch,
+		},
+		{
+			name:      "audience not expected",
+			validator: Validator{},
+			claims: &Claims{
+				Audience: "aud",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.validator.Now = func() time.Time { return now }
+			err := tt.validator.Validate(tt.claims)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validator.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidator_Validate_NilClaims(t *testing.T) {
+	var v Validator
+	if err := v.Validate(nil); err == nil {
+		t.Error("Validator.Validate() error = nil, wantErr true")
+	}
+}
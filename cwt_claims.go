@@ -0,0 +1,86 @@
+package cose
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CWT claim keys registered in the IANA "CBOR Web Token (CWT) Claims" registry.
+//
+// Reference: https://www.iana.org/assignments/cwt/cwt.xhtml
+const (
+	CWTClaimIssuer         int64 = 1
+	CWTClaimSubject        int64 = 2
+	CWTClaimAudience       int64 = 3
+	CWTClaimExpirationTime int64 = 4
+	CWTClaimNotBefore      int64 = 5
+	CWTClaimIssuedAt       int64 = 6
+	CWTClaimCWTID          int64 = 7
+	CWTClaimConfirmation   int64 = 8
+)
+
+// CWTClaims represents the claims set of the CWT Claims header parameter.
+//
+// The claims set is a map of claim keys, which are integers or strings, to
+// claim values. See the cwt package for CBOR Web Tokens carrying the claims in
+// the payload.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9597#section-2
+type CWTClaims map[interface{}]interface{}
+
+// Issuer gets the issuer claim.
+// It returns an empty string if the claim is not present.
+func (c CWTClaims) Issuer() (string, error) {
+	return c.text(CWTClaimIssuer)
+}
+
+// Subject gets the subject claim.
+// It returns an empty string if the claim is not present.
+func (c CWTClaims) Subject() (string, error) {
+	return c.text(CWTClaimSubject)
+}
+
+// Audience gets the audience claim.
+// It returns an empty string if the claim is not present.
+func (c CWTClaims) Audience() (string, error) {
+	return c.text(CWTClaimAudience)
+}
+
+// text gets a tstr claim value.
+func (c CWTClaims) text(key int64) (string, error) {
+	value, ok := c[key]
+	if !ok {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("claim %d: require tstr type", key)
+	}
+	return s, nil
+}
+
+// toCWTClaims converts a CWT Claims header value into CWTClaims, with its
+// integer claim keys converted to int64.
+func toCWTClaims(value interface{}) (CWTClaims, error) {
+	var m map[interface{}]interface{}
+	switch v := value.(type) {
+	case CWTClaims:
+		m = v
+	case map[interface{}]interface{}:
+		m = v
+	default:
+		return nil, errors.New("require map type")
+	}
+	claims := make(CWTClaims, len(m))
+	for key, value := range m {
+		key, ok := normalizeLabel(key)
+		if !ok {
+			return nil, errors.New("claim key: require int / tstr type")
+		}
+		if _, ok := claims[key]; ok {
+			return nil, fmt.Errorf("claim key: duplicated key: %v", key)
+		}
+		claims[key] = value
+	}
+	return claims, nil
+}
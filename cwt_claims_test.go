@@ -0,0 +1,61 @@
+package cose
+
+import "testing"
+
+func TestCWTClaims(t *testing.T) {
+	tests := []struct {
+		name         string
+		claims       CWTClaims
+		wantIssuer   string
+		wantSubject  string
+		wantAudience string
+		wantErr      bool
+	}{
+		{
+			name: "all claims",
+			claims: CWTClaims{
+				CWTClaimIssuer:   "iss",
+				CWTClaimSubject:  "sub",
+				CWTClaimAudience: "aud",
+			},
+			wantIssuer:   "iss",
+			wantSubject:  "sub",
+			wantAudience: "aud",
+		},
+		{
+			name:   "no claims",
+			claims: nil,
+		},
+		{
+			name: "invalid claims",
+			claims: CWTClaims{
+				CWTClaimIssuer:   []byte("iss"),
+				CWTClaimSubject:  42,
+				CWTClaimAudience: true,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer, err := tt.claims.Issuer()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CWTClaims.Issuer() error = %v, wantErr %v", err, tt.wantErr)
+			} else if issuer != tt.wantIssuer {
+				t.Errorf("CWTClaims.Issuer() = %v, want %v", issuer, tt.wantIssuer)
+			}
+			subject, err := tt.claims.Subject()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CWTClaims.Subject() error = %v, wantErr %v", err, tt.wantErr)
+			} else if subject != tt.wantSubject {
+				t.Errorf("CWTClaims.Subject() = %v, want %v", subject, tt.wantSubject)
+			}
+			audience, err := tt.claims.Audience()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CWTClaims.Audience() error = %v, wantErr %v", err, tt.wantErr)
+			} else if audience != tt.wantAudience {
+				t.Errorf("CWTClaims.Audience() = %v, want %v", audience, tt.wantAudience)
+			}
+		})
+	}
+}
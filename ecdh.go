@@ -0,0 +1,601 @@
+package cose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// X25519PublicKey is a public key for the X25519 key agreement, which is the
+// 32-byte u-coordinate of a point on Curve25519.
+type X25519PublicKey []byte
+
+// Equal reports whether pub and x have the same value.
+func (pub X25519PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(X25519PublicKey)
+	return ok && subtle.ConstantTimeCompare(pub, xx) == 1
+}
+
+// X25519PrivateKey is a private key for the X25519 key agreement, which is a
+// 32-byte scalar.
+type X25519PrivateKey []byte
+
+// GenerateX25519Key generates a private key for the X25519 key agreement using
+// entropy from rand.
+func GenerateX25519Key(rand io.Reader) (X25519PrivateKey, error) {
+	priv := make(X25519PrivateKey, curve25519.ScalarSize)
+	if _, err := io.ReadFull(rand, priv); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// Public returns the public key corresponding to priv.
+// It panics if priv is not 32 bytes long.
+func (priv X25519PrivateKey) Public() crypto.PublicKey {
+	pub, err := curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		panic(err)
+	}
+	return X25519PublicKey(pub)
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv X25519PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(X25519PrivateKey)
+	return ok && subtle.ConstantTimeCompare(priv, xx) == 1
+}
+
+// NewECDHKeyEncrypter returns a KeyEncrypter for the ECDH-ES key agreement
+// algorithms with the public key of the recipient.
+//
+// The supported key types are *ecdsa.PublicKey on the curves P-256, P-384 and
+// P-521, and X25519PublicKey.
+func NewECDHKeyEncrypter(alg Algorithm, key crypto.PublicKey) (KeyEncrypter, error) {
+	if static, _, err := ecdhParams(alg); err != nil || static {
+		return nil, ErrAlgorithmNotSupported
+	}
+	crv, err := ecdhPublicKeyCurve(key)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", alg, err)
+	}
+	return &ecdhKeyEncrypter{
+		alg: alg,
+		crv: crv,
+		key: key,
+	}, nil
+}
+
+// NewECDHKeyDecrypter returns a KeyDecrypter for the ECDH-ES key agreement
+// algorithms with the private key of the recipient.
+//
+// The supported key types are *ecdsa.PrivateKey on the curves P-256, P-384 and
+// P-521, and X25519PrivateKey.
+func NewECDHKeyDecrypter(alg Algorithm, key crypto.PrivateKey) (KeyDecrypter, error) {
+	if static, _, err := ecdhParams(alg); err != nil || static {
+		return nil, ErrAlgorithmNotSupported
+	}
+	crv, err := ecdhPrivateKeyCurve(key)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", alg, err)
+	}
+	return &ecdhKeyDecrypter{
+		alg: alg,
+		crv: crv,
+		key: key,
+	}, nil
+}
+
+// NewECDHStaticKeyEncrypter returns a KeyEncrypter for the ECDH-SS key
+// agreement algorithms with the public key of the recipient and the static
+// private key of the sender.
+//
+// The supported key types are the same as NewECDHKeyEncrypter and
+// NewECDHKeyDecrypter, and both keys must be on the same curve.
+func NewECDHStaticKeyEncrypter(alg Algorithm, key crypto.PublicKey, static crypto.PrivateKey) (KeyEncrypter, error) {
+	if isStatic, _, err := ecdhParams(alg); err != nil || !isStatic {
+		return nil, ErrAlgorithmNotSupported
+	}
+	crv, err := ecdhPublicKeyCurve(key)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", alg, err)
+	}
+	staticCrv, err := ecdhPrivateKeyCurve(static)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", alg, err)
+	}
+	if crv != staticCrv {
+		return nil, fmt.Errorf("%v: curve mismatch", alg)
+	}
+	return &ecdhKeyEncrypter{
+		alg:    alg,
+		crv:    crv,
+		key:    key,
+		static: static,
+	}, nil
+}
+
+// NewECDHStaticKeyDecrypter returns a KeyDecrypter for the ECDH-SS key
+// agreement algorithms with the private key of the recipient and the static
+// public key of the sender.
+// The static key of the sender is not taken from the recipient headers since
+// it has to be trusted by the recipient.
+//
+// The supported key types are the same as NewECDHKeyEncrypter and
+// NewECDHKeyDecrypter, and both keys must be on the same curve.
+func NewECDHStaticKeyDecrypter(alg Algorithm, key crypto.PrivateKey, static crypto.PublicKey) (KeyDecrypter, error) {
+	if isStatic, _, err := ecdhParams(alg); err != nil || !isStatic {
+		return nil, ErrAlgorithmNotSupported
+	}
+	crv, err := ecdhPrivateKeyCurve(key)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", alg, err)
+	}
+	staticCrv, err := ecdhPublicKeyCurve(static)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", alg, err)
+	}
+	if crv != staticCrv {
+		return nil, fmt.Errorf("%v: curve mismatch", alg)
+	}
+	return &ecdhKeyDecrypter{
+		alg:    alg,
+		crv:    crv,
+		key:    key,
+		static: static,
+	}, nil
+}
+
+// ecdhParams returns whether the sender key of the ECDH key agreement
+// algorithm alg is static, and the key wrap algorithm for the derived key.
+// The key wrap algorithm is zero if the derived key is the content key.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.3
+func ecdhParams(alg Algorithm) (static bool, keyWrap Algorithm, err error) {
+	switch alg {
+	case AlgorithmECDH_ES_HKDF_256, AlgorithmECDH_ES_HKDF_512:
+		return false, 0, nil
+	case AlgorithmECDH_SS_HKDF_256, AlgorithmECDH_SS_HKDF_512:
+		return true, 0, nil
+	case AlgorithmECDH_ES_A128KW:
+		return false, AlgorithmA128KW, nil
+	case AlgorithmECDH_ES_A192KW:
+		return false, AlgorithmA192KW, nil
+	case AlgorithmECDH_ES_A256KW:
+		return false, AlgorithmA256KW, nil
+	case AlgorithmECDH_SS_A128KW:
+		return true, AlgorithmA128KW, nil
+	case AlgorithmECDH_SS_A192KW:
+		return true, AlgorithmA192KW, nil
+	case AlgorithmECDH_SS_A256KW:
+		return true, AlgorithmA256KW, nil
+	}
+	return false, 0, ErrAlgorithmNotSupported
+}
+
+// ecdhPublicKeyCurve returns the curve of a public key for the ECDH key
+// agreement.
+func ecdhPublicKeyCurve(key crypto.PublicKey) (Curve, error) {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		crv, err := curveFromElliptic(key.Curve)
+		if err != nil {
+			return 0, err
+		}
+		if key.X == nil || key.Y == nil || !key.Curve.IsOnCurve(key.X, key.Y) {
+			return 0, errors.New("invalid public key")
+		}
+		return crv, nil
+	case X25519PublicKey:
+		if len(key) != curve25519.PointSize {
+			return 0, errors.New("invalid X25519 public key")
+		}
+		return CurveX25519, nil
+	}
+	return 0, ErrAlgorithmMismatch
+}
+
+// ecdhPrivateKeyCurve returns the curve of a private key for the ECDH key
+// agreement.
+func ecdhPrivateKeyCurve(key crypto.PrivateKey) (Curve, error) {
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		crv, err := curveFromElliptic(key.Curve)
+		if err != nil {
+			return 0, err
+		}
+		if key.D == nil || key.D.Sign() <= 0 || key.D.Cmp(key.Curve.Params().N) >= 0 {
+			return 0, errors.New("invalid private key")
+		}
+		return crv, nil
+	case X25519PrivateKey:
+		if len(key) != curve25519.ScalarSize {
+			return 0, errors.New("invalid X25519 private key")
+		}
+		return CurveX25519, nil
+	}
+	return 0, ErrAlgorithmMismatch
+}
+
+// ecdhGenerateKey generates an ephemeral private key on the curve crv using
+// entropy from rand.
+func ecdhGenerateKey(rand io.Reader, crv Curve) (crypto.PrivateKey, error) {
+	if crv == CurveX25519 {
+		return GenerateX25519Key(rand)
+	}
+	return ecdsa.GenerateKey(crv.elliptic(), rand)
+}
+
+// ecdhPublicKey returns the public key of a private key for the ECDH key
+// agreement.
+func ecdhPublicKey(key crypto.PrivateKey) crypto.PublicKey {
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		return &key.PublicKey
+	case X25519PrivateKey:
+		return key.Public()
+	}
+	return nil
+}
+
+// ecdhSharedSecret computes the shared secret of the private key priv and the
+// public key pub, which are on the same curve.
+// For the NIST curves, the shared secret is the x-coordinate of the shared
+// point.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.3.1
+func ecdhSharedSecret(priv crypto.PrivateKey, pub crypto.PublicKey) ([]byte, error) {
+	switch priv := priv.(type) {
+	case *ecdsa.PrivateKey:
+		pub, ok := pub.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != priv.Curve {
+			return nil, errors.New("curve mismatch")
+		}
+		x, _ := priv.Curve.ScalarMult(pub.X, pub.Y, priv.D.Bytes())
+		return x.FillBytes(make([]byte, curveSize(priv.Curve))), nil
+	case X25519PrivateKey:
+		pub, ok := pub.(X25519PublicKey)
+		if !ok {
+			return nil, errors.New("curve mismatch")
+		}
+		return curve25519.X25519(priv, pub)
+	}
+	return nil, ErrAlgorithmMismatch
+}
+
+// ecdhKeyEncrypter is a KeyEncrypter using the ECDH key agreement with the
+// public key of the recipient.
+// The sender key is ephemeral for ECDH-ES, or static for ECDH-SS.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.3.1
+type ecdhKeyEncrypter struct {
+	alg    Algorithm
+	crv    Curve
+	key    crypto.PublicKey
+	static crypto.PrivateKey
+}
+
+// Algorithm returns the key management algorithm.
+func (ke *ecdhKeyEncrypter) Algorithm() Algorithm {
+	return ke.alg
+}
+
+// EncryptKey derives a key from the shared secret of the key agreement.
+//
+// For ECDH-ES, an ephemeral key is generated using entropy from rand and added
+// to the unprotected header of the recipient.
+// For ECDH-SS, the static public key of the sender is added to the unprotected
+// header of the recipient unless the static key or the static key ID header
+// parameters are present. A random PartyU nonce is added as well unless the
+// salt or the PartyU nonce header parameters are present.
+//
+// For direct key agreement, the derived key is the content key, and therefore
+// cannot be shared with other recipients. Otherwise, the content key is
+// wrapped with the derived key, and a random content key is generated using
+// entropy from rand if cek is nil.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.3.1
+func (ke *ecdhKeyEncrypter) EncryptKey(rand io.Reader, recipient *Recipient, alg Algorithm, cek []byte) ([]byte, error) {
+	_, keyWrap, err := ecdhParams(ke.alg)
+	if err != nil {
+		return nil, err
+	}
+	if keyWrap == 0 && cek != nil {
+		return nil, fmt.Errorf("%v: content key must not be shared with other recipients", ke.alg)
+	}
+	h := &recipient.Headers
+	if h.RawUnprotected != nil {
+		return nil, fmt.Errorf("%v: unable to add sender key to raw unprotected header", ke.alg)
+	}
+	if h.Unprotected == nil {
+		h.Unprotected = make(UnprotectedHeader)
+	}
+
+	// compute the shared secret
+	var secret []byte
+	if ke.static == nil {
+		ephemeral, err := ecdhGenerateKey(rand, ke.crv)
+		if err != nil {
+			return nil, err
+		}
+		if secret, err = ecdhSharedSecret(ephemeral, ke.key); err != nil {
+			return nil, err
+		}
+		h.Unprotected[HeaderLabelEphemeralKey] = ecdhEncodeKey(ecdhPublicKey(ephemeral))
+	} else {
+		if secret, err = ecdhSharedSecret(ke.static, ke.key); err != nil {
+			return nil, err
+		}
+		if !h.hasLabel(HeaderLabelStaticKey) && !h.hasLabel(HeaderLabelStaticKeyID) {
+			h.Unprotected[HeaderLabelStaticKey] = ecdhEncodeKey(ecdhPublicKey(ke.static))
+		}
+		if !h.hasLabel(HeaderLabelSalt) && !h.hasLabel(HeaderLabelPartyUNonce) {
+			nonce := make([]byte, 32)
+			if _, err := io.ReadFull(rand, nonce); err != nil {
+				return nil, err
+			}
+			h.Unprotected[HeaderLabelPartyUNonce] = nonce
+		}
+	}
+
+	// derive the content key
+	if keyWrap == 0 {
+		key, err := ecdhDeriveKey(ke.alg, secret, h, alg)
+		if err != nil {
+			return nil, err
+		}
+		recipient.Ciphertext = []byte{}
+		return key, nil
+	}
+
+	// wrap the content key
+	kek, err := ecdhDeriveKey(ke.alg, secret, h, keyWrap)
+	if err != nil {
+		return nil, err
+	}
+	if cek == nil {
+		if cek, err = generateContentKey(rand, alg); err != nil {
+			return nil, err
+		}
+	}
+	wrapped, err := aesKeyWrap(kek, cek)
+	if err != nil {
+		return nil, err
+	}
+	recipient.Ciphertext = wrapped
+	return cek, nil
+}
+
+// ecdhKeyDecrypter is a KeyDecrypter using the ECDH key agreement with the
+// private key of the recipient.
+// The sender key is taken from the ephemeral key header parameter for
+// ECDH-ES, or is the trusted static key of the sender for ECDH-SS.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.3.1
+type ecdhKeyDecrypter struct {
+	alg    Algorithm
+	crv    Curve
+	key    crypto.PrivateKey
+	static crypto.PublicKey
+}
+
+// Algorithm returns the key management algorithm.
+func (kd *ecdhKeyDecrypter) Algorithm() Algorithm {
+	return kd.alg
+}
+
+// DecryptKey derives a key from the shared secret of the key agreement, and
+// returns it as the content key for direct key agreement, or unwraps the
+// content key with it otherwise.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.3.1
+func (kd *ecdhKeyDecrypter) DecryptKey(recipient *Recipient, alg Algorithm) ([]byte, error) {
+	_, keyWrap, err := ecdhParams(kd.alg)
+	if err != nil {
+		return nil, err
+	}
+	if keyWrap == 0 && len(recipient.Ciphertext) != 0 {
+		return nil, fmt.Errorf("%v: ciphertext must be empty", kd.alg)
+	}
+	h := &recipient.Headers
+
+	// compute the shared secret
+	sender := kd.static
+	if sender == nil {
+		value, ok := h.Protected[HeaderLabelEphemeralKey]
+		if !ok {
+			if value, ok = h.Unprotected[HeaderLabelEphemeralKey]; !ok {
+				return nil, fmt.Errorf("%v: missing ephemeral key", kd.alg)
+			}
+		}
+		if sender, err = ecdhDecodeKey(value, kd.crv); err != nil {
+			return nil, fmt.Errorf("%v: ephemeral key: %w", kd.alg, err)
+		}
+	}
+	secret, err := ecdhSharedSecret(kd.key, sender)
+	if err != nil {
+		return nil, err
+	}
+
+	// derive the content key
+	if keyWrap == 0 {
+		return ecdhDeriveKey(kd.alg, secret, h, alg)
+	}
+
+	// unwrap the content key
+	kek, err := ecdhDeriveKey(kd.alg, secret, h, keyWrap)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := aesKeyUnwrap(kek, recipient.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if size := alg.keySize(); size > 0 && len(cek) != size {
+		return nil, ErrDecryption
+	}
+	return cek, nil
+}
+
+// ecdhEncodeKey encodes pub into a COSE_Key of the EC2 or the OKP key type.
+func ecdhEncodeKey(pub crypto.PublicKey) *Key {
+	key, err := NewKeyFromPublic(pub)
+	if err != nil {
+		// unreachable: ECDH keys are restricted to the supported curves
+		panic(err)
+	}
+	return key
+}
+
+// ecdhDecodeKey decodes a COSE_Key of the EC2 or the OKP key type on the given
+// curve.
+// Both the uncompressed and the compressed forms of EC2 points are supported.
+func ecdhDecodeKey(value interface{}, crv Curve) (crypto.PublicKey, error) {
+	key, err := keyFromHeaderValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := key.Params[KeyLabelOKPD]; ok { // same label for EC2
+		return nil, errors.New("unexpected private key")
+	}
+	pub, err := key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	pubCrv, err := ecdhPublicKeyCurve(pub)
+	if err != nil {
+		return nil, err
+	}
+	if pubCrv != crv {
+		return nil, errors.New("curve mismatch")
+	}
+	return pub, nil
+}
+
+// kdfContext represents a COSE_KDF_Context CBOR object:
+//
+//	COSE_KDF_Context = [
+//	    AlgorithmID : int / tstr,
+//	    PartyUInfo : [ PartyInfo ],
+//	    PartyVInfo : [ PartyInfo ],
+//	    SuppPubInfo : [
+//	        keyDataLength : uint,
+//	        protected : empty_or_serialized_map,
+//	        ? other : bstr
+//	    ],
+//	    ? SuppPrivInfo : bstr
+//	]
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-5.2
+type kdfContext struct {
+	_           struct{} `cbor:",toarray"`
+	AlgorithmID Algorithm
+	PartyUInfo  kdfPartyInfo
+	PartyVInfo  kdfPartyInfo
+	SuppPubInfo kdfSuppPubInfo
+}
+
+// kdfPartyInfo represents a PartyInfo CBOR object:
+//
+//	PartyInfo = (
+//	    identity : bstr / nil,
+//	    nonce : bstr / int / nil,
+//	    other : bstr / nil
+//	)
+type kdfPartyInfo struct {
+	_        struct{} `cbor:",toarray"`
+	Identity byteString
+	Nonce    interface{}
+	Other    byteString
+}
+
+// kdfSuppPubInfo represents the SuppPubInfo field of COSE_KDF_Context.
+type kdfSuppPubInfo struct {
+	_             struct{} `cbor:",toarray"`
+	KeyDataLength uint
+	Protected     cbor.RawMessage
+}
+
+// newKDFPartyInfo constructs PartyInfo from the header parameters.
+func newKDFPartyInfo(h *Headers, identity, nonce, other int64) (kdfPartyInfo, error) {
+	var info kdfPartyInfo
+	var err error
+	if info.Identity, err = h.headerBytes(identity); err != nil {
+		return kdfPartyInfo{}, fmt.Errorf("party identity: %w", err)
+	}
+	value, ok := h.Protected[nonce]
+	if !ok {
+		value, ok = h.Unprotected[nonce]
+	}
+	if ok {
+		if !canBstr(value) && !canInt(value) {
+			return kdfPartyInfo{}, errors.New("party nonce: require bstr / int type")
+		}
+		info.Nonce = value
+	}
+	if info.Other, err = h.headerBytes(other); err != nil {
+		return kdfPartyInfo{}, fmt.Errorf("party other: %w", err)
+	}
+	return info, nil
+}
+
+// ecdhDeriveKey derives a key for alg from the shared secret using HKDF with
+// the hash function of the key agreement algorithm keyAlg.
+// The salt and the PartyInfo of COSE_KDF_Context are taken from the headers of
+// the recipient.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-5
+func ecdhDeriveKey(keyAlg Algorithm, secret []byte, h *Headers, alg Algorithm) ([]byte, error) {
+	size := alg.keySize()
+	if size == 0 {
+		return nil, fmt.Errorf("%v: unknown content key size", alg)
+	}
+	hash := keyAlg.hashFunc()
+	if !hash.Available() {
+		return nil, ErrUnavailableHashFunc
+	}
+	salt, err := h.headerBytes(HeaderLabelSalt)
+	if err != nil {
+		return nil, fmt.Errorf("salt: %w", err)
+	}
+
+	// construct COSE_KDF_Context
+	partyU, err := newKDFPartyInfo(h, HeaderLabelPartyUIdentity, HeaderLabelPartyUNonce, HeaderLabelPartyUOther)
+	if err != nil {
+		return nil, err
+	}
+	partyV, err := newKDFPartyInfo(h, HeaderLabelPartyVIdentity, HeaderLabelPartyVNonce, HeaderLabelPartyVOther)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := h.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+	info, err := encMode.Marshal(kdfContext{
+		AlgorithmID: alg,
+		PartyUInfo:  partyU,
+		PartyVInfo:  partyV,
+		SuppPubInfo: kdfSuppPubInfo{
+			KeyDataLength: uint(size * 8),
+			Protected:     protected,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// derive the key
+	key := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(hash.New, secret, salt, info), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,770 @@
+package cose
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+func generateTestECDHKey(t *testing.T, crv Curve) crypto.PrivateKey {
+	key, err := ecdhGenerateKey(rand.Reader, crv)
+	if err != nil {
+		t.Fatalf("ecdhGenerateKey() error = %v", err)
+	}
+	return key
+}
+
+func generateTestX25519Key(t *testing.T) X25519PrivateKey {
+	key, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateX25519Key() error = %v", err)
+	}
+	return key
+}
+
+func TestNewECDHKeyEncrypter(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	x25519 := generateTestX25519Key(t)
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		key     interface{}
+		wantErr error
+	}{
+		{
+			name: "ECDH-ES + HKDF-256",
+			alg:  AlgorithmECDH_ES_HKDF_256,
+			key:  key.Public(),
+		},
+		{
+			name: "ECDH-ES + HKDF-512",
+			alg:  AlgorithmECDH_ES_HKDF_512,
+			key:  key.Public(),
+		},
+		{
+			name: "ECDH-ES + A128KW",
+			alg:  AlgorithmECDH_ES_A128KW,
+			key:  ecdhPublicKey(x25519),
+		},
+		{
+			name:    "ECDH-SS + HKDF-256",
+			alg:     AlgorithmECDH_SS_HKDF_256,
+			key:     key.Public(),
+			wantErr: ErrAlgorithmNotSupported,
+		},
+		{
+			name:    "unknown algorithm",
+			alg:     AlgorithmA128KW,
+			key:     key.Public(),
+			wantErr: ErrAlgorithmNotSupported,
+		},
+		{
+			name:    "private key",
+			alg:     AlgorithmECDH_ES_HKDF_256,
+			key:     key,
+			wantErr: ErrAlgorithmMismatch,
+		},
+		{
+			name:    "ed25519 key",
+			alg:     AlgorithmECDH_ES_HKDF_256,
+			key:     ed25519.PublicKey{},
+			wantErr: ErrAlgorithmMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewECDHKeyEncrypter(tt.alg, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewECDHKeyEncrypter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Algorithm() != tt.alg {
+				t.Errorf("Algorithm() = %v, want %v", got.Algorithm(), tt.alg)
+			}
+		})
+	}
+
+	// unsupported curve
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	if _, err := NewECDHKeyEncrypter(AlgorithmECDH_ES_HKDF_256, p224.Public()); err == nil {
+		t.Errorf("NewECDHKeyEncrypter() error = nil, wantErr true")
+	}
+	if _, err := NewECDHKeyDecrypter(AlgorithmECDH_ES_HKDF_256, p224); err == nil {
+		t.Errorf("NewECDHKeyDecrypter() error = nil, wantErr true")
+	}
+
+	// invalid keys
+	invalidPublicKeys := []crypto.PublicKey{
+		&ecdsa.PublicKey{Curve: elliptic.P256(), X: key.X, Y: key.X},
+		&ecdsa.PublicKey{Curve: elliptic.P256()},
+		X25519PublicKey("foo"),
+	}
+	for _, pub := range invalidPublicKeys {
+		if _, err := NewECDHKeyEncrypter(AlgorithmECDH_ES_HKDF_256, pub); err == nil {
+			t.Errorf("NewECDHKeyEncrypter() error = nil, wantErr true")
+		}
+	}
+	invalidPrivateKeys := []crypto.PrivateKey{
+		&ecdsa.PrivateKey{PublicKey: key.PublicKey, D: new(big.Int)},
+		&ecdsa.PrivateKey{PublicKey: key.PublicKey, D: elliptic.P256().Params().N},
+		X25519PrivateKey("foo"),
+	}
+	for _, priv := range invalidPrivateKeys {
+		if _, err := NewECDHKeyDecrypter(AlgorithmECDH_ES_HKDF_256, priv); err == nil {
+			t.Errorf("NewECDHKeyDecrypter() error = nil, wantErr true")
+		}
+	}
+}
+
+func TestNewECDHKeyDecrypter(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		key     interface{}
+		wantErr error
+	}{
+		{
+			name: "ECDH-ES + HKDF-256",
+			alg:  AlgorithmECDH_ES_HKDF_256,
+			key:  key,
+		},
+		{
+			name: "ECDH-ES + A256KW",
+			alg:  AlgorithmECDH_ES_A256KW,
+			key:  generateTestECDHKey(t, CurveP384),
+		},
+		{
+			name:    "ECDH-SS + A256KW",
+			alg:     AlgorithmECDH_SS_A256KW,
+			key:     key,
+			wantErr: ErrAlgorithmNotSupported,
+		},
+		{
+			name:    "unknown algorithm",
+			alg:     AlgorithmDirect,
+			key:     key,
+			wantErr: ErrAlgorithmNotSupported,
+		},
+		{
+			name:    "public key",
+			alg:     AlgorithmECDH_ES_HKDF_256,
+			key:     key.Public(),
+			wantErr: ErrAlgorithmMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewECDHKeyDecrypter(tt.alg, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewECDHKeyDecrypter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Algorithm() != tt.alg {
+				t.Errorf("Algorithm() = %v, want %v", got.Algorithm(), tt.alg)
+			}
+		})
+	}
+}
+
+func TestNewECDHStaticKeyEncrypter(t *testing.T) {
+	recipientKey := generateTestECDSAKey(t)
+	senderKey := generateTestECDSAKey(t)
+	x25519 := generateTestX25519Key(t)
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		key     interface{}
+		static  interface{}
+		wantErr bool
+	}{
+		{
+			name:   "ECDH-SS + HKDF-256",
+			alg:    AlgorithmECDH_SS_HKDF_256,
+			key:    recipientKey.Public(),
+			static: senderKey,
+		},
+		{
+			name:   "ECDH-SS + A192KW",
+			alg:    AlgorithmECDH_SS_A192KW,
+			key:    ecdhPublicKey(x25519),
+			static: generateTestX25519Key(t),
+		},
+		{
+			name:    "ECDH-ES + HKDF-256",
+			alg:     AlgorithmECDH_ES_HKDF_256,
+			key:     recipientKey.Public(),
+			static:  senderKey,
+			wantErr: true,
+		},
+		{
+			name:    "invalid recipient key",
+			alg:     AlgorithmECDH_SS_HKDF_256,
+			key:     recipientKey,
+			static:  senderKey,
+			wantErr: true,
+		},
+		{
+			name:    "invalid sender key",
+			alg:     AlgorithmECDH_SS_HKDF_256,
+			key:     recipientKey.Public(),
+			static:  senderKey.Public(),
+			wantErr: true,
+		},
+		{
+			name:    "curve mismatch",
+			alg:     AlgorithmECDH_SS_HKDF_256,
+			key:     ecdhPublicKey(x25519),
+			static:  senderKey,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewECDHStaticKeyEncrypter(tt.alg, tt.key, tt.static)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewECDHStaticKeyEncrypter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Algorithm() != tt.alg {
+				t.Errorf("Algorithm() = %v, want %v", got.Algorithm(), tt.alg)
+			}
+		})
+	}
+}
+
+func TestNewECDHStaticKeyDecrypter(t *testing.T) {
+	recipientKey := generateTestECDSAKey(t)
+	senderKey := generateTestECDSAKey(t)
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		key     interface{}
+		static  interface{}
+		wantErr bool
+	}{
+		{
+			name:   "ECDH-SS + HKDF-512",
+			alg:    AlgorithmECDH_SS_HKDF_512,
+			key:    recipientKey,
+			static: senderKey.Public(),
+		},
+		{
+			name:    "ECDH-ES + A128KW",
+			alg:     AlgorithmECDH_ES_A128KW,
+			key:     recipientKey,
+			static:  senderKey.Public(),
+			wantErr: true,
+		},
+		{
+			name:    "invalid recipient key",
+			alg:     AlgorithmECDH_SS_HKDF_512,
+			key:     recipientKey.Public(),
+			static:  senderKey.Public(),
+			wantErr: true,
+		},
+		{
+			name:    "invalid sender key",
+			alg:     AlgorithmECDH_SS_HKDF_512,
+			key:     recipientKey,
+			static:  senderKey,
+			wantErr: true,
+		},
+		{
+			name:    "curve mismatch",
+			alg:     AlgorithmECDH_SS_HKDF_512,
+			key:     recipientKey,
+			static:  ecdhPublicKey(generateTestECDHKey(t, CurveP521)),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewECDHStaticKeyDecrypter(tt.alg, tt.key, tt.static)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewECDHStaticKeyDecrypter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Algorithm() != tt.alg {
+				t.Errorf("Algorithm() = %v, want %v", got.Algorithm(), tt.alg)
+			}
+		})
+	}
+}
+
+func Test_ecdhKeyAgreement(t *testing.T) {
+	algorithms := []Algorithm{
+		AlgorithmECDH_ES_HKDF_256,
+		AlgorithmECDH_ES_HKDF_512,
+		AlgorithmECDH_SS_HKDF_256,
+		AlgorithmECDH_SS_HKDF_512,
+		AlgorithmECDH_ES_A128KW,
+		AlgorithmECDH_ES_A192KW,
+		AlgorithmECDH_ES_A256KW,
+		AlgorithmECDH_SS_A128KW,
+		AlgorithmECDH_SS_A192KW,
+		AlgorithmECDH_SS_A256KW,
+	}
+	curves := []struct {
+		name  string
+		curve Curve
+	}{
+		{name: "P-256", curve: CurveP256},
+		{name: "P-384", curve: CurveP384},
+		{name: "P-521", curve: CurveP521},
+		{name: "X25519", curve: CurveX25519},
+	}
+	for _, alg := range algorithms {
+		for _, c := range curves {
+			t.Run(alg.String()+" w/ "+c.name, func(t *testing.T) {
+				static, keyWrap, err := ecdhParams(alg)
+				if err != nil {
+					t.Fatalf("ecdhParams() error = %v", err)
+				}
+				recipientKey := generateTestECDHKey(t, c.curve)
+				senderKey := generateTestECDHKey(t, c.curve)
+				var encrypter KeyEncrypter
+				var decrypter KeyDecrypter
+				if static {
+					encrypter, err = NewECDHStaticKeyEncrypter(alg, ecdhPublicKey(recipientKey), senderKey)
+					if err != nil {
+						t.Fatalf("NewECDHStaticKeyEncrypter() error = %v", err)
+					}
+					decrypter, err = NewECDHStaticKeyDecrypter(alg, recipientKey, ecdhPublicKey(senderKey))
+					if err != nil {
+						t.Fatalf("NewECDHStaticKeyDecrypter() error = %v", err)
+					}
+				} else {
+					encrypter, err = NewECDHKeyEncrypter(alg, ecdhPublicKey(recipientKey))
+					if err != nil {
+						t.Fatalf("NewECDHKeyEncrypter() error = %v", err)
+					}
+					decrypter, err = NewECDHKeyDecrypter(alg, recipientKey)
+					if err != nil {
+						t.Fatalf("NewECDHKeyDecrypter() error = %v", err)
+					}
+				}
+
+				// distribute the content key
+				recipient := NewRecipient()
+				recipient.Headers.Protected.SetAlgorithm(alg)
+				cek, err := encrypter.EncryptKey(rand.Reader, recipient, AlgorithmA256GCM, nil)
+				if err != nil {
+					t.Fatalf("EncryptKey() error = %v", err)
+				}
+				if len(cek) != 32 {
+					t.Fatalf("EncryptKey() = %x, want 32 bytes", cek)
+				}
+				if keyWrap == 0 {
+					if recipient.Ciphertext == nil || len(recipient.Ciphertext) != 0 {
+						t.Fatalf("EncryptKey() ciphertext = %v, want empty", recipient.Ciphertext)
+					}
+				} else if len(recipient.Ciphertext) != 40 {
+					t.Fatalf("EncryptKey() ciphertext = %x, want 40 bytes", recipient.Ciphertext)
+				}
+				if static {
+					if !recipient.Headers.hasLabel(HeaderLabelStaticKey) {
+						t.Fatalf("EncryptKey() missing static key header")
+					}
+					if !recipient.Headers.hasLabel(HeaderLabelPartyUNonce) {
+						t.Fatalf("EncryptKey() missing PartyU nonce header")
+					}
+				} else if !recipient.Headers.hasLabel(HeaderLabelEphemeralKey) {
+					t.Fatalf("EncryptKey() missing ephemeral key header")
+				}
+
+				// recover the content key after a round trip
+				data, err := recipient.MarshalCBOR()
+				if err != nil {
+					t.Fatalf("Recipient.MarshalCBOR() error = %v", err)
+				}
+				var got Recipient
+				if err := got.UnmarshalCBOR(data); err != nil {
+					t.Fatalf("Recipient.UnmarshalCBOR() error = %v", err)
+				}
+				key, err := decrypter.DecryptKey(&got, AlgorithmA256GCM)
+				if err != nil {
+					t.Fatalf("DecryptKey() error = %v", err)
+				}
+				if !bytes.Equal(key, cek) {
+					t.Fatalf("DecryptKey() = %x, want %x", key, cek)
+				}
+
+				// content key shared with other recipients
+				if keyWrap == 0 {
+					if _, err := encrypter.EncryptKey(rand.Reader, NewRecipient(), AlgorithmA256GCM, cek); err == nil {
+						t.Fatalf("EncryptKey() error = nil, wantErr true")
+					}
+				} else {
+					recipient := NewRecipient()
+					key, err := encrypter.EncryptKey(rand.Reader, recipient, AlgorithmA256GCM, cek)
+					if err != nil {
+						t.Fatalf("EncryptKey() error = %v", err)
+					}
+					if !bytes.Equal(key, cek) {
+						t.Fatalf("EncryptKey() = %x, want %x", key, cek)
+					}
+				}
+
+				// tampered ciphertext
+				if keyWrap == 0 {
+					got.Ciphertext = []byte("foo")
+					if _, err := decrypter.DecryptKey(&got, AlgorithmA256GCM); err == nil {
+						t.Fatalf("DecryptKey() error = nil, wantErr true")
+					}
+				} else {
+					got.Ciphertext[0]++
+					if _, err := decrypter.DecryptKey(&got, AlgorithmA256GCM); err != ErrDecryption {
+						t.Fatalf("DecryptKey() error = %v, wantErr %v", err, ErrDecryption)
+					}
+				}
+
+				// missing ephemeral key
+				if !static {
+					if _, err := decrypter.DecryptKey(NewRecipient(), AlgorithmA256GCM); err == nil {
+						t.Fatalf("DecryptKey() error = nil, wantErr true")
+					}
+				}
+			})
+		}
+	}
+}
+
+func Test_ecdhSharedSecret(t *testing.T) {
+	p256 := generateTestECDHKey(t, CurveP256)
+	p384 := generateTestECDHKey(t, CurveP384)
+	x25519 := generateTestX25519Key(t)
+
+	// both parties agree on the same secret
+	for _, crv := range []Curve{CurveP256, CurveP384, CurveP521, CurveX25519} {
+		alice := generateTestECDHKey(t, crv)
+		bob := generateTestECDHKey(t, crv)
+		secretA, err := ecdhSharedSecret(alice, ecdhPublicKey(bob))
+		if err != nil {
+			t.Fatalf("ecdhSharedSecret() error = %v", err)
+		}
+		secretB, err := ecdhSharedSecret(bob, ecdhPublicKey(alice))
+		if err != nil {
+			t.Fatalf("ecdhSharedSecret() error = %v", err)
+		}
+		if !bytes.Equal(secretA, secretB) {
+			t.Errorf("ecdhSharedSecret() = %x, want %x", secretA, secretB)
+		}
+	}
+
+	tests := []struct {
+		name string
+		priv crypto.PrivateKey
+		pub  crypto.PublicKey
+	}{
+		{
+			name: "curve mismatch",
+			priv: p256,
+			pub:  ecdhPublicKey(p384),
+		},
+		{
+			name: "key type mismatch",
+			priv: x25519,
+			pub:  ecdhPublicKey(p256),
+		},
+		{
+			name: "low order point",
+			priv: x25519,
+			pub:  make(X25519PublicKey, 32),
+		},
+		{
+			name: "unsupported key type",
+			priv: ed25519.PrivateKey{},
+			pub:  ecdhPublicKey(p256),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ecdhSharedSecret(tt.priv, tt.pub); err == nil {
+				t.Errorf("ecdhSharedSecret() error = nil, wantErr true")
+			}
+		})
+	}
+}
+
+func Test_ecdhKeyEncrypter_StaticKeyID(t *testing.T) {
+	recipientKey := generateTestECDHKey(t, CurveP256)
+	senderKey := generateTestECDHKey(t, CurveP256)
+	encrypter, err := NewECDHStaticKeyEncrypter(AlgorithmECDH_SS_HKDF_256, ecdhPublicKey(recipientKey), senderKey)
+	if err != nil {
+		t.Fatalf("NewECDHStaticKeyEncrypter() error = %v", err)
+	}
+
+	// the static key ID and the salt are kept
+	recipient := NewRecipient()
+	recipient.Headers.Unprotected[HeaderLabelStaticKeyID] = []byte("sender")
+	recipient.Headers.Unprotected[HeaderLabelSalt] = []byte("salt")
+	if _, err := encrypter.EncryptKey(rand.Reader, recipient, AlgorithmA128GCM, nil); err != nil {
+		t.Fatalf("EncryptKey() error = %v", err)
+	}
+	if recipient.Headers.hasLabel(HeaderLabelStaticKey) {
+		t.Errorf("EncryptKey() static key header = %v, want none", recipient.Headers.Unprotected[HeaderLabelStaticKey])
+	}
+	if recipient.Headers.hasLabel(HeaderLabelPartyUNonce) {
+		t.Errorf("EncryptKey() PartyU nonce header = %v, want none", recipient.Headers.Unprotected[HeaderLabelPartyUNonce])
+	}
+
+	// raw unprotected header cannot be updated
+	recipient = NewRecipient()
+	recipient.Headers.RawUnprotected = []byte{0xa0}
+	if _, err := encrypter.EncryptKey(rand.Reader, recipient, AlgorithmA128GCM, nil); err == nil {
+		t.Errorf("EncryptKey() error = nil, wantErr true")
+	}
+}
+
+func Test_ecdhDeriveKey(t *testing.T) {
+	secret := []byte("shared secret")
+	h := Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: AlgorithmECDH_ES_HKDF_256,
+		},
+		Unprotected: UnprotectedHeader{
+			HeaderLabelSalt:           []byte("salt"),
+			HeaderLabelPartyUIdentity: []byte("lighting-client"),
+			HeaderLabelPartyVNonce:    42,
+		},
+	}
+	got, err := ecdhDeriveKey(AlgorithmECDH_ES_HKDF_256, secret, &h, AlgorithmA128GCM)
+	if err != nil {
+		t.Fatalf("ecdhDeriveKey() error = %v", err)
+	}
+
+	// compute the expected key with a hand-crafted COSE_KDF_Context
+	info := []byte{
+		0x84, // array of length 4
+		0x01, // AlgorithmID: A128GCM
+		0x83, // PartyUInfo
+		0x4f, // identity
+		0x6c, 0x69, 0x67, 0x68, 0x74, 0x69, 0x6e, 0x67,
+		0x2d, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+		0xf6,       // nonce
+		0xf6,       // other
+		0x83,       // PartyVInfo
+		0xf6,       // identity
+		0x18, 0x2a, // nonce
+		0xf6,       // other
+		0x82,       // SuppPubInfo
+		0x18, 0x80, // keyDataLength
+		0x44, 0xa1, 0x01, 0x38, 0x18, // protected
+	}
+	want := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, []byte("salt"), info), want); err != nil {
+		t.Fatalf("hkdf error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ecdhDeriveKey() = %x, want %x", got, want)
+	}
+
+	// invalid party nonce
+	h.Unprotected[HeaderLabelPartyUNonce] = "foo"
+	if _, err := ecdhDeriveKey(AlgorithmECDH_ES_HKDF_256, secret, &h, AlgorithmA128GCM); err == nil {
+		t.Errorf("ecdhDeriveKey() error = nil, wantErr true")
+	}
+
+	// invalid salt
+	h.Unprotected[HeaderLabelSalt] = "salt"
+	if _, err := ecdhDeriveKey(AlgorithmECDH_ES_HKDF_256, secret, &h, AlgorithmA128GCM); err == nil {
+		t.Errorf("ecdhDeriveKey() error = nil, wantErr true")
+	}
+
+	// unknown key size
+	if _, err := ecdhDeriveKey(AlgorithmECDH_ES_HKDF_256, secret, &Headers{}, AlgorithmES256); err == nil {
+		t.Errorf("ecdhDeriveKey() error = nil, wantErr true")
+	}
+}
+
+func Test_ecdhDecodeKey(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	x, y := key.X.FillBytes(make([]byte, 32)), key.Y.FillBytes(make([]byte, 32))
+	compressed := elliptic.MarshalCompressed(elliptic.P256(), key.X, key.Y)
+	x25519 := generateTestX25519Key(t)
+	tests := []struct {
+		name    string
+		value   interface{}
+		curve   Curve
+		want    crypto.PublicKey
+		wantErr bool
+	}{
+		{
+			name: "uncompressed point",
+			value: map[interface{}]interface{}{
+				int64(1):  int64(2),
+				int64(-1): int64(1),
+				int64(-2): x,
+				int64(-3): y,
+			},
+			curve: CurveP256,
+			want:  key.Public(),
+		},
+		{
+			name: "compressed point",
+			value: map[interface{}]interface{}{
+				1:  2,
+				-1: 1,
+				-2: x,
+				-3: compressed[0] == 0x03,
+			},
+			curve: CurveP256,
+			want:  key.Public(),
+		},
+		{
+			name: "X25519",
+			value: map[interface{}]interface{}{
+				1:  1,
+				-1: 4,
+				-2: []byte(x25519.Public().(X25519PublicKey)),
+			},
+			curve: CurveX25519,
+			want:  ecdhPublicKey(x25519),
+		},
+		{
+			name:    "not a map",
+			value:   []byte("foo"),
+			curve:   CurveP256,
+			wantErr: true,
+		},
+		{
+			name: "invalid label",
+			value: map[interface{}]interface{}{
+				1.5: 2,
+			},
+			curve:   CurveP256,
+			wantErr: true,
+		},
+		{
+			name: "OKP key type",
+			value: map[interface{}]interface{}{
+				1:  1,
+				-1: 1,
+				-2: x,
+				-3: y,
+			},
+			curve:   CurveP256,
+			wantErr: true,
+		},
+		{
+			name: "curve mismatch",
+			value: map[interface{}]interface{}{
+				1:  2,
+				-1: 2,
+				-2: x,
+				-3: y,
+			},
+			curve:   CurveP256,
+			wantErr: true,
+		},
+		{
+			name: "invalid x-coordinate",
+			value: map[interface{}]interface{}{
+				1:  2,
+				-1: 1,
+				-2: x[1:],
+				-3: y,
+			},
+			curve:   CurveP256,
+			wantErr: true,
+		},
+		{
+			name: "invalid y-coordinate",
+			value: map[interface{}]interface{}{
+				1:  2,
+				-1: 1,
+				-2: x,
+				-3: "foo",
+			},
+			curve:   CurveP256,
+			wantErr: true,
+		},
+		{
+			name: "point not on curve",
+			value: map[interface{}]interface{}{
+				1:  2,
+				-1: 1,
+				-2: x,
+				-3: x,
+			},
+			curve:   CurveP256,
+			wantErr: true,
+		},
+		{
+			name: "private key",
+			value: map[interface{}]interface{}{
+				1:  1,
+				-1: 4,
+				-2: []byte(x25519.Public().(X25519PublicKey)),
+				-4: []byte(x25519),
+			},
+			curve:   CurveX25519,
+			wantErr: true,
+		},
+		{
+			name:  "Key value",
+			value: ecdhEncodeKey(key.Public()),
+			curve: CurveP256,
+			want:  key.Public(),
+		},
+		{
+			name: "invalid X25519 key",
+			value: map[interface{}]interface{}{
+				1:  1,
+				-1: 4,
+				-2: []byte(x25519.Public().(X25519PublicKey))[1:],
+			},
+			curve:   CurveX25519,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ecdhDecodeKey(tt.value, tt.curve)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ecdhDecodeKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !tt.want.(interface{ Equal(crypto.PublicKey) bool }).Equal(got) {
+				t.Errorf("ecdhDecodeKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ecdhEncodeKey(t *testing.T) {
+	for _, curve := range []Curve{CurveP256, CurveP384, CurveP521, CurveX25519} {
+		key := ecdhPublicKey(generateTestECDHKey(t, curve))
+		got, err := ecdhDecodeKey(ecdhEncodeKey(key), curve)
+		if err != nil {
+			t.Fatalf("ecdhDecodeKey() error = %v", err)
+		}
+		if !key.(interface{ Equal(crypto.PublicKey) bool }).Equal(got) {
+			t.Errorf("ecdhDecodeKey() = %v, want %v", got, key)
+		}
+	}
+}
@@ -36,6 +36,46 @@ func OS2IP(x []byte) *big.Int {
 	return new(big.Int).SetBytes(x)
 }
 
+// checkECDSAKey ensures key is an ECDSA public key.
+func checkECDSAKey(key crypto.PublicKey) error {
+	if _, ok := key.(*ecdsa.PublicKey); !ok {
+		return ErrAlgorithmMismatch
+	}
+	return nil
+}
+
+// newECDSASigner returns an ECDSA signer with a checked key.
+// Golang built-in keys are used directly for better performance.
+func newECDSASigner(alg Algorithm, key crypto.Signer) (Signer, error) {
+	if sk, ok := key.(*ecdsa.PrivateKey); ok {
+		return &ecdsaKeySigner{
+			alg: alg,
+			key: sk,
+		}, nil
+	}
+	vk, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrAlgorithmMismatch
+	}
+	return &ecdsaCryptoSigner{
+		alg:    alg,
+		key:    vk,
+		signer: key,
+	}, nil
+}
+
+// newECDSAVerifier returns an ECDSA verifier with a checked key.
+func newECDSAVerifier(alg Algorithm, key crypto.PublicKey) (Verifier, error) {
+	vk, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrAlgorithmMismatch
+	}
+	return &ecdsaVerifier{
+		alg: alg,
+		key: vk,
+	}, nil
+}
+
 // ecdsaKeySigner is a ECDSA-based signer with golang built-in keys.
 type ecdsaKeySigner struct {
 	alg Algorithm
@@ -57,6 +97,18 @@ func (es *ecdsaKeySigner) Sign(rand io.Reader, content []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	return es.SignDigest(rand, digest)
+}
+
+// SignDigest signs message digest with the private key using entropy from
+// rand.
+// The resulting signature should follow RFC 8152 section 8.1.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8.1
+func (es *ecdsaKeySigner) SignDigest(rand io.Reader, digest []byte) ([]byte, error) {
+	if err := checkDigestSize(es.alg, digest); err != nil {
+		return nil, err
+	}
 	r, s, err := ecdsa.Sign(rand, es.key, digest)
 	if err != nil {
 		return nil, err
@@ -86,6 +138,18 @@ func (es *ecdsaCryptoSigner) Sign(rand io.Reader, content []byte) ([]byte, error
 	if err != nil {
 		return nil, err
 	}
+	return es.SignDigest(rand, digest)
+}
+
+// SignDigest signs message digest with the private key, possibly using entropy
+// from rand.
+// The resulting signature should follow RFC 8152 section 8.1.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8.1
+func (es *ecdsaCryptoSigner) SignDigest(rand io.Reader, digest []byte) ([]byte, error) {
+	if err := checkDigestSize(es.alg, digest); err != nil {
+		return nil, err
+	}
 	sigASN1, err := es.signer.Sign(rand, digest, nil)
 	if err != nil {
 		return nil, err
@@ -142,6 +206,11 @@ func (ev *ecdsaVerifier) Algorithm() Algorithm {
 	return ev.alg
 }
 
+// PublicKey returns the public key of the verifier.
+func (ev *ecdsaVerifier) PublicKey() crypto.PublicKey {
+	return ev.key
+}
+
 // Verify verifies message content with the public key, returning nil for
 // success.
 // Otherwise, it returns ErrVerification.
@@ -155,6 +224,18 @@ func (ev *ecdsaVerifier) Verify(content []byte, signature []byte) error {
 	}
 
 	// verify signature
+	return ev.VerifyDigest(digest, signature)
+}
+
+// VerifyDigest verifies message digest with the public key, returning nil for
+// success.
+// Otherwise, it returns ErrVerification.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8.1
+func (ev *ecdsaVerifier) VerifyDigest(digest []byte, signature []byte) error {
+	if checkDigestSize(ev.alg, digest) != nil {
+		return ErrVerification
+	}
 	r, s, err := decodeECDSASignature(ev.key.Curve, signature)
 	if err != nil {
 		return ErrVerification
@@ -164,3 +245,12 @@ func (ev *ecdsaVerifier) Verify(content []byte, signature []byte) error {
 	}
 	return nil
 }
+
+// checkDigestSize checks the digest size against the hash function of alg,
+// since ECDSA truncates or accepts digests of any size.
+func checkDigestSize(alg Algorithm, digest []byte) error {
+	if size := alg.hashFunc().Size(); len(digest) != size {
+		return fmt.Errorf("%v: require %d-byte digest, got %d", alg, size, len(digest))
+	}
+	return nil
+}
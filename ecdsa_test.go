@@ -228,6 +228,37 @@ func testSignVerify(t *testing.T, alg Algorithm, key crypto.Signer, isCryptoSign
 	if err := verifier.Verify(content, sig); err != nil {
 		t.Fatalf("Verifier.Verify() error = %v", err)
 	}
+
+	// sign / verify digest round trip
+	digest, err := alg.computeHash(content)
+	if err != nil {
+		t.Fatalf("computeHash() error = %v", err)
+	}
+	sig, err = signer.(DigestSigner).SignDigest(rand.Reader, digest)
+	if err != nil {
+		t.Fatalf("SignDigest() error = %v", err)
+	}
+	if err := verifier.Verify(content, sig); err != nil {
+		t.Fatalf("Verifier.Verify() error = %v", err)
+	}
+	if err := verifier.(DigestVerifier).VerifyDigest(digest, sig); err != nil {
+		t.Fatalf("Verifier.VerifyDigest() error = %v", err)
+	}
+	if err := verifier.(DigestVerifier).VerifyDigest(content, sig); err != ErrVerification {
+		t.Fatalf("Verifier.VerifyDigest() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// digest size mismatch
+	longDigest := append(digest, make([]byte, len(digest))...)
+	if err := verifier.(DigestVerifier).VerifyDigest(longDigest, sig); err != ErrVerification {
+		t.Fatalf("Verifier.VerifyDigest() error = %v, wantErr %v", err, ErrVerification)
+	}
+	if _, err := signer.(DigestSigner).SignDigest(rand.Reader, longDigest); err == nil {
+		t.Fatalf("SignDigest() error = nil, wantErr true")
+	}
+	if _, err := signer.(DigestSigner).SignDigest(rand.Reader, digest[:len(digest)-1]); err == nil {
+		t.Fatalf("SignDigest() error = nil, wantErr true")
+	}
 }
 
 type ecdsaBadCryptoSigner struct {
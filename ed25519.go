@@ -6,6 +6,32 @@ import (
 	"io"
 )
 
+// checkEd25519Key ensures key is an Ed25519 public key.
+func checkEd25519Key(key crypto.PublicKey) error {
+	if _, ok := key.(ed25519.PublicKey); !ok {
+		return ErrAlgorithmMismatch
+	}
+	return nil
+}
+
+// newEd25519Signer returns a Pure EdDSA signer with a checked key.
+func newEd25519Signer(_ Algorithm, key crypto.Signer) (Signer, error) {
+	return &ed25519Signer{
+		key: key,
+	}, nil
+}
+
+// newEd25519Verifier returns a Pure EdDSA verifier with a checked key.
+func newEd25519Verifier(_ Algorithm, key crypto.PublicKey) (Verifier, error) {
+	vk, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrAlgorithmMismatch
+	}
+	return &ed25519Verifier{
+		key: vk,
+	}, nil
+}
+
 // ed25519Signer is a Pure EdDSA based signer with a generic crypto.Signer.
 type ed25519Signer struct {
 	key crypto.Signer
@@ -37,6 +63,11 @@ func (ev *ed25519Verifier) Algorithm() Algorithm {
 	return AlgorithmEd25519
 }
 
+// PublicKey returns the public key of the verifier.
+func (ev *ed25519Verifier) PublicKey() crypto.PublicKey {
+	return ev.key
+}
+
 // Verify verifies message content with the public key, returning nil for
 // success.
 // Otherwise, it returns ErrVerification.
@@ -0,0 +1,252 @@
+package cose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// encryptMessage represents a COSE_Encrypt CBOR object:
+//
+//	COSE_Encrypt = [
+//	    Headers,
+//	    ciphertext : bstr / nil,
+//	    recipients : [+COSE_recipient]
+//	]
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.1
+type encryptMessage struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   cbor.RawMessage
+	Unprotected cbor.RawMessage
+	Ciphertext  byteString
+	Recipients  []cbor.RawMessage
+}
+
+// encryptMessagePrefix represents the fixed prefix of COSE_Encrypt_Tagged.
+var encryptMessagePrefix = []byte{
+	0xd8, 0x60, // #6.96
+	0x84, // Array of length 4
+}
+
+// EncryptMessage represents a decoded COSE_Encrypt message.
+// Payload is the plaintext, which is not encoded by MarshalCBOR.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.1
+type EncryptMessage struct {
+	Headers    Headers
+	Payload    []byte
+	Ciphertext []byte
+	Recipients []Recipient
+}
+
+// NewEncryptMessage returns an EncryptMessage with header initialized.
+func NewEncryptMessage() *EncryptMessage {
+	return &EncryptMessage{
+		Headers: Headers{
+			Protected:   ProtectedHeader{},
+			Unprotected: UnprotectedHeader{},
+		},
+	}
+}
+
+// Kind returns MessageKindEncrypt.
+func (m *EncryptMessage) Kind() MessageKind {
+	return MessageKindEncrypt
+}
+
+// MessageHeaders returns the headers of the message body.
+func (m *EncryptMessage) MessageHeaders() *Headers {
+	if m == nil {
+		return nil
+	}
+	return &m.Headers
+}
+
+// MarshalCBOR encodes EncryptMessage into a COSE_Encrypt_Tagged object.
+// A nil Ciphertext is encoded as detached.
+func (m *EncryptMessage) MarshalCBOR() ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil EncryptMessage pointer")
+	}
+	if len(m.Recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	protected, unprotected, err := m.Headers.marshal()
+	if err != nil {
+		return nil, err
+	}
+	recipients, err := marshalRecipients(m.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	content := encryptMessage{
+		Protected:   protected,
+		Unprotected: unprotected,
+		Ciphertext:  m.Ciphertext,
+		Recipients:  recipients,
+	}
+	return encMode.Marshal(cbor.Tag{
+		Number:  CBORTagEncryptMessage,
+		Content: content,
+	})
+}
+
+// UnmarshalCBOR decodes a COSE_Encrypt_Tagged object into EncryptMessage.
+func (m *EncryptMessage) UnmarshalCBOR(data []byte) error {
+	if m == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil EncryptMessage pointer")
+	}
+
+	if err := defaultDecoder.validate(data); err != nil {
+		return err
+	}
+
+	// fast message check
+	if !bytes.HasPrefix(data, encryptMessagePrefix) {
+		return errors.New("cbor: invalid COSE_Encrypt_Tagged object")
+	}
+
+	// decode to encryptMessage and parse
+	var raw encryptMessage
+	if err := decModeWithTagsForbidden.Unmarshal(data[2:], &raw); err != nil {
+		return err
+	}
+	recipients, err := unmarshalRecipients(raw.Recipients)
+	if err != nil {
+		return err
+	}
+	msg := EncryptMessage{
+		Headers: Headers{
+			RawProtected:   raw.Protected,
+			RawUnprotected: raw.Unprotected,
+		},
+		Ciphertext: raw.Ciphertext,
+		Recipients: recipients,
+	}
+	if err := msg.Headers.UnmarshalFromRaw(); err != nil {
+		return err
+	}
+
+	*m = msg
+	return nil
+}
+
+// Encrypt encrypts m.Payload and distributes the content encryption key to the
+// recipients using the provided encrypters corresponding to the recipients.
+// The ciphertext is stored in m.Ciphertext.
+// The content encryption algorithm is taken from the `alg` header of
+// m.Headers.Protected.
+//
+// If there are multiple recipients, a random content encryption key is
+// generated using entropy from rand, and direct key management algorithms,
+// including direct key agreement, are rejected. Otherwise, the content
+// encryption key is chosen by the encrypter of the single recipient.
+// The IV is taken from the IV header parameter if present. Otherwise, a random
+// IV is generated using entropy from rand and added to m.Headers.Unprotected.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.3
+func (m *EncryptMessage) Encrypt(rand io.Reader, external []byte, encrypters ...KeyEncrypter) error {
+	if m == nil {
+		return errors.New("encrypting nil EncryptMessage")
+	}
+	if m.Payload == nil {
+		return ErrMissingPayload
+	}
+	if len(m.Ciphertext) > 0 {
+		return errors.New("EncryptMessage already has ciphertext bytes")
+	}
+	alg, err := m.Headers.Protected.Algorithm()
+	if err != nil {
+		return err
+	}
+
+	// distribute the content encryption key
+	key, err := distributeKey(rand, m.Recipients, encrypters, alg)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(alg, key)
+	if err != nil {
+		return err
+	}
+	iv, err := m.Headers.contentIV(rand, aead.NonceSize(), nil)
+	if err != nil {
+		return err
+	}
+
+	// encrypt the payload
+	aad, err := m.aad(external)
+	if err != nil {
+		return err
+	}
+	m.Ciphertext = aead.Seal(nil, iv, m.Payload, aad)
+	return nil
+}
+
+// Decrypt recovers the content encryption key using the provided decrypter and
+// decrypts m.Ciphertext.
+// The plaintext is stored in m.Payload.
+//
+// The content encryption key is recovered from the first recipient whose
+// algorithm matches the decrypter. If kid is not nil, only the recipients with
+// a matching `kid` header are tried.
+// A recipient with nested recipients is tried if the key of that recipient
+// layer can be recovered from the nested recipients by the decrypter.
+//
+// It returns ErrDecryption if the content encryption key cannot be recovered
+// or if the ciphertext cannot be authenticated.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.3
+func (m *EncryptMessage) Decrypt(external []byte, kid []byte, decrypter KeyDecrypter) error {
+	if m == nil {
+		return errors.New("decrypting nil EncryptMessage")
+	}
+	if m.Ciphertext == nil {
+		return ErrMissingCiphertext
+	}
+	alg, err := m.Headers.Protected.Algorithm()
+	if err != nil {
+		return err
+	}
+
+	// recover the content encryption key
+	key, err := recoverKey(m.Recipients, kid, decrypter, alg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDecryption, err)
+	}
+	aead, err := newAEAD(alg, key)
+	if err != nil {
+		return err
+	}
+	iv, err := m.Headers.contentIV(nil, aead.NonceSize(), nil)
+	if err != nil {
+		return err
+	}
+
+	// decrypt the ciphertext
+	aad, err := m.aad(external)
+	if err != nil {
+		return err
+	}
+	plaintext, err := aead.Open(nil, iv, m.Ciphertext, aad)
+	if err != nil {
+		return ErrDecryption
+	}
+	m.Payload = plaintext
+	return nil
+}
+
+// aad constructs Enc_structure and returns the additional authenticated data.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.3
+func (m *EncryptMessage) aad(external []byte) ([]byte, error) {
+	protected, err := m.Headers.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+	return encStructure("Encrypt", protected, external)
+}
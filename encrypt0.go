@@ -0,0 +1,213 @@
+package cose
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// encrypt0Message represents a COSE_Encrypt0 CBOR object:
+//
+//	COSE_Encrypt0 = [
+//	    Headers,
+//	    ciphertext : bstr / nil,
+//	]
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.2
+type encrypt0Message struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   cbor.RawMessage
+	Unprotected cbor.RawMessage
+	Ciphertext  byteString
+}
+
+// encrypt0MessagePrefix represents the fixed prefix of COSE_Encrypt0_Tagged.
+var encrypt0MessagePrefix = []byte{
+	0xd0, // #6.16
+	0x83, // Array of length 3
+}
+
+// Encrypt0Message represents a decoded COSE_Encrypt0 message.
+//
+// Payload holds the plaintext, and Ciphertext holds the encrypted payload.
+// Only Ciphertext is encoded into the COSE_Encrypt0 object.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.2
+type Encrypt0Message struct {
+	Headers    Headers
+	Payload    []byte
+	Ciphertext []byte
+}
+
+// NewEncrypt0Message returns an Encrypt0Message with header initialized.
+func NewEncrypt0Message() *Encrypt0Message {
+	return &Encrypt0Message{
+		Headers: Headers{
+			Protected:   ProtectedHeader{},
+			Unprotected: UnprotectedHeader{},
+		},
+	}
+}
+
+// Kind returns MessageKindEncrypt0.
+func (m *Encrypt0Message) Kind() MessageKind {
+	return MessageKindEncrypt0
+}
+
+// MessageHeaders returns the headers of the message body.
+func (m *Encrypt0Message) MessageHeaders() *Headers {
+	if m == nil {
+		return nil
+	}
+	return &m.Headers
+}
+
+// MarshalCBOR encodes Encrypt0Message into a COSE_Encrypt0_Tagged object.
+// A nil Ciphertext is encoded as nil, indicating a detached ciphertext.
+func (m *Encrypt0Message) MarshalCBOR() ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil Encrypt0Message pointer")
+	}
+	protected, unprotected, err := m.Headers.marshal()
+	if err != nil {
+		return nil, err
+	}
+	content := encrypt0Message{
+		Protected:   protected,
+		Unprotected: unprotected,
+		Ciphertext:  m.Ciphertext,
+	}
+	return encMode.Marshal(cbor.Tag{
+		Number:  CBORTagEncrypt0Message,
+		Content: content,
+	})
+}
+
+// UnmarshalCBOR decodes a COSE_Encrypt0_Tagged object into Encrypt0Message.
+func (m *Encrypt0Message) UnmarshalCBOR(data []byte) error {
+	if m == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil Encrypt0Message pointer")
+	}
+
+	if err := defaultDecoder.validate(data); err != nil {
+		return err
+	}
+
+	// fast message check
+	if !bytes.HasPrefix(data, encrypt0MessagePrefix) {
+		return errors.New("cbor: invalid COSE_Encrypt0_Tagged object")
+	}
+
+	// decode to encrypt0Message and parse
+	var raw encrypt0Message
+	if err := decModeWithTagsForbidden.Unmarshal(data[1:], &raw); err != nil {
+		return err
+	}
+	msg := Encrypt0Message{
+		Headers: Headers{
+			RawProtected:   raw.Protected,
+			RawUnprotected: raw.Unprotected,
+		},
+		Ciphertext: raw.Ciphertext,
+	}
+	if err := msg.Headers.UnmarshalFromRaw(); err != nil {
+		return err
+	}
+
+	*m = msg
+	return nil
+}
+
+// Encrypt encrypts m.Payload with the content encryption key.
+// The ciphertext is stored in m.Ciphertext.
+// The content encryption algorithm is taken from the `alg` header of
+// m.Headers.Protected.
+//
+// The IV is taken from the IV header parameter if present. Otherwise, a random
+// IV is generated using entropy from rand and added to m.Headers.Unprotected.
+// The Partial IV header parameter is not supported as the Base IV of the key is
+// unknown.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.3
+func (m *Encrypt0Message) Encrypt(rand io.Reader, external []byte, key []byte) error {
+	if m == nil {
+		return errors.New("encrypting nil Encrypt0Message")
+	}
+	if m.Payload == nil {
+		return ErrMissingPayload
+	}
+	if len(m.Ciphertext) > 0 {
+		return errors.New("Encrypt0Message already has ciphertext bytes")
+	}
+	alg, err := m.Headers.Protected.Algorithm()
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(alg, key)
+	if err != nil {
+		return err
+	}
+	iv, err := m.Headers.contentIV(rand, aead.NonceSize(), nil)
+	if err != nil {
+		return err
+	}
+
+	// encrypt the payload
+	aad, err := m.aad(external)
+	if err != nil {
+		return err
+	}
+	m.Ciphertext = aead.Seal(nil, iv, m.Payload, aad)
+	return nil
+}
+
+// Decrypt decrypts m.Ciphertext with the content encryption key.
+// The plaintext is stored in m.Payload.
+// It returns ErrDecryption if the ciphertext cannot be authenticated.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.3
+func (m *Encrypt0Message) Decrypt(external []byte, key []byte) error {
+	if m == nil {
+		return errors.New("decrypting nil Encrypt0Message")
+	}
+	if m.Ciphertext == nil {
+		return ErrMissingCiphertext
+	}
+	alg, err := m.Headers.Protected.Algorithm()
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(alg, key)
+	if err != nil {
+		return err
+	}
+	iv, err := m.Headers.contentIV(nil, aead.NonceSize(), nil)
+	if err != nil {
+		return err
+	}
+
+	// decrypt the ciphertext
+	aad, err := m.aad(external)
+	if err != nil {
+		return err
+	}
+	plaintext, err := aead.Open(nil, iv, m.Ciphertext, aad)
+	if err != nil {
+		return ErrDecryption
+	}
+	m.Payload = plaintext
+	return nil
+}
+
+// aad constructs Enc_structure and returns the additional authenticated data.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.3
+func (m *Encrypt0Message) aad(external []byte) ([]byte, error) {
+	protected, err := m.Headers.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+	return encStructure("Encrypt0", protected, external)
+}
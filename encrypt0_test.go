@@ -0,0 +1,417 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestEncrypt0Message_MarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       *Encrypt0Message
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "valid message",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelIV: []byte("iv"),
+					},
+				},
+				Payload:    []byte("plaintext is not encoded"),
+				Ciphertext: []byte("foo"),
+			},
+			want: []byte{
+				0xd0, // tag
+				0x83,
+				0x43, 0xa1, 0x01, 0x01, // protected
+				0xa1, 0x05, 0x42, 0x69, 0x76, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+			},
+		},
+		{
+			name: "detached ciphertext",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+			},
+			want: []byte{
+				0xd0, // tag
+				0x83,
+				0x43, 0xa1, 0x01, 0x01, // protected
+				0xa0, // unprotected
+				0xf6, // ciphertext
+			},
+		},
+		{
+			name:    "nil message",
+			m:       nil,
+			wantErr: true,
+		},
+		{
+			name: "IV and Partial IV",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelIV: []byte("iv"),
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelPartialIV: []byte("iv"),
+					},
+				},
+				Ciphertext: []byte("foo"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.MarshalCBOR()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Encrypt0Message.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Encrypt0Message.MarshalCBOR() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncrypt0Message_UnmarshalCBOR(t *testing.T) {
+	// test nil pointer
+	t.Run("nil Encrypt0Message pointer", func(t *testing.T) {
+		var msg *Encrypt0Message
+		data := []byte{0xd0, 0x83, 0x40, 0xa0, 0xf6}
+		if err := msg.UnmarshalCBOR(data); err == nil {
+			t.Errorf("want error on nil *Encrypt0Message")
+		}
+	})
+
+	// test others
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Encrypt0Message
+		wantErr bool
+	}{
+		{
+			name: "valid message",
+			data: []byte{
+				0xd0, // tag
+				0x83,
+				0x43, 0xa1, 0x01, 0x01, // protected
+				0xa1, 0x05, 0x42, 0x69, 0x76, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+			},
+			want: Encrypt0Message{
+				Headers: Headers{
+					RawProtected: []byte{0x43, 0xa1, 0x01, 0x01},
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+					RawUnprotected: []byte{0xa1, 0x05, 0x42, 0x69, 0x76},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelIV: []byte("iv"),
+					},
+				},
+				Ciphertext: []byte("foo"),
+			},
+		},
+		{
+			name: "detached ciphertext",
+			data: []byte{
+				0xd0, // tag
+				0x83,
+				0x40, // protected
+				0xa0, // unprotected
+				0xf6, // ciphertext
+			},
+			want: Encrypt0Message{
+				Headers: Headers{
+					RawProtected:   []byte{0x40},
+					Protected:      ProtectedHeader{},
+					RawUnprotected: []byte{0xa0},
+					Unprotected:    UnprotectedHeader{},
+				},
+			},
+		},
+		{
+			name: "COSE_Sign1 tag",
+			data: []byte{
+				0xd2, // tag
+				0x83,
+				0x40, // protected
+				0xa0, // unprotected
+				0xf6, // ciphertext
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ciphertext",
+			data: []byte{
+				0xd0, // tag
+				0x83,
+				0x40, // protected
+				0xa0, // unprotected
+				0x80, // ciphertext
+			},
+			wantErr: true,
+		},
+		{
+			name: "IV and Partial IV",
+			data: []byte{
+				0xd0, // tag
+				0x83,
+				0x44, 0xa1, 0x05, 0x41, 0x00, // protected
+				0xa1, 0x06, 0x41, 0x00, // unprotected
+				0xf6, // ciphertext
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Encrypt0Message
+			if err := got.UnmarshalCBOR(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("Encrypt0Message.UnmarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encrypt0Message.UnmarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncrypt0Message_Encrypt(t *testing.T) {
+	key := mustDecodeHex(t, "000102030405060708090a0b0c0d0e0f")
+	iv := mustDecodeHex(t, "02d1f7e6f26c43d4868d87ce")
+	msg := NewEncrypt0Message()
+	msg.Headers.Protected.SetAlgorithm(AlgorithmA128GCM)
+	msg.Headers.Unprotected[HeaderLabelIV] = iv
+	msg.Payload = []byte("This is the content.")
+	if err := msg.Encrypt(rand.Reader, nil, key); err != nil {
+		t.Fatalf("Encrypt0Message.Encrypt() error = %v", err)
+	}
+
+	// compute the expected ciphertext with a hand-crafted Enc_structure
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("aes.NewCipher() error = %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("cipher.NewGCM() error = %v", err)
+	}
+	aad := []byte{
+		0x83,                                                 // array of length 3
+		0x68, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x30, // "Encrypt0"
+		0x43, 0xa1, 0x01, 0x01, // protected
+		0x40, // external_aad
+	}
+	want := aead.Seal(nil, iv, msg.Payload, aad)
+	if !bytes.Equal(msg.Ciphertext, want) {
+		t.Fatalf("Encrypt0Message.Encrypt() ciphertext = %x, want %x", msg.Ciphertext, want)
+	}
+
+	// encrypt again
+	if err := msg.Encrypt(rand.Reader, nil, key); err == nil {
+		t.Errorf("Encrypt0Message.Encrypt() error = nil, wantErr true")
+	}
+}
+
+func TestEncrypt0Message_Encrypt_Decrypt(t *testing.T) {
+	tests := []struct {
+		alg     Algorithm
+		keySize int
+	}{
+		{alg: AlgorithmA128GCM, keySize: 16},
+		{alg: AlgorithmA192GCM, keySize: 24},
+		{alg: AlgorithmA256GCM, keySize: 32},
+		{alg: AlgorithmChaCha20Poly1305, keySize: 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg.String(), func(t *testing.T) {
+			key := make([]byte, tt.keySize)
+			if _, err := rand.Read(key); err != nil {
+				t.Fatalf("rand.Read() error = %v", err)
+			}
+			external := []byte("external")
+
+			// encrypt with a random IV
+			msg := NewEncrypt0Message()
+			msg.Headers.Protected.SetAlgorithm(tt.alg)
+			msg.Payload = []byte("hello world")
+			if err := msg.Encrypt(rand.Reader, external, key); err != nil {
+				t.Fatalf("Encrypt0Message.Encrypt() error = %v", err)
+			}
+			if iv, ok := msg.Headers.Unprotected[HeaderLabelIV].([]byte); !ok || len(iv) != 12 {
+				t.Fatalf("Encrypt0Message.Encrypt() IV = %v, want 12 bytes", msg.Headers.Unprotected[HeaderLabelIV])
+			}
+			data, err := msg.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("Encrypt0Message.MarshalCBOR() error = %v", err)
+			}
+
+			// decrypt
+			var got Encrypt0Message
+			if err := got.UnmarshalCBOR(data); err != nil {
+				t.Fatalf("Encrypt0Message.UnmarshalCBOR() error = %v", err)
+			}
+			if err := got.Decrypt(external, key); err != nil {
+				t.Fatalf("Encrypt0Message.Decrypt() error = %v", err)
+			}
+			if !bytes.Equal(got.Payload, msg.Payload) {
+				t.Fatalf("Encrypt0Message.Decrypt() payload = %s, want %s", got.Payload, msg.Payload)
+			}
+
+			// different external data
+			if err := got.Decrypt([]byte("foo"), key); err != ErrDecryption {
+				t.Fatalf("Encrypt0Message.Decrypt() error = %v, wantErr %v", err, ErrDecryption)
+			}
+
+			// tampered ciphertext
+			got.Ciphertext[0]++
+			if err := got.Decrypt(external, key); err != ErrDecryption {
+				t.Fatalf("Encrypt0Message.Decrypt() error = %v, wantErr %v", err, ErrDecryption)
+			}
+		})
+	}
+}
+
+func TestEncrypt0Message_Encrypt_Failure(t *testing.T) {
+	key := make([]byte, 16)
+	tests := []struct {
+		name string
+		m    *Encrypt0Message
+	}{
+		{
+			name: "nil message",
+		},
+		{
+			name: "missing payload",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+			},
+		},
+		{
+			name: "missing algorithm",
+			m: &Encrypt0Message{
+				Payload: []byte("foo"),
+			},
+		},
+		{
+			name: "key size mismatch",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA256GCM,
+					},
+				},
+				Payload: []byte("foo"),
+			},
+		},
+		{
+			name: "invalid IV length",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelIV: []byte("iv"),
+					},
+				},
+				Payload: []byte("foo"),
+			},
+		},
+		{
+			name: "Partial IV without Base IV",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelPartialIV: []byte{0x01},
+					},
+				},
+				Payload: []byte("foo"),
+			},
+		},
+		{
+			name: "IV and Partial IV",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+						HeaderLabelIV:        make([]byte, 12),
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelPartialIV: []byte{0x01},
+					},
+				},
+				Payload: []byte("foo"),
+			},
+		},
+		{
+			name: "raw unprotected header without IV",
+			m: &Encrypt0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+					RawUnprotected: []byte{0xa0},
+				},
+				Payload: []byte("foo"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.m.Encrypt(rand.Reader, nil, key); err == nil {
+				t.Errorf("Encrypt0Message.Encrypt() error = nil, wantErr true")
+			}
+		})
+	}
+}
+
+func TestEncrypt0Message_Decrypt_Failure(t *testing.T) {
+	key := make([]byte, 16)
+
+	// nil message
+	var msg *Encrypt0Message
+	if err := msg.Decrypt(nil, key); err == nil {
+		t.Errorf("Encrypt0Message.Decrypt() error = nil, wantErr true")
+	}
+
+	// detached ciphertext
+	msg = NewEncrypt0Message()
+	msg.Headers.Protected.SetAlgorithm(AlgorithmA128GCM)
+	if err := msg.Decrypt(nil, key); err != ErrMissingCiphertext {
+		t.Errorf("Encrypt0Message.Decrypt() error = %v, wantErr %v", err, ErrMissingCiphertext)
+	}
+
+	// missing IV
+	msg.Ciphertext = []byte("foo")
+	if err := msg.Decrypt(nil, key); err == nil {
+		t.Errorf("Encrypt0Message.Decrypt() error = nil, wantErr true")
+	}
+}
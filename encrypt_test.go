@@ -0,0 +1,645 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncryptMessage_MarshalCBOR(t *testing.T) {
+	recipient := Recipient{
+		Headers: Headers{
+			Unprotected: UnprotectedHeader{
+				HeaderLabelAlgorithm: AlgorithmDirect,
+			},
+		},
+		Ciphertext: []byte{},
+	}
+	tests := []struct {
+		name    string
+		m       *EncryptMessage
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "valid message",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelIV: []byte("iv"),
+					},
+				},
+				Payload:    []byte("plaintext is not encoded"),
+				Ciphertext: []byte("foo"),
+				Recipients: []Recipient{recipient},
+			},
+			want: []byte{
+				0xd8, 0x60, // tag
+				0x84,
+				0x43, 0xa1, 0x01, 0x01, // protected
+				0xa1, 0x05, 0x42, 0x69, 0x76, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+				0x81, // recipients
+				0x83, 0x40, 0xa1, 0x01, 0x25, 0x40,
+			},
+		},
+		{
+			name: "detached ciphertext",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+				Recipients: []Recipient{recipient},
+			},
+			want: []byte{
+				0xd8, 0x60, // tag
+				0x84,
+				0x43, 0xa1, 0x01, 0x01, // protected
+				0xa0, // unprotected
+				0xf6, // ciphertext
+				0x81, // recipients
+				0x83, 0x40, 0xa1, 0x01, 0x25, 0x40,
+			},
+		},
+		{
+			name:    "nil message",
+			m:       nil,
+			wantErr: true,
+		},
+		{
+			name: "no recipients",
+			m: &EncryptMessage{
+				Ciphertext: []byte("foo"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid recipient",
+			m: &EncryptMessage{
+				Ciphertext: []byte("foo"),
+				Recipients: []Recipient{
+					{
+						Headers: Headers{
+							Unprotected: UnprotectedHeader{
+								HeaderLabelKeyID: 42,
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.MarshalCBOR()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EncryptMessage.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("EncryptMessage.MarshalCBOR() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptMessage_UnmarshalCBOR(t *testing.T) {
+	// test nil pointer
+	t.Run("nil EncryptMessage pointer", func(t *testing.T) {
+		var msg *EncryptMessage
+		data := []byte{0xd8, 0x60, 0x84, 0x40, 0xa0, 0xf6, 0x81, 0x83, 0x40, 0xa0, 0x40}
+		if err := msg.UnmarshalCBOR(data); err == nil {
+			t.Errorf("want error on nil *EncryptMessage")
+		}
+	})
+
+	// test others
+	tests := []struct {
+		name    string
+		data    []byte
+		want    EncryptMessage
+		wantErr bool
+	}{
+		{
+			name: "valid message",
+			data: []byte{
+				0xd8, 0x60, // tag
+				0x84,
+				0x43, 0xa1, 0x01, 0x01, // protected
+				0xa1, 0x05, 0x42, 0x69, 0x76, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+				0x81, // recipients
+				0x83, 0x40, 0xa1, 0x01, 0x25, 0x40,
+			},
+			want: EncryptMessage{
+				Headers: Headers{
+					RawProtected: []byte{0x43, 0xa1, 0x01, 0x01},
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+					RawUnprotected: []byte{0xa1, 0x05, 0x42, 0x69, 0x76},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelIV: []byte("iv"),
+					},
+				},
+				Ciphertext: []byte("foo"),
+				Recipients: []Recipient{
+					{
+						Headers: Headers{
+							RawProtected:   []byte{0x40},
+							Protected:      ProtectedHeader{},
+							RawUnprotected: []byte{0xa1, 0x01, 0x25},
+							Unprotected: UnprotectedHeader{
+								HeaderLabelAlgorithm: int64(-6),
+							},
+						},
+						Ciphertext: []byte{},
+					},
+				},
+			},
+		},
+		{
+			name: "no recipients",
+			data: []byte{
+				0xd8, 0x60, // tag
+				0x84,
+				0x40,                   // protected
+				0xa0,                   // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+				0x80, // recipients
+			},
+			wantErr: true,
+		},
+		{
+			name: "COSE_Mac tag",
+			data: []byte{
+				0xd8, 0x61, // tag
+				0x84,
+				0x40,                   // protected
+				0xa0,                   // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+				0x81, // recipients
+				0x83, 0x40, 0xa1, 0x01, 0x25, 0x40,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid recipient",
+			data: []byte{
+				0xd8, 0x60, // tag
+				0x84,
+				0x40,                   // protected
+				0xa0,                   // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+				0x81, // recipients
+				0x82, 0x40, 0xa0,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got EncryptMessage
+			if err := got.UnmarshalCBOR(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("EncryptMessage.UnmarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EncryptMessage.UnmarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptMessage_Encrypt_Decrypt(t *testing.T) {
+	directKey := make([]byte, 16)
+	if _, err := rand.Read(directKey); err != nil {
+		t.Fatalf("rand.Read() error = %v", err)
+	}
+	direct, err := NewKeyEncrypter(AlgorithmDirect, directKey)
+	if err != nil {
+		t.Fatalf("NewKeyEncrypter() error = %v", err)
+	}
+	kek := make([]byte, 16)
+	if _, err := rand.Read(kek); err != nil {
+		t.Fatalf("rand.Read() error = %v", err)
+	}
+	keyWrap, err := NewKeyEncrypter(AlgorithmA128KW, kek)
+	if err != nil {
+		t.Fatalf("NewKeyEncrypter() error = %v", err)
+	}
+	ecdhKey := generateTestECDSAKey(t)
+	ecdh, err := NewECDHKeyEncrypter(AlgorithmECDH_ES_HKDF_256, ecdhKey.Public())
+	if err != nil {
+		t.Fatalf("NewECDHKeyEncrypter() error = %v", err)
+	}
+	kek2 := make([]byte, 16)
+	if _, err := rand.Read(kek2); err != nil {
+		t.Fatalf("rand.Read() error = %v", err)
+	}
+	keyWrap2, err := NewKeyEncrypter(AlgorithmA128KW, kek2)
+	if err != nil {
+		t.Fatalf("NewKeyEncrypter() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		recipients []Recipient
+		encrypters []KeyEncrypter
+		kid        []byte
+		decrypter  func() (KeyDecrypter, error)
+		wantErr    error
+	}{
+		{
+			name:       "direct",
+			recipients: []Recipient{*NewRecipient()},
+			encrypters: []KeyEncrypter{direct},
+			decrypter: func() (KeyDecrypter, error) {
+				return NewKeyDecrypter(AlgorithmDirect, directKey)
+			},
+		},
+		{
+			name:       "ECDH-ES + HKDF-256",
+			recipients: []Recipient{*NewRecipient()},
+			encrypters: []KeyEncrypter{ecdh},
+			decrypter: func() (KeyDecrypter, error) {
+				return NewECDHKeyDecrypter(AlgorithmECDH_ES_HKDF_256, ecdhKey)
+			},
+		},
+		{
+			name:       "multiple recipients",
+			recipients: []Recipient{*NewRecipient(), *NewRecipient()},
+			encrypters: []KeyEncrypter{keyWrap2, keyWrap},
+			decrypter: func() (KeyDecrypter, error) {
+				return NewKeyDecrypter(AlgorithmA128KW, kek)
+			},
+		},
+		{
+			name: "multiple recipients with kid",
+			recipients: []Recipient{
+				{
+					Headers: Headers{
+						Unprotected: UnprotectedHeader{
+							HeaderLabelKeyID: []byte("2"),
+						},
+					},
+				},
+				{
+					Headers: Headers{
+						Unprotected: UnprotectedHeader{
+							HeaderLabelKeyID: []byte("1"),
+						},
+					},
+				},
+			},
+			encrypters: []KeyEncrypter{keyWrap2, keyWrap},
+			kid:        []byte("1"),
+			decrypter: func() (KeyDecrypter, error) {
+				return NewKeyDecrypter(AlgorithmA128KW, kek)
+			},
+		},
+		{
+			name: "kid mismatch",
+			recipients: []Recipient{
+				{
+					Headers: Headers{
+						Unprotected: UnprotectedHeader{
+							HeaderLabelKeyID: []byte("1"),
+						},
+					},
+				},
+			},
+			encrypters: []KeyEncrypter{keyWrap},
+			kid:        []byte("2"),
+			decrypter: func() (KeyDecrypter, error) {
+				return NewKeyDecrypter(AlgorithmA128KW, kek)
+			},
+			wantErr: ErrDecryption,
+		},
+		{
+			name:       "wrong key",
+			recipients: []Recipient{*NewRecipient(), *NewRecipient()},
+			encrypters: []KeyEncrypter{keyWrap, keyWrap},
+			decrypter: func() (KeyDecrypter, error) {
+				return NewKeyDecrypter(AlgorithmA128KW, kek2)
+			},
+			wantErr: ErrDecryption,
+		},
+		{
+			name:       "algorithm mismatch",
+			recipients: []Recipient{*NewRecipient()},
+			encrypters: []KeyEncrypter{keyWrap},
+			decrypter: func() (KeyDecrypter, error) {
+				return NewKeyDecrypter(AlgorithmDirect, directKey)
+			},
+			wantErr: ErrDecryption,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			external := []byte("external")
+
+			// encrypt
+			msg := NewEncryptMessage()
+			msg.Headers.Protected.SetAlgorithm(AlgorithmA128GCM)
+			msg.Payload = []byte("hello world")
+			msg.Recipients = tt.recipients
+			if err := msg.Encrypt(rand.Reader, external, tt.encrypters...); err != nil {
+				t.Fatalf("EncryptMessage.Encrypt() error = %v", err)
+			}
+			data, err := msg.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("EncryptMessage.MarshalCBOR() error = %v", err)
+			}
+
+			// decrypt
+			var got EncryptMessage
+			if err := got.UnmarshalCBOR(data); err != nil {
+				t.Fatalf("EncryptMessage.UnmarshalCBOR() error = %v", err)
+			}
+			decrypter, err := tt.decrypter()
+			if err != nil {
+				t.Fatalf("decrypter error = %v", err)
+			}
+			err = got.Decrypt(external, tt.kid, decrypter)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("EncryptMessage.Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EncryptMessage.Decrypt() error = %v", err)
+			}
+			if !bytes.Equal(got.Payload, msg.Payload) {
+				t.Fatalf("EncryptMessage.Decrypt() payload = %s, want %s", got.Payload, msg.Payload)
+			}
+
+			// different external data
+			if err := got.Decrypt(nil, tt.kid, decrypter); err != ErrDecryption {
+				t.Fatalf("EncryptMessage.Decrypt() error = %v, wantErr %v", err, ErrDecryption)
+			}
+		})
+	}
+}
+
+func TestEncryptMessage_Decrypt_NestedRecipients(t *testing.T) {
+	// the key of the AES Key Wrap layer is agreed by ECDH-ES + HKDF-256
+	ecdhKey := generateTestECDSAKey(t)
+	ecdh, err := NewECDHKeyEncrypter(AlgorithmECDH_ES_HKDF_256, ecdhKey.Public())
+	if err != nil {
+		t.Fatalf("NewECDHKeyEncrypter() error = %v", err)
+	}
+	nested := NewRecipient()
+	nested.Headers.Protected.SetAlgorithm(AlgorithmECDH_ES_HKDF_256)
+	nested.Headers.Unprotected[HeaderLabelKeyID] = []byte("ecdh")
+	kek, err := ecdh.EncryptKey(rand.Reader, nested, AlgorithmA128KW, nil)
+	if err != nil {
+		t.Fatalf("EncryptKey() error = %v", err)
+	}
+	keyWrap, err := NewKeyEncrypter(AlgorithmA128KW, kek)
+	if err != nil {
+		t.Fatalf("NewKeyEncrypter() error = %v", err)
+	}
+
+	// encrypt
+	msg := NewEncryptMessage()
+	msg.Headers.Protected.SetAlgorithm(AlgorithmA256GCM)
+	msg.Payload = []byte("hello world")
+	msg.Recipients = []Recipient{
+		{
+			Headers: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelAlgorithm: AlgorithmA128KW,
+				},
+			},
+			Recipients: []Recipient{*nested},
+		},
+	}
+	if err := msg.Encrypt(rand.Reader, nil, keyWrap); err != nil {
+		t.Fatalf("EncryptMessage.Encrypt() error = %v", err)
+	}
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("EncryptMessage.MarshalCBOR() error = %v", err)
+	}
+
+	// decrypt
+	var got EncryptMessage
+	if err := got.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("EncryptMessage.UnmarshalCBOR() error = %v", err)
+	}
+	decrypter, err := NewECDHKeyDecrypter(AlgorithmECDH_ES_HKDF_256, ecdhKey)
+	if err != nil {
+		t.Fatalf("NewECDHKeyDecrypter() error = %v", err)
+	}
+	if err := got.Decrypt(nil, []byte("ecdh"), decrypter); err != nil {
+		t.Fatalf("EncryptMessage.Decrypt() error = %v", err)
+	}
+	if !bytes.Equal(got.Payload, msg.Payload) {
+		t.Fatalf("EncryptMessage.Decrypt() payload = %s, want %s", got.Payload, msg.Payload)
+	}
+
+	// kid mismatch
+	if err := got.Decrypt(nil, []byte("foo"), decrypter); err == nil || !errors.Is(err, ErrDecryption) {
+		t.Fatalf("EncryptMessage.Decrypt() error = %v, wantErr %v", err, ErrDecryption)
+	}
+}
+
+func TestEncryptMessage_Encrypt_Failure(t *testing.T) {
+	key := make([]byte, 16)
+	encrypter, err := NewKeyEncrypter(AlgorithmDirect, key)
+	if err != nil {
+		t.Fatalf("NewKeyEncrypter() error = %v", err)
+	}
+	keyWrap, err := NewKeyEncrypter(AlgorithmA128KW, key)
+	if err != nil {
+		t.Fatalf("NewKeyEncrypter() error = %v", err)
+	}
+	ecdhKey, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateX25519Key() error = %v", err)
+	}
+	keyAgreement, err := NewECDHKeyEncrypter(AlgorithmECDH_ES_HKDF_256, ecdhKey.Public())
+	if err != nil {
+		t.Fatalf("NewECDHKeyEncrypter() error = %v", err)
+	}
+	tests := []struct {
+		name       string
+		m          *EncryptMessage
+		encrypters []KeyEncrypter
+	}{
+		{
+			name:       "nil message",
+			encrypters: []KeyEncrypter{encrypter},
+		},
+		{
+			name: "missing payload",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+				Recipients: []Recipient{*NewRecipient()},
+			},
+			encrypters: []KeyEncrypter{encrypter},
+		},
+		{
+			name: "existing ciphertext",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+				Payload:    []byte("foo"),
+				Ciphertext: []byte("bar"),
+				Recipients: []Recipient{*NewRecipient()},
+			},
+			encrypters: []KeyEncrypter{encrypter},
+		},
+		{
+			name: "missing algorithm",
+			m: &EncryptMessage{
+				Payload:    []byte("foo"),
+				Recipients: []Recipient{*NewRecipient()},
+			},
+			encrypters: []KeyEncrypter{encrypter},
+		},
+		{
+			name: "no recipients",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+				Payload: []byte("foo"),
+			},
+			encrypters: []KeyEncrypter{encrypter},
+		},
+		{
+			name: "missing encrypter",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+				Payload:    []byte("foo"),
+				Recipients: []Recipient{*NewRecipient()},
+			},
+		},
+		{
+			name: "direct with multiple recipients",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+				Payload:    []byte("foo"),
+				Recipients: []Recipient{*NewRecipient(), *NewRecipient()},
+			},
+			encrypters: []KeyEncrypter{encrypter, keyWrap},
+		},
+		{
+			name: "direct key agreement with multiple recipients",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128GCM,
+					},
+				},
+				Payload:    []byte("foo"),
+				Recipients: []Recipient{*NewRecipient(), *NewRecipient()},
+			},
+			encrypters: []KeyEncrypter{keyAgreement, keyWrap},
+		},
+		{
+			name: "key size mismatch",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA256GCM,
+					},
+				},
+				Payload:    []byte("foo"),
+				Recipients: []Recipient{*NewRecipient()},
+			},
+			encrypters: []KeyEncrypter{encrypter},
+		},
+		{
+			name: "not an AEAD algorithm",
+			m: &EncryptMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmHMAC256_256,
+					},
+				},
+				Payload:    []byte("foo"),
+				Recipients: []Recipient{*NewRecipient()},
+			},
+			encrypters: []KeyEncrypter{encrypter},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.m.Encrypt(rand.Reader, nil, tt.encrypters...); err == nil {
+				t.Errorf("EncryptMessage.Encrypt() error = nil, wantErr true")
+			}
+		})
+	}
+}
+
+func TestEncryptMessage_Decrypt_Failure(t *testing.T) {
+	key := make([]byte, 16)
+	decrypter, err := NewKeyDecrypter(AlgorithmDirect, key)
+	if err != nil {
+		t.Fatalf("NewKeyDecrypter() error = %v", err)
+	}
+
+	// nil message
+	var msg *EncryptMessage
+	if err := msg.Decrypt(nil, nil, decrypter); err == nil {
+		t.Errorf("EncryptMessage.Decrypt() error = nil, wantErr true")
+	}
+
+	// detached ciphertext
+	msg = NewEncryptMessage()
+	msg.Headers.Protected.SetAlgorithm(AlgorithmA128GCM)
+	msg.Recipients = []Recipient{
+		{
+			Headers: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelAlgorithm: AlgorithmDirect,
+				},
+			},
+			Ciphertext: []byte{},
+		},
+	}
+	if err := msg.Decrypt(nil, nil, decrypter); err != ErrMissingCiphertext {
+		t.Errorf("EncryptMessage.Decrypt() error = %v, wantErr %v", err, ErrMissingCiphertext)
+	}
+
+	// missing IV
+	msg.Ciphertext = []byte("foo")
+	if err := msg.Decrypt(nil, nil, decrypter); err == nil {
+		t.Errorf("EncryptMessage.Decrypt() error = nil, wantErr true")
+	}
+
+	// missing algorithm
+	delete(msg.Headers.Protected, HeaderLabelAlgorithm)
+	if err := msg.Decrypt(nil, nil, decrypter); err != ErrAlgorithmNotFound {
+		t.Errorf("EncryptMessage.Decrypt() error = %v, wantErr %v", err, ErrAlgorithmNotFound)
+	}
+}
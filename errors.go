@@ -1,16 +1,45 @@
 package cose
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Common errors
 var (
-	ErrAlgorithmMismatch     = errors.New("algorithm mismatch")
-	ErrAlgorithmNotFound     = errors.New("algorithm not found")
-	ErrAlgorithmNotSupported = errors.New("algorithm not supported")
-	ErrEmptySignature        = errors.New("empty signature")
-	ErrInvalidAlgorithm      = errors.New("invalid algorithm")
-	ErrMissingPayload        = errors.New("missing payload")
-	ErrNoSignatures          = errors.New("no signatures attached")
-	ErrUnavailableHashFunc   = errors.New("hash function is not available")
-	ErrVerification          = errors.New("verification error")
+	ErrAlgorithmMismatch        = errors.New("algorithm mismatch")
+	ErrAlgorithmNotAllowed      = errors.New("algorithm not allowed")
+	ErrAlgorithmNotFound        = errors.New("algorithm not found")
+	ErrAlgorithmNotSupported    = errors.New("algorithm not supported")
+	ErrAlgorithmRegistered      = errors.New("algorithm registered")
+	ErrDecryption               = errors.New("decryption error")
+	ErrEmptySignature           = errors.New("empty signature")
+	ErrEmptyTag                 = errors.New("empty tag")
+	ErrInvalidAlgorithm         = errors.New("invalid algorithm")
+	ErrInvalidHeaderValue       = errors.New("invalid header value")
+	ErrKeyNotFound              = errors.New("key not found")
+	ErrMessageNotSupported      = errors.New("message not supported")
+	ErrMessageRegistered        = errors.New("message registered")
+	ErrMissingCiphertext        = errors.New("missing ciphertext")
+	ErrMissingPayload           = errors.New("missing payload")
+	ErrNoCountersignatures      = errors.New("no countersignatures attached")
+	ErrNoRecipients             = errors.New("no recipients attached")
+	ErrNoSignatures             = errors.New("no signatures attached")
+	ErrNonDeterministicEncoding = errors.New("non-deterministic encoding")
+	ErrUnavailableHashFunc      = errors.New("hash function is not available")
+	ErrVerification             = errors.New("verification error")
 )
+
+// CriticalHeaderError is returned when the crit header parameter lists header
+// labels that are not understood.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-3.1
+type CriticalHeaderError struct {
+	// Labels are the critical header labels that are not understood.
+	Labels []interface{}
+}
+
+// Error implements the error interface.
+func (e *CriticalHeaderError) Error() string {
+	return fmt.Sprintf("critical headers not understood: %v", e.Labels)
+}
@@ -1,11 +1,13 @@
 package cose_test
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	_ "crypto/sha512"
 	"fmt"
+	"io"
 
 	"github.com/veraison/go-cose"
 )
@@ -170,3 +172,284 @@ func ExampleSign1() {
 	// Output:
 	// message signed
 }
+
+// This example demonstrates streaming a sequence of COSE_Sign1 messages.
+func ExampleEncoder() {
+	// create a signer and a verifier
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	signer, err := cose.NewSigner(cose.AlgorithmES256, privateKey)
+	if err != nil {
+		panic(err)
+	}
+	verifier, err := cose.NewVerifier(cose.AlgorithmES256, privateKey.Public())
+	if err != nil {
+		panic(err)
+	}
+
+	// sign and write records
+	var stream bytes.Buffer
+	enc := cose.NewEncoder(&stream)
+	for _, record := range []string{"first record", "second record"} {
+		msg := cose.NewSign1Message()
+		msg.Headers.Protected.SetAlgorithm(cose.AlgorithmES256)
+		msg.Payload = []byte(record)
+		if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+			panic(err)
+		}
+		if err := enc.Encode(msg); err != nil {
+			panic(err)
+		}
+	}
+
+	// read and verify records
+	dec := cose.NewDecoder(&stream)
+	for {
+		msg, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(err)
+		}
+		sign1, ok := msg.(*cose.Sign1Message)
+		if !ok {
+			panic("unexpected message kind " + msg.Kind().String())
+		}
+		if err := sign1.Verify(nil, verifier); err != nil {
+			panic(err)
+		}
+		fmt.Printf("verified %s\n", sign1.Payload)
+	}
+	// Output:
+	// verified first record
+	// verified second record
+}
+
+// This example demonstrates creating and verifying COSE_Mac0 messages.
+func ExampleMac0Message() {
+	// create message to be authenticated
+	msgToMAC := cose.NewMac0Message()
+	msgToMAC.Payload = []byte("hello world")
+	msgToMAC.Headers.Protected.SetAlgorithm(cose.AlgorithmHMAC256_256)
+	msgToMAC.Headers.Unprotected[cose.HeaderLabelKeyID] = []byte("1")
+
+	// create a MACer with a shared secret
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	macer, err := cose.NewMACer(cose.AlgorithmHMAC256_256, key)
+	if err != nil {
+		panic(err)
+	}
+
+	// compute the tag
+	err = msgToMAC.Create(nil, macer)
+	if err != nil {
+		panic(err)
+	}
+	tagged, err := msgToMAC.MarshalCBOR()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("message authenticated")
+
+	// create a MAC verifier from the shared secret
+	verifier, err := cose.NewMACVerifier(cose.AlgorithmHMAC256_256, key)
+	if err != nil {
+		panic(err)
+	}
+
+	// verify message
+	var msgToVerify cose.Mac0Message
+	err = msgToVerify.UnmarshalCBOR(tagged)
+	if err != nil {
+		panic(err)
+	}
+	err = msgToVerify.Verify(nil, verifier)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("message verified")
+
+	// tamper the message and verification should fail
+	msgToVerify.Payload = []byte("foobar")
+	err = msgToVerify.Verify(nil, verifier)
+	if err != cose.ErrVerification {
+		panic(err)
+	}
+	fmt.Println("verification error as expected")
+	// Output:
+	// message authenticated
+	// message verified
+	// verification error as expected
+}
+
+// This example demonstrates encrypting and decrypting COSE_Encrypt0 messages.
+func ExampleEncrypt0Message() {
+	// create message to be encrypted
+	msgToEncrypt := cose.NewEncrypt0Message()
+	msgToEncrypt.Payload = []byte("hello world")
+	msgToEncrypt.Headers.Protected.SetAlgorithm(cose.AlgorithmA128GCM)
+	msgToEncrypt.Headers.Unprotected[cose.HeaderLabelKeyID] = []byte("1")
+
+	// generate a shared content encryption key
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+
+	// encrypt message with a random IV
+	err := msgToEncrypt.Encrypt(rand.Reader, nil, key)
+	if err != nil {
+		panic(err)
+	}
+	encrypted, err := msgToEncrypt.MarshalCBOR()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("message encrypted")
+
+	// decrypt message
+	var msgToDecrypt cose.Encrypt0Message
+	err = msgToDecrypt.UnmarshalCBOR(encrypted)
+	if err != nil {
+		panic(err)
+	}
+	err = msgToDecrypt.Decrypt(nil, key)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(msgToDecrypt.Payload))
+
+	// tamper the message and decryption should fail
+	msgToDecrypt.Ciphertext[0]++
+	err = msgToDecrypt.Decrypt(nil, key)
+	if err != cose.ErrDecryption {
+		panic(err)
+	}
+	fmt.Println("decryption error as expected")
+	// Output:
+	// message encrypted
+	// hello world
+	// decryption error as expected
+}
+
+// This example demonstrates encrypting and decrypting COSE_Encrypt messages
+// with a recipient using ECDH-ES + HKDF-256.
+func ExampleEncryptMessage() {
+	// create message to be encrypted
+	msgToEncrypt := cose.NewEncryptMessage()
+	msgToEncrypt.Payload = []byte("hello world")
+	msgToEncrypt.Headers.Protected.SetAlgorithm(cose.AlgorithmA128GCM)
+
+	// create a recipient identified by its key ID
+	recipient := cose.NewRecipient()
+	recipient.Headers.Protected.SetAlgorithm(cose.AlgorithmECDH_ES_HKDF_256)
+	recipient.Headers.Unprotected[cose.HeaderLabelKeyID] = []byte("1")
+	msgToEncrypt.Recipients = []cose.Recipient{*recipient}
+
+	// create a key encrypter with the public key of the recipient
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	encrypter, err := cose.NewECDHKeyEncrypter(cose.AlgorithmECDH_ES_HKDF_256, privateKey.Public())
+	if err != nil {
+		panic(err)
+	}
+
+	// encrypt message
+	err = msgToEncrypt.Encrypt(rand.Reader, nil, encrypter)
+	if err != nil {
+		panic(err)
+	}
+	encrypted, err := msgToEncrypt.MarshalCBOR()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("message encrypted")
+
+	// create a key decrypter with the private key of the recipient
+	decrypter, err := cose.NewECDHKeyDecrypter(cose.AlgorithmECDH_ES_HKDF_256, privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	// decrypt message
+	var msgToDecrypt cose.EncryptMessage
+	err = msgToDecrypt.UnmarshalCBOR(encrypted)
+	if err != nil {
+		panic(err)
+	}
+	err = msgToDecrypt.Decrypt(nil, []byte("1"), decrypter)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(msgToDecrypt.Payload))
+	// Output:
+	// message encrypted
+	// hello world
+}
+
+// This example demonstrates distributing a public key as a COSE_Key and
+// verifying a signature with it.
+func ExampleKey() {
+	// create a signer and export its public key
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	key, err := cose.NewKeyFromPrivate(privateKey)
+	if err != nil {
+		panic(err)
+	}
+	signer, err := key.Signer()
+	if err != nil {
+		panic(err)
+	}
+	publicKey, err := cose.NewKeyFromPublic(&privateKey.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+	publicKey.ID = []byte("1")
+	data, err := publicKey.MarshalCBOR()
+	if err != nil {
+		panic(err)
+	}
+
+	// sign message
+	headers := cose.Headers{
+		Protected: cose.ProtectedHeader{
+			cose.HeaderLabelAlgorithm: signer.Algorithm(),
+		},
+		Unprotected: cose.UnprotectedHeader{
+			cose.HeaderLabelKeyID: publicKey.ID,
+		},
+	}
+	sig, err := cose.Sign1(rand.Reader, signer, headers, []byte("hello world"), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// decode the public key and verify the message
+	var decodedKey cose.Key
+	if err := decodedKey.UnmarshalCBOR(data); err != nil {
+		panic(err)
+	}
+	verifier, err := decodedKey.Verifier()
+	if err != nil {
+		panic(err)
+	}
+	var msg cose.Sign1Message
+	if err := msg.UnmarshalCBOR(sig); err != nil {
+		panic(err)
+	}
+	err = msg.Verify(nil, verifier)
+	fmt.Println("verification error:", err)
+	// Output:
+	// verification error: <nil>
+}
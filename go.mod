@@ -2,6 +2,12 @@ module github.com/veraison/go-cose
 
 go 1.18
 
-require github.com/fxamacker/cbor/v2 v2.4.0
+require (
+	github.com/fxamacker/cbor/v2 v2.4.0
+	golang.org/x/crypto v0.5.0
+)
 
-require github.com/x448/float16 v0.8.4 // indirect
+require (
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/sys v0.4.0 // indirect
+)
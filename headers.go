@@ -1,8 +1,10 @@
 package cose
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 
 	"github.com/fxamacker/cbor/v2"
@@ -12,18 +14,38 @@ import (
 //
 // Reference: https://www.iana.org/assignments/cose/cose.xhtml#header-parameters
 const (
-	HeaderLabelAlgorithm         int64 = 1
-	HeaderLabelCritical          int64 = 2
-	HeaderLabelContentType       int64 = 3
-	HeaderLabelKeyID             int64 = 4
-	HeaderLabelIV                int64 = 5
-	HeaderLabelPartialIV         int64 = 6
-	HeaderLabelCounterSignature  int64 = 7
-	HeaderLabelCounterSignature0 int64 = 9
-	HeaderLabelX5Bag             int64 = 32
-	HeaderLabelX5Chain           int64 = 33
-	HeaderLabelX5T               int64 = 34
-	HeaderLabelX5U               int64 = 35
+	HeaderLabelAlgorithm           int64 = 1
+	HeaderLabelCritical            int64 = 2
+	HeaderLabelContentType         int64 = 3
+	HeaderLabelKeyID               int64 = 4
+	HeaderLabelIV                  int64 = 5
+	HeaderLabelPartialIV           int64 = 6
+	HeaderLabelCounterSignature    int64 = 7
+	HeaderLabelCounterSignature0   int64 = 9
+	HeaderLabelCounterSignatureV2  int64 = 11
+	HeaderLabelCounterSignature0V2 int64 = 12
+	HeaderLabelCWTClaims           int64 = 15
+	HeaderLabelX5Bag               int64 = 32
+	HeaderLabelX5Chain             int64 = 33
+	HeaderLabelX5T                 int64 = 34
+	HeaderLabelX5U                 int64 = 35
+)
+
+// COSE Header labels of the key agreement algorithms registered in the IANA
+// "COSE Header Algorithm Parameters" registry.
+//
+// Reference: https://www.iana.org/assignments/cose/cose.xhtml#header-algorithm-parameters
+const (
+	HeaderLabelEphemeralKey   int64 = -1
+	HeaderLabelStaticKey      int64 = -2
+	HeaderLabelStaticKeyID    int64 = -3
+	HeaderLabelSalt           int64 = -20
+	HeaderLabelPartyUIdentity int64 = -21
+	HeaderLabelPartyUNonce    int64 = -22
+	HeaderLabelPartyUOther    int64 = -23
+	HeaderLabelPartyVIdentity int64 = -24
+	HeaderLabelPartyVNonce    int64 = -25
+	HeaderLabelPartyVOther    int64 = -26
 )
 
 // ProtectedHeader contains parameters that are to be cryptographically
@@ -58,6 +80,27 @@ func (h *ProtectedHeader) UnmarshalCBOR(data []byte) error {
 	if h == nil {
 		return errors.New("cbor: UnmarshalCBOR on nil ProtectedHeader pointer")
 	}
+	return h.unmarshal(defaultDecoder, data)
+}
+
+// UnmarshalCBORWithOptions decodes a CBOR bstr object into ProtectedHeader
+// with the limits of opts, in place of those set by SetDecOptions.
+func (h *ProtectedHeader) UnmarshalCBORWithOptions(data []byte, opts DecOptions) error {
+	if h == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil ProtectedHeader pointer")
+	}
+	d, err := opts.decoder()
+	if err != nil {
+		return err
+	}
+	if err := d.validate(data); err != nil {
+		return err
+	}
+	return h.unmarshal(d, data)
+}
+
+// unmarshal decodes a CBOR bstr object into ProtectedHeader using the decoder.
+func (h *ProtectedHeader) unmarshal(d *decoder, data []byte) error {
 	var encoded byteString
 	if err := encoded.UnmarshalCBOR(data); err != nil {
 		return err
@@ -71,13 +114,19 @@ func (h *ProtectedHeader) UnmarshalCBOR(data []byte) error {
 		if encoded[0]>>5 != 5 { // major type 5: map
 			return errors.New("cbor: protected header: require map type")
 		}
-		if err := validateHeaderLabelCBOR(encoded); err != nil {
+		if err := validateHeaderLabelCBOR(d, encoded); err != nil {
 			return err
 		}
+		if err := d.checkDeterministic(encoded); err != nil {
+			return fmt.Errorf("protected header: %w", err)
+		}
 		var header map[interface{}]interface{}
-		if err := decMode.Unmarshal(encoded, &header); err != nil {
+		if err := d.mode.Unmarshal(encoded, &header); err != nil {
 			return err
 		}
+		if err := d.checkHeaders(len(header)); err != nil {
+			return fmt.Errorf("protected header: %w", err)
+		}
 		candidate := ProtectedHeader(header)
 		if err := validateHeaderParameters(candidate, true); err != nil {
 			return fmt.Errorf("protected header: %w", err)
@@ -88,6 +137,11 @@ func (h *ProtectedHeader) UnmarshalCBOR(data []byte) error {
 			candidate.SetAlgorithm(alg)
 		}
 
+		// cast to type CWTClaims if `CWT Claims` presents
+		if claims, err := candidate.CWTClaims(); err == nil && claims != nil {
+			candidate.SetCWTClaims(claims)
+		}
+
 		*h = candidate
 	}
 	return nil
@@ -138,6 +192,63 @@ func (h ProtectedHeader) Critical() ([]interface{}, error) {
 	return value.([]interface{}), nil
 }
 
+// SetCWTClaims sets the CWT claims value to the CWT Claims header.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9597#section-2
+func (h ProtectedHeader) SetCWTClaims(claims CWTClaims) {
+	h[HeaderLabelCWTClaims] = claims
+}
+
+// CWTClaims gets the CWT claims value from the CWT Claims header.
+// The integer claim keys of the returned claims are of type int64.
+// It returns nil if the header is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9597#section-2
+func (h ProtectedHeader) CWTClaims() (CWTClaims, error) {
+	value, ok := h[HeaderLabelCWTClaims]
+	if !ok {
+		return nil, nil
+	}
+	return toCWTClaims(value)
+}
+
+// CheckCritical ensures all the labels of the crit header parameter are
+// understood, either by this library or by the application as listed in
+// understood.
+// This library understands the alg, crit, content type, kid, IV and Partial IV
+// header parameters. Any other label, including those with accessors in this
+// package such as x5chain, must be listed in understood by the application
+// processing it.
+// It returns a *CriticalHeaderError listing the labels not understood.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-3.1
+func (h ProtectedHeader) CheckCritical(understood ...interface{}) error {
+	labels, err := h.Critical()
+	if err != nil {
+		return err
+	}
+	var unknown []interface{}
+	for _, label := range labels {
+		if isKnownHeaderLabel(label) {
+			continue
+		}
+		found := false
+		for _, candidate := range understood {
+			if sameLabel(label, candidate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, label)
+		}
+	}
+	if len(unknown) > 0 {
+		return &CriticalHeaderError{Labels: unknown}
+	}
+	return nil
+}
+
 // ensureCritical ensures all critical headers are present in the protected bucket.
 func ensureCritical(value interface{}, headers map[interface{}]interface{}) error {
 	labels, ok := value.([]interface{})
@@ -182,6 +293,28 @@ func (h *UnprotectedHeader) UnmarshalCBOR(data []byte) error {
 	if h == nil {
 		return errors.New("cbor: UnmarshalCBOR on nil UnprotectedHeader pointer")
 	}
+	return h.unmarshal(defaultDecoder, data)
+}
+
+// UnmarshalCBORWithOptions decodes a CBOR map object into UnprotectedHeader
+// with the limits of opts, in place of those set by SetDecOptions.
+func (h *UnprotectedHeader) UnmarshalCBORWithOptions(data []byte, opts DecOptions) error {
+	if h == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil UnprotectedHeader pointer")
+	}
+	d, err := opts.decoder()
+	if err != nil {
+		return err
+	}
+	if err := d.validate(data); err != nil {
+		return err
+	}
+	return h.unmarshal(d, data)
+}
+
+// unmarshal decodes a CBOR map object into UnprotectedHeader using the
+// decoder.
+func (h *UnprotectedHeader) unmarshal(d *decoder, data []byte) error {
 	if data == nil {
 		return errors.New("cbor: nil unprotected header")
 	}
@@ -191,13 +324,16 @@ func (h *UnprotectedHeader) UnmarshalCBOR(data []byte) error {
 	if data[0]>>5 != 5 { // major type 5: map
 		return errors.New("cbor: unprotected header: require map type")
 	}
-	if err := validateHeaderLabelCBOR(data); err != nil {
+	if err := validateHeaderLabelCBOR(d, data); err != nil {
 		return err
 	}
 	var header map[interface{}]interface{}
-	if err := decMode.Unmarshal(data, &header); err != nil {
+	if err := d.mode.Unmarshal(data, &header); err != nil {
 		return err
 	}
+	if err := d.checkHeaders(len(header)); err != nil {
+		return fmt.Errorf("unprotected header: %w", err)
+	}
 	if err := validateHeaderParameters(header, false); err != nil {
 		return fmt.Errorf("unprotected header: %w", err)
 	}
@@ -296,10 +432,39 @@ func (h *Headers) MarshalUnprotected() ([]byte, error) {
 // UnmarshalFromRaw decodes Protected from RawProtected and Unprotected from
 // RawUnprotected.
 func (h *Headers) UnmarshalFromRaw() error {
-	if err := decMode.Unmarshal(h.RawProtected, &h.Protected); err != nil {
+	return h.unmarshalFromRaw(defaultDecoder)
+}
+
+// UnmarshalFromRawWithOptions decodes Protected from RawProtected and
+// Unprotected from RawUnprotected with the limits of opts, in place of those
+// set by SetDecOptions.
+func (h *Headers) UnmarshalFromRawWithOptions(opts DecOptions) error {
+	d, err := opts.decoder()
+	if err != nil {
+		return err
+	}
+	if err := d.validate(h.RawProtected); err != nil {
+		return fmt.Errorf("cbor: invalid protected header: %w", err)
+	}
+	if err := d.validate(h.RawUnprotected); err != nil {
+		return fmt.Errorf("cbor: invalid unprotected header: %w", err)
+	}
+	return h.unmarshalFromRaw(d)
+}
+
+// unmarshalFromRaw decodes Protected from RawProtected and Unprotected from
+// RawUnprotected using the decoder.
+func (h *Headers) unmarshalFromRaw(d *decoder) error {
+	if err := d.mode.Valid(h.RawProtected); err != nil {
 		return fmt.Errorf("cbor: invalid protected header: %w", err)
 	}
-	if err := decMode.Unmarshal(h.RawUnprotected, &h.Unprotected); err != nil {
+	if err := h.Protected.unmarshal(d, h.RawProtected); err != nil {
+		return fmt.Errorf("cbor: invalid protected header: %w", err)
+	}
+	if err := d.mode.Valid(h.RawUnprotected); err != nil {
+		return fmt.Errorf("cbor: invalid unprotected header: %w", err)
+	}
+	if err := h.Unprotected.unmarshal(d, h.RawUnprotected); err != nil {
 		return fmt.Errorf("cbor: invalid unprotected header: %w", err)
 	}
 	if err := h.ensureIV(); err != nil {
@@ -308,16 +473,420 @@ func (h *Headers) UnmarshalFromRaw() error {
 	return nil
 }
 
+// KeyID gets the key identifier from the kid header parameter.
+// It returns nil if the parameter is not present.
+func (h *Headers) KeyID() ([]byte, error) {
+	kid, err := h.headerBytes(HeaderLabelKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: kid: %v", ErrInvalidHeaderValue, err)
+	}
+	return kid, nil
+}
+
+// SetKeyID sets the key identifier to the kid header parameter of the
+// protected header, and removes it from the unprotected header.
+func (h *Headers) SetKeyID(kid []byte) {
+	h.setProtected(HeaderLabelKeyID, kid)
+}
+
+// ContentType gets the content type from the content type header parameter.
+// The content type is either a media type of type string or a CoAP
+// Content-Format of type uint64.
+// It returns nil if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-3.1
+func (h *Headers) ContentType() (interface{}, error) {
+	value, ok := h.lookup(HeaderLabelContentType)
+	if !ok {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case uint64:
+		return v, nil
+	}
+	if canUint(value) {
+		cty, _ := intValue(value)
+		return uint64(cty), nil
+	}
+	return nil, fmt.Errorf("%w: content type: require tstr / uint type", ErrInvalidHeaderValue)
+}
+
+// SetContentType sets the content type to the content type header parameter of
+// the protected header, and removes it from the unprotected header.
+// The content type is required to be either a media type of type string or a
+// CoAP Content-Format of an unsigned integer.
+func (h *Headers) SetContentType(cty interface{}) error {
+	if !canTstr(cty) && !canUint(cty) {
+		return fmt.Errorf("%w: content type: require tstr / uint type", ErrInvalidHeaderValue)
+	}
+	h.setProtected(HeaderLabelContentType, cty)
+	return nil
+}
+
+// IV gets the full initialization vector from the IV header parameter.
+// It returns nil if the parameter is not present.
+func (h *Headers) IV() ([]byte, error) {
+	iv, err := h.headerBytes(HeaderLabelIV)
+	if err != nil {
+		return nil, fmt.Errorf("%w: IV: %v", ErrInvalidHeaderValue, err)
+	}
+	return iv, nil
+}
+
+// SetIV sets the full initialization vector to the IV header parameter of the
+// unprotected header, and removes it from the protected header.
+func (h *Headers) SetIV(iv []byte) {
+	h.setUnprotected(HeaderLabelIV, iv)
+}
+
+// PartialIV gets the partial initialization vector from the Partial IV header
+// parameter.
+// It returns nil if the parameter is not present.
+func (h *Headers) PartialIV() ([]byte, error) {
+	partialIV, err := h.headerBytes(HeaderLabelPartialIV)
+	if err != nil {
+		return nil, fmt.Errorf("%w: Partial IV: %v", ErrInvalidHeaderValue, err)
+	}
+	return partialIV, nil
+}
+
+// SetPartialIV sets the partial initialization vector to the Partial IV header
+// parameter of the unprotected header, and removes it from the protected
+// header.
+func (h *Headers) SetPartialIV(partialIV []byte) {
+	h.setUnprotected(HeaderLabelPartialIV, partialIV)
+}
+
+// X5Chain gets the DER encoded certificates from the x5chain header parameter,
+// with the certificate containing the end-entity key first.
+// It returns nil if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) X5Chain() ([][]byte, error) {
+	return h.certificates(HeaderLabelX5Chain, "x5chain")
+}
+
+// X5Bag gets the unordered DER encoded certificates from the x5bag header
+// parameter.
+// It returns nil if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) X5Bag() ([][]byte, error) {
+	return h.certificates(HeaderLabelX5Bag, "x5bag")
+}
+
+// certificates gets the DER encoded certificates from a COSE_X509 header
+// parameter, which is a bstr or an array of bstr.
+// It returns nil if the parameter is not present.
+func (h *Headers) certificates(label int64, name string) ([][]byte, error) {
+	value, ok := h.lookup(label)
+	if !ok {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case []byte:
+		return [][]byte{v}, nil
+	case [][]byte:
+		if len(v) == 0 {
+			return nil, fmt.Errorf("%w: %s: empty certificate chain", ErrInvalidHeaderValue, name)
+		}
+		return v, nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, fmt.Errorf("%w: %s: empty certificate chain", ErrInvalidHeaderValue, name)
+		}
+		certs := make([][]byte, len(v))
+		for i, cert := range v {
+			b, ok := cert.([]byte)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: require bstr type", ErrInvalidHeaderValue, name)
+			}
+			certs[i] = b
+		}
+		return certs, nil
+	}
+	return nil, fmt.Errorf("%w: %s: require bstr / array type", ErrInvalidHeaderValue, name)
+}
+
+// SetX5Chain sets the DER encoded certificates to the x5chain header parameter
+// of the protected header, and removes it from the unprotected header.
+// A single certificate is encoded as a bstr and multiple certificates as an
+// array of bstr.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) SetX5Chain(certs [][]byte) {
+	if len(certs) == 1 {
+		h.setProtected(HeaderLabelX5Chain, certs[0])
+		return
+	}
+	h.setProtected(HeaderLabelX5Chain, certs)
+}
+
+// X5T gets the hash algorithm and the hash value of the certificate thumbprint
+// from the x5t header parameter.
+// It returns a nil hash value if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) X5T() (Algorithm, []byte, error) {
+	value, ok := h.lookup(HeaderLabelX5T)
+	if !ok {
+		return 0, nil, nil
+	}
+	thumbprint, ok := value.([]interface{})
+	if !ok || len(thumbprint) != 2 {
+		return 0, nil, fmt.Errorf("%w: x5t: require array of 2 elements", ErrInvalidHeaderValue)
+	}
+	alg, err := ProtectedHeader{HeaderLabelAlgorithm: thumbprint[0]}.Algorithm()
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: x5t: hash algorithm: require int type", ErrInvalidHeaderValue)
+	}
+	hash, ok := thumbprint[1].([]byte)
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: x5t: hash value: require bstr type", ErrInvalidHeaderValue)
+	}
+	return alg, hash, nil
+}
+
+// SetX5T sets the hash algorithm and the hash value of the certificate
+// thumbprint to the x5t header parameter of the protected header, and removes
+// it from the unprotected header.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) SetX5T(alg Algorithm, hash []byte) {
+	h.setProtected(HeaderLabelX5T, []interface{}{alg, hash})
+}
+
+// X5U gets the URI of the certificates from the x5u header parameter.
+// It returns an empty string if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) X5U() (string, error) {
+	value, ok := h.lookup(HeaderLabelX5U)
+	if !ok {
+		return "", nil
+	}
+	uri, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: x5u: require tstr type", ErrInvalidHeaderValue)
+	}
+	return uri, nil
+}
+
+// SetX5U sets the URI of the certificates to the x5u header parameter of the
+// protected header, and removes it from the unprotected header.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) SetX5U(uri string) {
+	h.setProtected(HeaderLabelX5U, uri)
+}
+
+// CounterSignature gets the COSE_Signature objects from the counter signature
+// header parameter.
+// It returns nil if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.5
+func (h *Headers) CounterSignature() ([]*Signature, error) {
+	value, ok := h.lookup(HeaderLabelCounterSignature)
+	if !ok {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case *Signature:
+		return []*Signature{v}, nil
+	case []*Signature:
+		return v, nil
+	}
+	elements, err := signatureElements(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: counter signature: %v", ErrInvalidHeaderValue, err)
+	}
+	sigs := make([]*Signature, len(elements))
+	for i, element := range elements {
+		sigs[i] = &Signature{}
+		if err := sigs[i].UnmarshalCBOR(element); err != nil {
+			return nil, fmt.Errorf("%w: counter signature: %v", ErrInvalidHeaderValue, err)
+		}
+	}
+	return sigs, nil
+}
+
+// SetCounterSignature sets the COSE_Signature objects to the counter signature
+// header parameter of the unprotected header, and removes it from the protected
+// header.
+// A single counter signature is encoded as a COSE_Signature and multiple
+// counter signatures as an array of COSE_Signature.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.5
+func (h *Headers) SetCounterSignature(sigs ...*Signature) {
+	if len(sigs) == 1 {
+		h.setUnprotected(HeaderLabelCounterSignature, sigs[0])
+		return
+	}
+	h.setUnprotected(HeaderLabelCounterSignature, sigs)
+}
+
+// CountersignaturesV2 gets the COSE_Countersignature objects from the
+// Countersignature version 2 header parameter.
+// It returns nil if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (h *Headers) CountersignaturesV2() ([]*CountersignatureV2, error) {
+	value, ok := h.lookup(HeaderLabelCounterSignatureV2)
+	if !ok {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case *CountersignatureV2:
+		return []*CountersignatureV2{v}, nil
+	case []*CountersignatureV2:
+		return v, nil
+	}
+	elements, err := signatureElements(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: countersignature: %v", ErrInvalidHeaderValue, err)
+	}
+	sigs := make([]*CountersignatureV2, len(elements))
+	for i, element := range elements {
+		sigs[i] = &CountersignatureV2{}
+		if err := sigs[i].UnmarshalCBOR(element); err != nil {
+			return nil, fmt.Errorf("%w: countersignature: %v", ErrInvalidHeaderValue, err)
+		}
+	}
+	return sigs, nil
+}
+
+// SetCountersignaturesV2 sets the COSE_Countersignature objects to the
+// Countersignature version 2 header parameter of the unprotected header, and
+// removes it from the protected header.
+// A single countersignature is encoded as a COSE_Countersignature and multiple
+// countersignatures as an array of COSE_Countersignature.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (h *Headers) SetCountersignaturesV2(sigs ...*CountersignatureV2) {
+	if len(sigs) == 1 {
+		h.setUnprotected(HeaderLabelCounterSignatureV2, sigs[0])
+		return
+	}
+	h.setUnprotected(HeaderLabelCounterSignatureV2, sigs)
+}
+
+// Countersignature0V2 gets the abbreviated countersignature from the
+// Countersignature0 version 2 header parameter.
+// It returns nil if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.2
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (h *Headers) Countersignature0V2() ([]byte, error) {
+	sig, err := h.headerBytes(HeaderLabelCounterSignature0V2)
+	if err != nil {
+		return nil, fmt.Errorf("%w: countersignature0: %v", ErrInvalidHeaderValue, err)
+	}
+	return sig, nil
+}
+
+// SetCountersignature0V2 sets the abbreviated countersignature to the
+// Countersignature0 version 2 header parameter of the unprotected header, and
+// removes it from the protected header.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.2
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (h *Headers) SetCountersignature0V2(sig []byte) {
+	h.setUnprotected(HeaderLabelCounterSignature0V2, sig)
+}
+
+// signatureElements returns the encoded COSE_Signature objects of a header
+// value holding either a single COSE_Signature or an array of COSE_Signature.
+func signatureElements(value interface{}) ([]cbor.RawMessage, error) {
+	data, err := encMode.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var elements []cbor.RawMessage
+	if err := decMode.Unmarshal(data, &elements); err != nil || len(elements) == 0 {
+		return nil, errors.New("require non-empty array type")
+	}
+	if elements[0][0]>>5 != 4 { // major type 4: array
+		// a single COSE_Signature
+		elements = []cbor.RawMessage{data}
+	}
+	return elements, nil
+}
+
+// lookup gets a header parameter from the protected header, or from the
+// unprotected header if it is absent in the protected header.
+func (h *Headers) lookup(label interface{}) (interface{}, bool) {
+	if value, ok := h.Protected[label]; ok {
+		return value, true
+	}
+	value, ok := h.Unprotected[label]
+	return value, ok
+}
+
+// setProtected sets a header parameter to the protected header, and removes it
+// from the unprotected header.
+func (h *Headers) setProtected(label, value interface{}) {
+	if h.Protected == nil {
+		h.Protected = make(ProtectedHeader)
+	}
+	h.Protected[label] = value
+	delete(h.Unprotected, label)
+}
+
+// setUnprotected sets a header parameter to the unprotected header, and removes
+// it from the protected header.
+func (h *Headers) setUnprotected(label, value interface{}) {
+	if h.Unprotected == nil {
+		h.Unprotected = make(UnprotectedHeader)
+	}
+	h.Unprotected[label] = value
+	delete(h.Protected, label)
+}
+
 // ensureSigningAlgorithm ensures the presence of the `alg` header if there is
 // no externally supplied data for signing.
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
 func (h *Headers) ensureSigningAlgorithm(alg Algorithm, external []byte) error {
+	return h.ensureCreationAlgorithm("signer", alg, external)
+}
+
+// ensureMACAlgorithm ensures the presence of the `alg` header if there is no
+// externally supplied data for computing the authentication tag.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func (h *Headers) ensureMACAlgorithm(alg Algorithm, external []byte) error {
+	return h.ensureCreationAlgorithm("MACer", alg, external)
+}
+
+// ensureCreationAlgorithm ensures the presence of the `alg` header if there is
+// no externally supplied data, and that it matches the algorithm of the
+// signer or the MACer named by role.
+func (h *Headers) ensureCreationAlgorithm(role string, alg Algorithm, external []byte) error {
 	candidate, err := h.Protected.Algorithm()
 	switch err {
 	case nil:
 		if candidate != alg {
-			return fmt.Errorf("%w: signer %v: header %v", ErrAlgorithmMismatch, alg, candidate)
+			return fmt.Errorf("%w: %s %v: header %v", ErrAlgorithmMismatch, role, alg, candidate)
 		}
 		return nil
 	case ErrAlgorithmNotFound:
@@ -341,11 +910,26 @@ func (h *Headers) ensureSigningAlgorithm(alg Algorithm, external []byte) error {
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
 func (h *Headers) ensureVerificationAlgorithm(alg Algorithm, external []byte) error {
+	return h.ensureCheckingAlgorithm("verifier", alg, external)
+}
+
+// ensureMACVerificationAlgorithm ensures the presence of the `alg` header if
+// there is no externally supplied data for verifying the authentication tag.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func (h *Headers) ensureMACVerificationAlgorithm(alg Algorithm, external []byte) error {
+	return h.ensureCheckingAlgorithm("MAC verifier", alg, external)
+}
+
+// ensureCheckingAlgorithm ensures the presence of the `alg` header if there is
+// no externally supplied data, and that it matches the algorithm of the
+// verifier or the MAC verifier named by role.
+func (h *Headers) ensureCheckingAlgorithm(role string, alg Algorithm, external []byte) error {
 	candidate, err := h.Protected.Algorithm()
 	switch err {
 	case nil:
 		if candidate != alg {
-			return fmt.Errorf("%w: verifier %v: header %v", ErrAlgorithmMismatch, alg, candidate)
+			return fmt.Errorf("%w: %s %v: header %v", ErrAlgorithmMismatch, role, alg, candidate)
 		}
 		return nil
 	case ErrAlgorithmNotFound:
@@ -356,6 +940,57 @@ func (h *Headers) ensureVerificationAlgorithm(alg Algorithm, external []byte) er
 	return err
 }
 
+// ensureRecipientAlgorithm ensures the presence of the `alg` header of a
+// COSE_recipient. If absent, the `alg` header is added to the unprotected
+// header as key management algorithms may require the protected header to be
+// empty.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.1
+func (h *Headers) ensureRecipientAlgorithm(alg Algorithm) error {
+	candidate, err := h.algorithm()
+	switch err {
+	case nil:
+		if candidate != alg {
+			return fmt.Errorf("%w: encrypter %v: header %v", ErrAlgorithmMismatch, alg, candidate)
+		}
+		return nil
+	case ErrAlgorithmNotFound:
+		if h.RawUnprotected != nil {
+			return ErrAlgorithmNotFound
+		}
+		if h.Unprotected == nil {
+			h.Unprotected = make(UnprotectedHeader)
+		}
+		h.Unprotected[HeaderLabelAlgorithm] = alg
+		return nil
+	}
+	return err
+}
+
+// algorithm gets the algorithm value from the protected header, or from the
+// unprotected header if it is absent in the protected header.
+func (h *Headers) algorithm() (Algorithm, error) {
+	alg, err := h.Protected.Algorithm()
+	if err != ErrAlgorithmNotFound {
+		return alg, err
+	}
+	return ProtectedHeader(h.Unprotected).Algorithm()
+}
+
+// hasLabel reports whether the label is present in the protected or the
+// unprotected header.
+func (h *Headers) hasLabel(label interface{}) bool {
+	return hasLabel(h.Protected, label) || hasLabel(h.Unprotected, label)
+}
+
+// isProtectedEmpty reports whether the protected header is empty.
+func (h *Headers) isProtectedEmpty() bool {
+	if len(h.RawProtected) > 0 {
+		return bytes.Equal(h.RawProtected, []byte{0x40}) // empty bstr
+	}
+	return len(h.Protected) == 0
+}
+
 // ensureIV ensures IV and Partial IV are not both present
 // in the protected and unprotected headers.
 // It does not check if they are both present within one header,
@@ -372,12 +1007,138 @@ func (h *Headers) ensureIV() error {
 	return nil
 }
 
+// contentIV returns the IV of size bytes for the content encryption specified
+// by the IV or the Partial IV header parameters.
+// If none of them is present, a random IV is generated using entropy from rand
+// and added to the unprotected header. If rand is nil, an error is returned
+// instead.
+//
+// The Partial IV requires the Base IV of the key, which is given by baseIV.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-3.1
+func (h *Headers) contentIV(rand io.Reader, size int, baseIV []byte) ([]byte, error) {
+	if err := h.ensureIV(); err != nil {
+		return nil, err
+	}
+	iv, err := h.headerBytes(HeaderLabelIV)
+	if err != nil {
+		return nil, fmt.Errorf("IV: %w", err)
+	}
+	if iv != nil {
+		if len(iv) != size {
+			return nil, fmt.Errorf("IV: require %d bytes, got %d", size, len(iv))
+		}
+		return iv, nil
+	}
+	partialIV, err := h.headerBytes(HeaderLabelPartialIV)
+	if err != nil {
+		return nil, fmt.Errorf("Partial IV: %w", err)
+	}
+	if partialIV != nil {
+		// 1. Left-pad the Partial IV with zeros to the length of the IV.
+		// 2. XOR the padded Partial IV with the Base IV.
+		if len(baseIV) != size {
+			return nil, errors.New("Partial IV: missing base IV")
+		}
+		if len(partialIV) > size {
+			return nil, fmt.Errorf("Partial IV: require at most %d bytes, got %d", size, len(partialIV))
+		}
+		iv = make([]byte, size)
+		copy(iv[size-len(partialIV):], partialIV)
+		for i := range iv {
+			iv[i] ^= baseIV[i]
+		}
+		return iv, nil
+	}
+	if rand == nil {
+		return nil, errors.New("missing IV")
+	}
+	if h.RawUnprotected != nil {
+		return nil, errors.New("missing IV: unable to add IV to raw unprotected header")
+	}
+	iv = make([]byte, size)
+	if _, err := io.ReadFull(rand, iv); err != nil {
+		return nil, err
+	}
+	if h.Unprotected == nil {
+		h.Unprotected = make(UnprotectedHeader)
+	}
+	h.Unprotected[HeaderLabelIV] = iv
+	return iv, nil
+}
+
+// headerBytes gets a bstr value from the protected header, or from the
+// unprotected header if it is absent in the protected header.
+// It returns nil if the label is not present.
+func (h *Headers) headerBytes(label interface{}) ([]byte, error) {
+	value, ok := h.lookup(label)
+	if !ok {
+		return nil, nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil, errors.New("require bstr type")
+	}
+	return b, nil
+}
+
 // hasLabel returns true if h contains label.
 func hasLabel(h map[interface{}]interface{}, label interface{}) bool {
 	_, ok := h[label]
 	return ok
 }
 
+// isKnownHeaderLabel reports whether the header label is understood by this
+// library.
+// Only the header parameters processed by every message are understood.
+// Others, such as the countersignature, CWT Claims and X.509 header
+// parameters, are processed on request of the application, which lists them as
+// understood when it does so.
+func isKnownHeaderLabel(label interface{}) bool {
+	label, ok := normalizeLabel(label)
+	if !ok {
+		return false
+	}
+	switch label {
+	case HeaderLabelAlgorithm,
+		HeaderLabelCritical,
+		HeaderLabelContentType,
+		HeaderLabelKeyID,
+		HeaderLabelIV,
+		HeaderLabelPartialIV:
+		return true
+	}
+	return false
+}
+
+// hasNormalizedLabel reports whether h contains label, regardless of the
+// integer types of the labels.
+func hasNormalizedLabel(h map[interface{}]interface{}, label interface{}) bool {
+	if hasLabel(h, label) {
+		return true
+	}
+	for candidate := range h {
+		if sameLabel(candidate, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameLabel reports whether a and b are the same header label, regardless of
+// their integer types.
+func sameLabel(a, b interface{}) bool {
+	a, ok := normalizeLabel(a)
+	if !ok {
+		return false
+	}
+	b, ok = normalizeLabel(b)
+	if !ok {
+		return false
+	}
+	return a == b
+}
+
 // validateHeaderParameters validates all headers conform to the spec.
 func validateHeaderParameters(h map[interface{}]interface{}, protected bool) error {
 	existing := make(map[interface{}]struct{}, len(h))
@@ -434,6 +1195,10 @@ func validateHeaderParameters(h map[interface{}]interface{}, protected bool) err
 			if hasLabel(h, HeaderLabelIV) {
 				return errors.New("header parameter: IV and PartialIV: parameters must not both be present")
 			}
+		case HeaderLabelCWTClaims:
+			if _, err := toCWTClaims(value); err != nil {
+				return fmt.Errorf("header parameter: CWT claims: %w", err)
+			}
 		}
 	}
 	return nil
@@ -556,7 +1321,7 @@ func (discardedCBORMessage) UnmarshalCBOR(data []byte) error {
 //   label = int / tstr
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-1.4
-func validateHeaderLabelCBOR(data []byte) error {
+func validateHeaderLabelCBOR(d *decoder, data []byte) error {
 	var header map[headerLabelValidator]discardedCBORMessage
-	return decMode.Unmarshal(data, &header)
+	return d.mode.Unmarshal(data, &header)
 }
@@ -1,6 +1,9 @@
 package cose
 
 import (
+	"bytes"
+	"crypto/rand"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -32,6 +35,22 @@ func TestProtectedHeader_MarshalCBOR(t *testing.T) {
 				0x63, 0x66, 0x6f, 0x6f, 0x63, 0x62, 0x61, 0x72, // foo: bar
 			},
 		},
+		{
+			name: "CWT claims",
+			h: ProtectedHeader{
+				HeaderLabelCWTClaims: CWTClaims{
+					CWTClaimIssuer:  "iss",
+					CWTClaimSubject: "sub",
+				},
+			},
+			want: []byte{
+				0x4d,       // bstr
+				0xa1,       // map
+				0x0f, 0xa2, // CWT claims
+				0x01, 0x63, 0x69, 0x73, 0x73, // iss
+				0x02, 0x63, 0x73, 0x75, 0x62, // sub
+			},
+		},
 		{
 			name: "nil header",
 			h:    nil,
@@ -80,6 +99,24 @@ func TestProtectedHeader_MarshalCBOR(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown algorithm",
+			h: ProtectedHeader{
+				HeaderLabelAlgorithm: Algorithm(-65536),
+			},
+			want: []byte{
+				0x45, 0xa1, 0x01, 0x39, 0xff, 0xff,
+			},
+		},
+		{
+			name: "tstr algorithm",
+			h: ProtectedHeader{
+				HeaderLabelAlgorithm: "foo",
+			},
+			want: []byte{
+				0x46, 0xa1, 0x01, 0x63, 0x66, 0x6f, 0x6f,
+			},
+		},
 		{
 			name: "empty critical",
 			h: ProtectedHeader{
@@ -161,6 +198,32 @@ func TestProtectedHeader_MarshalCBOR(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid CWT claims",
+			h: ProtectedHeader{
+				HeaderLabelCWTClaims: "foo",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid CWT claim key",
+			h: ProtectedHeader{
+				HeaderLabelCWTClaims: CWTClaims{
+					1.5: "foo",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated CWT claim key",
+			h: ProtectedHeader{
+				HeaderLabelCWTClaims: map[interface{}]interface{}{
+					int(1):   "foo",
+					int64(1): "bar",
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -203,6 +266,17 @@ func TestProtectedHeader_UnmarshalCBOR(t *testing.T) {
 				"foo":                  "bar",
 			},
 		},
+		{
+			name: "unknown algorithm",
+			data: []byte{
+				0x45,                   // bstr
+				0xa1,                   // map
+				0x01, 0x39, 0xff, 0xff, // alg: -65536
+			},
+			want: ProtectedHeader{
+				HeaderLabelAlgorithm: Algorithm(-65536),
+			},
+		},
 		{
 			name: "empty header",
 			data: []byte{0x40},
@@ -213,6 +287,32 @@ func TestProtectedHeader_UnmarshalCBOR(t *testing.T) {
 			data: []byte{0x41, 0xa0},
 			want: ProtectedHeader{},
 		},
+		{
+			name: "CWT claims",
+			data: []byte{
+				0x4d,       // bstr
+				0xa1,       // map
+				0x0f, 0xa2, // CWT claims
+				0x01, 0x63, 0x69, 0x73, 0x73, // iss
+				0x02, 0x63, 0x73, 0x75, 0x62, // sub
+			},
+			want: ProtectedHeader{
+				HeaderLabelCWTClaims: CWTClaims{
+					CWTClaimIssuer:  "iss",
+					CWTClaimSubject: "sub",
+				},
+			},
+		},
+		{
+			name:    "invalid CWT claims",
+			data:    []byte{0x43, 0xa1, 0x0f, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "invalid CWT claim key",
+			data:    []byte{0x47, 0xa1, 0x0f, 0xa1, 0xf9, 0x3c, 0x00, 0x01},
+			wantErr: true,
+		},
 		{
 			name:    "nil CBOR data",
 			data:    nil,
@@ -494,6 +594,191 @@ func TestProtectedHeader_Critical(t *testing.T) {
 	}
 }
 
+func TestProtectedHeader_CheckCritical(t *testing.T) {
+	tests := []struct {
+		name       string
+		h          ProtectedHeader
+		understood []interface{}
+		wantLabels []interface{}
+		wantErr    bool
+	}{
+		{
+			name: "known labels",
+			h: ProtectedHeader{
+				HeaderLabelCritical:    []interface{}{HeaderLabelContentType, HeaderLabelKeyID},
+				HeaderLabelContentType: "text/plain",
+				HeaderLabelKeyID:       []byte("foo"),
+			},
+		},
+		{
+			name: "labels understood by application only",
+			h: ProtectedHeader{
+				HeaderLabelCritical:  []interface{}{HeaderLabelX5Chain, HeaderLabelCWTClaims},
+				HeaderLabelX5Chain:   []byte{0x01},
+				HeaderLabelCWTClaims: CWTClaims{CWTClaimIssuer: "foo"},
+			},
+			wantLabels: []interface{}{HeaderLabelX5Chain, HeaderLabelCWTClaims},
+		},
+		{
+			name: "labels understood by application",
+			h: ProtectedHeader{
+				HeaderLabelCritical:  []interface{}{HeaderLabelX5Chain, HeaderLabelCWTClaims},
+				HeaderLabelX5Chain:   []byte{0x01},
+				HeaderLabelCWTClaims: CWTClaims{CWTClaimIssuer: "foo"},
+			},
+			understood: []interface{}{HeaderLabelX5Chain, HeaderLabelCWTClaims},
+		},
+		{
+			name: "understood labels",
+			h: ProtectedHeader{
+				HeaderLabelCritical: []interface{}{int64(-65537), "foo"},
+				int64(-65537):       "bar",
+				"foo":               "bar",
+			},
+			understood: []interface{}{"foo", -65537},
+		},
+		{
+			name: "labels not understood",
+			h: ProtectedHeader{
+				HeaderLabelCritical:    []interface{}{int64(-65537), HeaderLabelContentType, "foo"},
+				int64(-65537):          "bar",
+				HeaderLabelContentType: "text/plain",
+				"foo":                  "bar",
+			},
+			understood: []interface{}{"baz"},
+			wantLabels: []interface{}{int64(-65537), "foo"},
+		},
+		{
+			name: "no critical header",
+			h: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+		},
+		{
+			name: "nil header",
+			h:    nil,
+		},
+		{
+			name: "invalid critical header",
+			h: ProtectedHeader{
+				HeaderLabelCritical: []interface{}{"foo"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.h.CheckCritical(tt.understood...)
+			if tt.wantLabels != nil {
+				var critErr *CriticalHeaderError
+				if !errors.As(err, &critErr) {
+					t.Fatalf("ProtectedHeader.CheckCritical() error = %v, want *CriticalHeaderError", err)
+				}
+				if !reflect.DeepEqual(critErr.Labels, tt.wantLabels) {
+					t.Errorf("CriticalHeaderError.Labels = %v, want %v", critErr.Labels, tt.wantLabels)
+				}
+				return
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProtectedHeader.CheckCritical() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProtectedHeader_CWTClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       ProtectedHeader
+		want    CWTClaims
+		wantErr bool
+	}{
+		{
+			name: "CWT claims",
+			h: ProtectedHeader{
+				HeaderLabelCWTClaims: CWTClaims{
+					CWTClaimIssuer: "iss",
+					"foo":          "bar",
+				},
+			},
+			want: CWTClaims{
+				CWTClaimIssuer: "iss",
+				"foo":          "bar",
+			},
+		},
+		{
+			name: "generic map",
+			h: ProtectedHeader{
+				HeaderLabelCWTClaims: map[interface{}]interface{}{
+					int(1):    "iss",
+					uint8(2):  "sub",
+					int16(-1): "foo",
+				},
+			},
+			want: CWTClaims{
+				CWTClaimIssuer:  "iss",
+				CWTClaimSubject: "sub",
+				int64(-1):       "foo",
+			},
+		},
+		{
+			name: "nil header",
+			h:    nil,
+			want: nil,
+		},
+		{
+			name: "no CWT claims",
+			h: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+			want: nil,
+		},
+		{
+			name: "invalid CWT claims",
+			h: ProtectedHeader{
+				HeaderLabelCWTClaims: []interface{}{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid CWT claim key",
+			h: ProtectedHeader{
+				HeaderLabelCWTClaims: CWTClaims{
+					true: "bar",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.h.CWTClaims()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProtectedHeader.CWTClaims() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProtectedHeader.CWTClaims() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtectedHeader_SetCWTClaims(t *testing.T) {
+	h := ProtectedHeader{}
+	claims := CWTClaims{
+		CWTClaimIssuer: "iss",
+	}
+	h.SetCWTClaims(claims)
+	got, err := h.CWTClaims()
+	if err != nil {
+		t.Fatalf("ProtectedHeader.CWTClaims() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, claims) {
+		t.Errorf("ProtectedHeader.CWTClaims() = %v, want %v", got, claims)
+	}
+}
+
 func TestUnprotectedHeader_MarshalCBOR(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -931,3 +1216,867 @@ func TestHeaders_UnmarshalFromRaw(t *testing.T) {
 		})
 	}
 }
+
+func TestHeaders_UnmarshalFromRawWithOptions(t *testing.T) {
+	rawProtected := []byte{0x46, 0xa2, 0x01, 0x26, 0x03, 0x18, 0x2a}
+	rawUnprotected := []byte{0xa2, 0x04, 0x42, 0x34, 0x32, 0x05, 0x40}
+	tests := []struct {
+		name    string
+		opts    DecOptions
+		wantErr bool
+	}{
+		{
+			name: "default limits",
+			opts: DecOptions{},
+		},
+		{
+			name: "within limits",
+			opts: DecOptions{
+				MaxMessageSize: 7,
+				MaxHeaders:     2,
+			},
+		},
+		{
+			name:    "header size exceeded",
+			opts:    DecOptions{MaxMessageSize: 6},
+			wantErr: true,
+		},
+		{
+			name:    "headers exceeded",
+			opts:    DecOptions{MaxHeaders: 1},
+			wantErr: true,
+		},
+		{
+			name:    "invalid options",
+			opts:    DecOptions{MaxMessageSize: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Headers{
+				RawProtected:   rawProtected,
+				RawUnprotected: rawUnprotected,
+			}
+			err := h.UnmarshalFromRawWithOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Headers.UnmarshalFromRawWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var protected ProtectedHeader
+			err = protected.UnmarshalCBORWithOptions(rawProtected, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProtectedHeader.UnmarshalCBORWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var unprotected UnprotectedHeader
+			err = unprotected.UnmarshalCBORWithOptions(rawUnprotected, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnprotectedHeader.UnmarshalCBORWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHeaders_contentIV(t *testing.T) {
+	baseIV := []byte{0x89, 0xf5, 0x2f, 0x65, 0xa1, 0xc5, 0x80, 0x93, 0x3b, 0x52, 0x61, 0xa7}
+	tests := []struct {
+		name    string
+		h       Headers
+		baseIV  []byte
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "IV in protected header",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelIV: baseIV,
+				},
+			},
+			want: baseIV,
+		},
+		{
+			name: "IV in unprotected header",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelIV: baseIV,
+				},
+			},
+			want: baseIV,
+		},
+		{
+			name: "IV with invalid length",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelIV: baseIV[:8],
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "IV with invalid type",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelIV: "foo",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Partial IV",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelPartialIV: []byte{0x61, 0xa7},
+				},
+			},
+			baseIV: baseIV,
+			want:   []byte{0x89, 0xf5, 0x2f, 0x65, 0xa1, 0xc5, 0x80, 0x93, 0x3b, 0x52, 0x00, 0x00},
+		},
+		{
+			name: "Partial IV without Base IV",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelPartialIV: []byte{0x61, 0xa7},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Partial IV too long",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelPartialIV: make([]byte, 13),
+				},
+			},
+			baseIV:  baseIV,
+			wantErr: true,
+		},
+		{
+			name: "IV and Partial IV",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelIV: baseIV,
+				},
+				Unprotected: UnprotectedHeader{
+					HeaderLabelPartialIV: []byte{0x61, 0xa7},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.h.contentIV(nil, 12, tt.baseIV)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Headers.contentIV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Headers.contentIV() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+
+	// generate random IV
+	t.Run("random IV", func(t *testing.T) {
+		var h Headers
+		got, err := h.contentIV(rand.Reader, 12, nil)
+		if err != nil {
+			t.Fatalf("Headers.contentIV() error = %v", err)
+		}
+		if len(got) != 12 {
+			t.Fatalf("Headers.contentIV() = %x, want 12 bytes", got)
+		}
+		if iv := h.Unprotected[HeaderLabelIV]; !reflect.DeepEqual(iv, got) {
+			t.Errorf("Headers.Unprotected[HeaderLabelIV] = %v, want %v", iv, got)
+		}
+	})
+}
+
+func TestHeaders_KeyID(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       Headers
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "protected",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelKeyID: []byte("foo"),
+				},
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("bar"),
+				},
+			},
+			want: []byte("foo"),
+		},
+		{
+			name: "unprotected",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("bar"),
+				},
+			},
+			want: []byte("bar"),
+		},
+		{
+			name: "absent",
+			h:    Headers{},
+			want: nil,
+		},
+		{
+			name: "invalid",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelKeyID: "foo",
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.h.KeyID()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Headers.KeyID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Headers.KeyID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders_SetKeyID(t *testing.T) {
+	h := Headers{
+		Unprotected: UnprotectedHeader{
+			HeaderLabelKeyID: []byte("bar"),
+		},
+	}
+	h.SetKeyID([]byte("foo"))
+	if hasLabel(h.Unprotected, HeaderLabelKeyID) {
+		t.Error("Headers.SetKeyID() did not remove the unprotected kid")
+	}
+	got, err := h.KeyID()
+	if err != nil {
+		t.Fatalf("Headers.KeyID() error = %v", err)
+	}
+	if want := []byte("foo"); !bytes.Equal(got, want) {
+		t.Errorf("Headers.KeyID() = %v, want %v", got, want)
+	}
+}
+
+func TestHeaders_ContentType(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       Headers
+		want    interface{}
+		wantErr error
+	}{
+		{
+			name: "media type",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelContentType: "text/plain",
+				},
+			},
+			want: "text/plain",
+		},
+		{
+			name: "content format",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelContentType: int64(60),
+				},
+			},
+			want: uint64(60),
+		},
+		{
+			name: "content format of type uint16",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelContentType: uint16(60),
+				},
+			},
+			want: uint64(60),
+		},
+		{
+			name: "absent",
+			h:    Headers{},
+			want: nil,
+		},
+		{
+			name: "negative integer",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelContentType: -1,
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "invalid",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelContentType: []byte("text/plain"),
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.h.ContentType()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Headers.ContentType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Headers.ContentType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders_SetContentType(t *testing.T) {
+	h := Headers{
+		Unprotected: UnprotectedHeader{
+			HeaderLabelContentType: "text/plain",
+		},
+	}
+	if err := h.SetContentType(uint16(60)); err != nil {
+		t.Fatalf("Headers.SetContentType() error = %v", err)
+	}
+	if hasLabel(h.Unprotected, HeaderLabelContentType) {
+		t.Error("Headers.SetContentType() did not remove the unprotected content type")
+	}
+	got, err := h.ContentType()
+	if err != nil {
+		t.Fatalf("Headers.ContentType() error = %v", err)
+	}
+	if want := uint64(60); got != want {
+		t.Errorf("Headers.ContentType() = %v, want %v", got, want)
+	}
+
+	if err := h.SetContentType(-1); !errors.Is(err, ErrInvalidHeaderValue) {
+		t.Errorf("Headers.SetContentType() error = %v, wantErr %v", err, ErrInvalidHeaderValue)
+	}
+}
+
+func TestHeaders_IV(t *testing.T) {
+	var h Headers
+	h.SetIV([]byte("iv"))
+	h.SetPartialIV([]byte("partial iv"))
+	got, err := h.IV()
+	if err != nil {
+		t.Fatalf("Headers.IV() error = %v", err)
+	}
+	if want := []byte("iv"); !bytes.Equal(got, want) {
+		t.Errorf("Headers.IV() = %v, want %v", got, want)
+	}
+	got, err = h.PartialIV()
+	if err != nil {
+		t.Fatalf("Headers.PartialIV() error = %v", err)
+	}
+	if want := []byte("partial iv"); !bytes.Equal(got, want) {
+		t.Errorf("Headers.PartialIV() = %v, want %v", got, want)
+	}
+
+	h.Protected = ProtectedHeader{
+		HeaderLabelIV:        "iv",
+		HeaderLabelPartialIV: 42,
+	}
+	if _, err := h.IV(); !errors.Is(err, ErrInvalidHeaderValue) {
+		t.Errorf("Headers.IV() error = %v, wantErr %v", err, ErrInvalidHeaderValue)
+	}
+	if _, err := h.PartialIV(); !errors.Is(err, ErrInvalidHeaderValue) {
+		t.Errorf("Headers.PartialIV() error = %v, wantErr %v", err, ErrInvalidHeaderValue)
+	}
+}
+
+func TestHeaders_X5Chain(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       Headers
+		want    [][]byte
+		wantErr error
+	}{
+		{
+			name: "single certificate",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelX5Chain: []byte("leaf"),
+				},
+			},
+			want: [][]byte{[]byte("leaf")},
+		},
+		{
+			name: "certificate chain",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelX5Chain: []interface{}{
+						[]byte("leaf"),
+						[]byte("root"),
+					},
+				},
+			},
+			want: [][]byte{[]byte("leaf"), []byte("root")},
+		},
+		{
+			name: "absent",
+			h:    Headers{},
+			want: nil,
+		},
+		{
+			name: "empty chain",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelX5Chain: []interface{}{},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "invalid certificate",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelX5Chain: []interface{}{
+						[]byte("leaf"),
+						"root",
+					},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "invalid",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelX5Chain: "leaf",
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.h.X5Chain()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Headers.X5Chain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Headers.X5Chain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders_SetX5Chain(t *testing.T) {
+	tests := []struct {
+		name  string
+		certs [][]byte
+		want  []byte
+	}{
+		{
+			name:  "single certificate",
+			certs: [][]byte{{0x01}},
+			want: []byte{
+				0x45,             // bstr
+				0xa1,             // map
+				0x18, 0x21, 0x41, // x5chain
+				0x01,
+			},
+		},
+		{
+			name:  "certificate chain",
+			certs: [][]byte{{0x01}, {0x02}},
+			want: []byte{
+				0x48,                   // bstr
+				0xa1,                   // map
+				0x18, 0x21, 0x82, 0x41, // x5chain
+				0x01, 0x41, 0x02,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h Headers
+			h.SetX5Chain(tt.certs)
+			encoded, err := h.MarshalProtected()
+			if err != nil {
+				t.Fatalf("Headers.MarshalProtected() error = %v", err)
+			}
+			if !bytes.Equal(encoded, tt.want) {
+				t.Errorf("Headers.MarshalProtected() = %x, want %x", encoded, tt.want)
+			}
+			var decoded Headers
+			decoded.RawProtected = encoded
+			decoded.RawUnprotected = []byte{0xa0}
+			if err := decoded.UnmarshalFromRaw(); err != nil {
+				t.Fatalf("Headers.UnmarshalFromRaw() error = %v", err)
+			}
+			got, err := decoded.X5Chain()
+			if err != nil {
+				t.Fatalf("Headers.X5Chain() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.certs) {
+				t.Errorf("Headers.X5Chain() = %v, want %v", got, tt.certs)
+			}
+		})
+	}
+}
+
+func TestHeaders_X5T(t *testing.T) {
+	tests := []struct {
+		name     string
+		h        Headers
+		wantAlg  Algorithm
+		wantHash []byte
+		wantErr  error
+	}{
+		{
+			name: "thumbprint",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelX5T: []interface{}{int64(-16), []byte("hash")},
+				},
+			},
+			wantAlg:  -16,
+			wantHash: []byte("hash"),
+		},
+		{
+			name: "absent",
+			h:    Headers{},
+		},
+		{
+			name: "invalid",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelX5T: []byte("hash"),
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "invalid hash algorithm",
+			h: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelX5T: []interface{}{"SHA-256", []byte("hash")},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "invalid hash value",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelX5T: []interface{}{int64(-16), "hash"},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alg, hash, err := tt.h.X5T()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Headers.X5T() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if alg != tt.wantAlg || !bytes.Equal(hash, tt.wantHash) {
+				t.Errorf("Headers.X5T() = %v, %v, want %v, %v", alg, hash, tt.wantAlg, tt.wantHash)
+			}
+		})
+	}
+
+	var h Headers
+	h.SetX5T(-16, []byte("hash"))
+	alg, hash, err := h.X5T()
+	if err != nil {
+		t.Fatalf("Headers.X5T() error = %v", err)
+	}
+	if alg != -16 || !bytes.Equal(hash, []byte("hash")) {
+		t.Errorf("Headers.X5T() = %v, %v, want %v, %v", alg, hash, -16, []byte("hash"))
+	}
+}
+
+func TestHeaders_X5U(t *testing.T) {
+	var h Headers
+	got, err := h.X5U()
+	if err != nil || got != "" {
+		t.Errorf("Headers.X5U() = %v, %v, want empty", got, err)
+	}
+
+	h.Unprotected = UnprotectedHeader{
+		HeaderLabelX5U: "https://example.com/old",
+	}
+	h.SetX5U("https://example.com/certs")
+	if hasLabel(h.Unprotected, HeaderLabelX5U) {
+		t.Error("Headers.SetX5U() did not remove the unprotected x5u")
+	}
+	got, err = h.X5U()
+	if err != nil {
+		t.Fatalf("Headers.X5U() error = %v", err)
+	}
+	if want := "https://example.com/certs"; got != want {
+		t.Errorf("Headers.X5U() = %v, want %v", got, want)
+	}
+
+	h.Protected[HeaderLabelX5U] = 42
+	if _, err := h.X5U(); !errors.Is(err, ErrInvalidHeaderValue) {
+		t.Errorf("Headers.X5U() error = %v, wantErr %v", err, ErrInvalidHeaderValue)
+	}
+}
+
+func TestHeaders_CounterSignature(t *testing.T) {
+	sig := []interface{}{
+		[]byte{0xa1, 0x01, 0x26},
+		map[interface{}]interface{}{},
+		[]byte{0x01, 0x02},
+	}
+	tests := []struct {
+		name    string
+		h       Headers
+		want    [][]byte
+		wantErr error
+	}{
+		{
+			name: "single counter signature",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignature: sig,
+				},
+			},
+			want: [][]byte{{0x01, 0x02}},
+		},
+		{
+			name: "multiple counter signatures",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignature: []interface{}{sig, sig},
+				},
+			},
+			want: [][]byte{{0x01, 0x02}, {0x01, 0x02}},
+		},
+		{
+			name: "absent",
+			h:    Headers{},
+		},
+		{
+			name: "empty array",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignature: []interface{}{},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "invalid",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignature: []byte{0x01, 0x02},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "invalid counter signature",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignature: []interface{}{[]byte{0x01}},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sigs, err := tt.h.CounterSignature()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Headers.CounterSignature() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var got [][]byte
+			for _, sig := range sigs {
+				if alg, err := sig.Headers.Protected.Algorithm(); err != nil || alg != AlgorithmES256 {
+					t.Errorf("Signature.Headers.Protected.Algorithm() = %v, %v, want %v", alg, err, AlgorithmES256)
+				}
+				got = append(got, sig.Signature)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Headers.CounterSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders_SetCounterSignature(t *testing.T) {
+	sig := &Signature{
+		Headers: Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+		},
+		Signature: []byte{0x01, 0x02},
+	}
+	for _, sigs := range [][]*Signature{{sig}, {sig, sig}} {
+		var h Headers
+		h.SetCounterSignature(sigs...)
+		encoded, err := h.MarshalUnprotected()
+		if err != nil {
+			t.Fatalf("Headers.MarshalUnprotected() error = %v", err)
+		}
+		var decoded Headers
+		decoded.RawProtected = []byte{0x40}
+		decoded.RawUnprotected = encoded
+		if err := decoded.UnmarshalFromRaw(); err != nil {
+			t.Fatalf("Headers.UnmarshalFromRaw() error = %v", err)
+		}
+		got, err := decoded.CounterSignature()
+		if err != nil {
+			t.Fatalf("Headers.CounterSignature() error = %v", err)
+		}
+		if len(got) != len(sigs) {
+			t.Fatalf("Headers.CounterSignature() = %d signatures, want %d", len(got), len(sigs))
+		}
+		for _, s := range got {
+			if !bytes.Equal(s.Signature, sig.Signature) {
+				t.Errorf("Signature.Signature = %v, want %v", s.Signature, sig.Signature)
+			}
+		}
+	}
+}
+
+func TestHeaders_CountersignaturesV2(t *testing.T) {
+	sig := []interface{}{
+		[]byte{0xa1, 0x01, 0x26},
+		map[interface{}]interface{}{},
+		[]byte{0x01, 0x02},
+	}
+	tests := []struct {
+		name    string
+		h       Headers
+		want    [][]byte
+		wantErr error
+	}{
+		{
+			name: "single countersignature",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignatureV2: sig,
+				},
+			},
+			want: [][]byte{{0x01, 0x02}},
+		},
+		{
+			name: "multiple countersignatures",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignatureV2: []interface{}{sig, sig},
+				},
+			},
+			want: [][]byte{{0x01, 0x02}, {0x01, 0x02}},
+		},
+		{
+			name: "absent",
+			h:    Headers{},
+		},
+		{
+			name: "empty array",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignatureV2: []interface{}{},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "invalid countersignature",
+			h: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelCounterSignatureV2: []interface{}{[]byte{0x01}},
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sigs, err := tt.h.CountersignaturesV2()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Headers.CountersignaturesV2() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var got [][]byte
+			for _, sig := range sigs {
+				if alg, err := sig.Headers.Protected.Algorithm(); err != nil || alg != AlgorithmES256 {
+					t.Errorf("CountersignatureV2.Headers.Protected.Algorithm() = %v, %v, want %v", alg, err, AlgorithmES256)
+				}
+				got = append(got, sig.Signature)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Headers.CountersignaturesV2() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders_SetCountersignaturesV2(t *testing.T) {
+	sig := &CountersignatureV2{
+		Headers: Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+		},
+		Signature: []byte{0x01, 0x02},
+	}
+	for _, sigs := range [][]*CountersignatureV2{{sig}, {sig, sig}} {
+		var h Headers
+		h.SetCountersignaturesV2(sigs...)
+		encoded, err := h.MarshalUnprotected()
+		if err != nil {
+			t.Fatalf("Headers.MarshalUnprotected() error = %v", err)
+		}
+		var decoded Headers
+		decoded.RawProtected = []byte{0x40}
+		decoded.RawUnprotected = encoded
+		if err := decoded.UnmarshalFromRaw(); err != nil {
+			t.Fatalf("Headers.UnmarshalFromRaw() error = %v", err)
+		}
+		got, err := decoded.CountersignaturesV2()
+		if err != nil {
+			t.Fatalf("Headers.CountersignaturesV2() error = %v", err)
+		}
+		if len(got) != len(sigs) {
+			t.Fatalf("Headers.CountersignaturesV2() = %d countersignatures, want %d", len(got), len(sigs))
+		}
+		for _, s := range got {
+			if !bytes.Equal(s.Signature, sig.Signature) {
+				t.Errorf("CountersignatureV2.Signature = %v, want %v", s.Signature, sig.Signature)
+			}
+		}
+	}
+}
+
+func TestHeaders_Countersignature0V2(t *testing.T) {
+	var h Headers
+	if got, err := h.Countersignature0V2(); err != nil || got != nil {
+		t.Errorf("Headers.Countersignature0V2() = %v, %v, want nil", got, err)
+	}
+	h.SetCountersignature0V2([]byte{0x01, 0x02})
+	if got, err := h.Countersignature0V2(); err != nil || !bytes.Equal(got, []byte{0x01, 0x02}) {
+		t.Errorf("Headers.Countersignature0V2() = %v, %v, want %v", got, err, []byte{0x01, 0x02})
+	}
+
+	// invalid type
+	h.Unprotected[HeaderLabelCounterSignature0V2] = "foo"
+	if _, err := h.Countersignature0V2(); !errors.Is(err, ErrInvalidHeaderValue) {
+		t.Errorf("Headers.Countersignature0V2() error = %v, wantErr %v", err, ErrInvalidHeaderValue)
+	}
+}
@@ -0,0 +1,908 @@
+package cose
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// COSE Key labels registered in the IANA "COSE Key Common Parameters"
+// registry.
+//
+// Reference: https://www.iana.org/assignments/cose/cose.xhtml#key-common-parameters
+const (
+	KeyLabelKeyType   int64 = 1
+	KeyLabelKeyID     int64 = 2
+	KeyLabelAlgorithm int64 = 3
+	KeyLabelKeyOps    int64 = 4
+	KeyLabelBaseIV    int64 = 5
+)
+
+// COSE Key labels registered in the IANA "COSE Key Type Parameters" registry.
+//
+// Reference: https://www.iana.org/assignments/cose/cose.xhtml#key-type-parameters
+const (
+	KeyLabelOKPCurve int64 = -1
+	KeyLabelOKPX     int64 = -2
+	KeyLabelOKPD     int64 = -4
+
+	KeyLabelEC2Curve int64 = -1
+	KeyLabelEC2X     int64 = -2
+	KeyLabelEC2Y     int64 = -3
+	KeyLabelEC2D     int64 = -4
+
+	KeyLabelRSAN    int64 = -1
+	KeyLabelRSAE    int64 = -2
+	KeyLabelRSAD    int64 = -3
+	KeyLabelRSAP    int64 = -4
+	KeyLabelRSAQ    int64 = -5
+	KeyLabelRSADP   int64 = -6
+	KeyLabelRSADQ   int64 = -7
+	KeyLabelRSAQInv int64 = -8
+
+	KeyLabelSymmetricK int64 = -1
+)
+
+// KeyType represents an IANA key type entry in the COSE Key Types registry.
+//
+// Reference: https://www.iana.org/assignments/cose/cose.xhtml#key-type
+type KeyType int64
+
+// Key types supported by this library.
+const (
+	KeyTypeOKP       KeyType = 1
+	KeyTypeEC2       KeyType = 2
+	KeyTypeRSA       KeyType = 3
+	KeyTypeSymmetric KeyType = 4
+)
+
+// String returns the name of the key type.
+func (kt KeyType) String() string {
+	switch kt {
+	case KeyTypeOKP:
+		return "OKP"
+	case KeyTypeEC2:
+		return "EC2"
+	case KeyTypeRSA:
+		return "RSA"
+	case KeyTypeSymmetric:
+		return "Symmetric"
+	}
+	return "unknown key type value " + strconv.Itoa(int(kt))
+}
+
+// Curve represents an IANA curve entry in the COSE Elliptic Curves registry.
+//
+// Reference: https://www.iana.org/assignments/cose/cose.xhtml#elliptic-curves
+type Curve int64
+
+// Elliptic curves registered in the IANA "COSE Elliptic Curves" registry.
+const (
+	CurveP256    Curve = 1
+	CurveP384    Curve = 2
+	CurveP521    Curve = 3
+	CurveX25519  Curve = 4
+	CurveX448    Curve = 5
+	CurveEd25519 Curve = 6
+	CurveEd448   Curve = 7
+)
+
+// String returns the name of the curve.
+func (c Curve) String() string {
+	switch c {
+	case CurveP256:
+		return "P-256"
+	case CurveP384:
+		return "P-384"
+	case CurveP521:
+		return "P-521"
+	case CurveX25519:
+		return "X25519"
+	case CurveX448:
+		return "X448"
+	case CurveEd25519:
+		return "Ed25519"
+	case CurveEd448:
+		return "Ed448"
+	}
+	return "unknown curve value " + strconv.Itoa(int(c))
+}
+
+// KeyOp represents a key operation value of the key_ops parameter.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-7.1
+type KeyOp int64
+
+// Key operations defined by RFC 9052.
+const (
+	KeyOpSign       KeyOp = 1
+	KeyOpVerify     KeyOp = 2
+	KeyOpEncrypt    KeyOp = 3
+	KeyOpDecrypt    KeyOp = 4
+	KeyOpWrapKey    KeyOp = 5
+	KeyOpUnwrapKey  KeyOp = 6
+	KeyOpDeriveKey  KeyOp = 7
+	KeyOpDeriveBits KeyOp = 8
+	KeyOpMACCreate  KeyOp = 9
+	KeyOpMACVerify  KeyOp = 10
+)
+
+// Key represents a COSE_Key structure:
+//
+//	COSE_Key = {
+//	    1 => tstr / int,          ; kty
+//	    ? 2 => bstr,              ; kid
+//	    ? 3 => tstr / int,        ; alg
+//	    ? 4 => [+ (tstr / int) ], ; key_ops
+//	    ? 5 => bstr,              ; Base IV
+//	    * label => values
+//	}
+//
+// The key type specific parameters are stored in Params, keyed by their
+// labels such as KeyLabelEC2X.
+// Key types and key operations with string values are not supported.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-7
+type Key struct {
+	Type      KeyType
+	ID        []byte
+	Algorithm Algorithm
+	Ops       []KeyOp
+	BaseIV    []byte
+	Params    map[interface{}]interface{}
+}
+
+// NewKeySymmetric returns a Key of the Symmetric key type with the key value k.
+func NewKeySymmetric(k []byte) *Key {
+	return &Key{
+		Type: KeyTypeSymmetric,
+		Params: map[interface{}]interface{}{
+			KeyLabelSymmetricK: k,
+		},
+	}
+}
+
+// NewKeyFromPublic returns a Key from a public key.
+//
+// The supported key types are *ecdsa.PublicKey, ed25519.PublicKey,
+// *rsa.PublicKey, and X25519PublicKey.
+func NewKeyFromPublic(pub crypto.PublicKey) (*Key, error) {
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		crv, err := curveFromElliptic(pub.Curve)
+		if err != nil {
+			return nil, err
+		}
+		size := curveSize(pub.Curve)
+		return newKeyEC2(crv, pub.X.FillBytes(make([]byte, size)), pub.Y.FillBytes(make([]byte, size)), nil), nil
+	case ed25519.PublicKey:
+		return newKeyOKP(CurveEd25519, pub, nil), nil
+	case *rsa.PublicKey:
+		return &Key{
+			Type: KeyTypeRSA,
+			Params: map[interface{}]interface{}{
+				KeyLabelRSAN: pub.N.Bytes(),
+				KeyLabelRSAE: big.NewInt(int64(pub.E)).Bytes(),
+			},
+		}, nil
+	case X25519PublicKey:
+		if len(pub) != curve25519.PointSize {
+			return nil, errors.New("invalid X25519 public key")
+		}
+		return newKeyOKP(CurveX25519, pub, nil), nil
+	}
+	return nil, errors.New("unsupported public key type")
+}
+
+// NewKeyFromPrivate returns a Key from a private key.
+//
+// The supported key types are *ecdsa.PrivateKey, ed25519.PrivateKey,
+// *rsa.PrivateKey, and X25519PrivateKey.
+func NewKeyFromPrivate(priv crypto.PrivateKey) (*Key, error) {
+	switch priv := priv.(type) {
+	case *ecdsa.PrivateKey:
+		key, err := NewKeyFromPublic(&priv.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		key.Params[KeyLabelEC2D] = priv.D.FillBytes(make([]byte, curveSize(priv.Curve)))
+		return key, nil
+	case ed25519.PrivateKey:
+		if len(priv) != ed25519.PrivateKeySize {
+			return nil, errors.New("invalid Ed25519 private key")
+		}
+		return newKeyOKP(CurveEd25519, priv.Public().(ed25519.PublicKey), priv.Seed()), nil
+	case *rsa.PrivateKey:
+		if len(priv.Primes) != 2 {
+			return nil, errors.New("RSA: multi-prime keys are not supported")
+		}
+		key, err := NewKeyFromPublic(&priv.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		key.Params[KeyLabelRSAD] = priv.D.Bytes()
+		key.Params[KeyLabelRSAP] = priv.Primes[0].Bytes()
+		key.Params[KeyLabelRSAQ] = priv.Primes[1].Bytes()
+		if priv.Precomputed.Dp != nil {
+			key.Params[KeyLabelRSADP] = priv.Precomputed.Dp.Bytes()
+			key.Params[KeyLabelRSADQ] = priv.Precomputed.Dq.Bytes()
+			key.Params[KeyLabelRSAQInv] = priv.Precomputed.Qinv.Bytes()
+		}
+		return key, nil
+	case X25519PrivateKey:
+		if len(priv) != curve25519.ScalarSize {
+			return nil, errors.New("invalid X25519 private key")
+		}
+		return newKeyOKP(CurveX25519, priv.Public().(X25519PublicKey), priv), nil
+	}
+	return nil, errors.New("unsupported private key type")
+}
+
+// newKeyOKP returns a Key of the OKP key type.
+func newKeyOKP(crv Curve, x, d []byte) *Key {
+	key := &Key{
+		Type: KeyTypeOKP,
+		Params: map[interface{}]interface{}{
+			KeyLabelOKPCurve: int64(crv),
+			KeyLabelOKPX:     x,
+		},
+	}
+	if d != nil {
+		key.Params[KeyLabelOKPD] = d
+	}
+	return key
+}
+
+// newKeyEC2 returns a Key of the EC2 key type.
+func newKeyEC2(crv Curve, x, y, d []byte) *Key {
+	key := &Key{
+		Type: KeyTypeEC2,
+		Params: map[interface{}]interface{}{
+			KeyLabelEC2Curve: int64(crv),
+			KeyLabelEC2X:     x,
+			KeyLabelEC2Y:     y,
+		},
+	}
+	if d != nil {
+		key.Params[KeyLabelEC2D] = d
+	}
+	return key
+}
+
+// MarshalCBOR encodes Key into a COSE_Key object.
+func (k *Key) MarshalCBOR() ([]byte, error) {
+	if k == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil Key pointer")
+	}
+	m, err := k.toMap()
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(m)
+}
+
+// UnmarshalCBOR decodes a COSE_Key object into Key.
+func (k *Key) UnmarshalCBOR(data []byte) error {
+	if k == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil Key pointer")
+	}
+	var m map[interface{}]interface{}
+	if err := decMode.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	var key Key
+	if err := key.fromMap(m); err != nil {
+		return err
+	}
+	*k = key
+	return nil
+}
+
+// toMap returns the COSE_Key map of the key.
+func (k *Key) toMap() (map[interface{}]interface{}, error) {
+	if k.Type == 0 {
+		return nil, errors.New("COSE_Key: missing key type")
+	}
+	m := make(map[interface{}]interface{}, len(k.Params)+5)
+	for label, value := range k.Params {
+		label, ok := normalizeLabel(label)
+		if !ok {
+			return nil, errors.New("COSE_Key: require int / tstr label")
+		}
+		switch label {
+		case KeyLabelKeyType, KeyLabelKeyID, KeyLabelAlgorithm, KeyLabelKeyOps, KeyLabelBaseIV:
+			return nil, fmt.Errorf("COSE_Key: common parameter %v in key type parameters", label)
+		}
+		if _, ok := m[label]; ok {
+			return nil, fmt.Errorf("COSE_Key: duplicated label: %v", label)
+		}
+		m[label] = value
+	}
+	m[KeyLabelKeyType] = int64(k.Type)
+	if k.ID != nil {
+		m[KeyLabelKeyID] = k.ID
+	}
+	if k.Algorithm != 0 {
+		m[KeyLabelAlgorithm] = int64(k.Algorithm)
+	}
+	if len(k.Ops) > 0 {
+		ops := make([]int64, len(k.Ops))
+		for i, op := range k.Ops {
+			ops[i] = int64(op)
+		}
+		m[KeyLabelKeyOps] = ops
+	}
+	if k.BaseIV != nil {
+		m[KeyLabelBaseIV] = k.BaseIV
+	}
+	return m, nil
+}
+
+// fromMap parses a COSE_Key map into the key.
+func (k *Key) fromMap(m map[interface{}]interface{}) error {
+	key := Key{
+		Params: make(map[interface{}]interface{}),
+	}
+	for label, value := range m {
+		label, ok := normalizeLabel(label)
+		if !ok {
+			return errors.New("COSE_Key: require int / tstr label")
+		}
+		if _, ok := key.Params[label]; ok {
+			return fmt.Errorf("COSE_Key: duplicated label: %v", label)
+		}
+		switch label {
+		case KeyLabelKeyType:
+			kty, ok := intValue(value)
+			if !ok || kty == 0 {
+				return errors.New("COSE_Key: kty: require non-zero int type")
+			}
+			key.Type = KeyType(kty)
+		case KeyLabelKeyID:
+			if key.ID, ok = value.([]byte); !ok {
+				return errors.New("COSE_Key: kid: require bstr type")
+			}
+		case KeyLabelAlgorithm:
+			alg, ok := intValue(value)
+			if !ok {
+				return errors.New("COSE_Key: alg: require int type")
+			}
+			key.Algorithm = Algorithm(alg)
+		case KeyLabelKeyOps:
+			ops, err := keyOpsValue(value)
+			if err != nil {
+				return err
+			}
+			key.Ops = ops
+		case KeyLabelBaseIV:
+			if key.BaseIV, ok = value.([]byte); !ok {
+				return errors.New("COSE_Key: Base IV: require bstr type")
+			}
+		default:
+			key.Params[label] = value
+		}
+	}
+	if key.Type == 0 {
+		return errors.New("COSE_Key: missing key type")
+	}
+	*k = key
+	return nil
+}
+
+// keyOpsValue parses the key_ops parameter.
+func keyOpsValue(value interface{}) ([]KeyOp, error) {
+	var values []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		values = v
+	case []KeyOp:
+		return v, nil
+	default:
+		return nil, errors.New("COSE_Key: key_ops: require array type")
+	}
+	if len(values) == 0 {
+		return nil, errors.New("COSE_Key: key_ops: require non-empty array")
+	}
+	ops := make([]KeyOp, len(values))
+	for i, v := range values {
+		op, ok := intValue(v)
+		if !ok {
+			return nil, errors.New("COSE_Key: key_ops: require int type")
+		}
+		ops[i] = KeyOp(op)
+	}
+	return ops, nil
+}
+
+// intValue converts v to int64 if v is an integer.
+func intValue(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case Algorithm:
+		return int64(v), true
+	case KeyType:
+		return int64(v), true
+	case Curve:
+		return int64(v), true
+	case KeyOp:
+		return int64(v), true
+	}
+	if !canInt(v) {
+		return 0, false
+	}
+	n, _ := normalizeLabel(v)
+	return n.(int64), true
+}
+
+// hasOp reports whether the key allows the operation op.
+// A key without key_ops allows all operations.
+func (k *Key) hasOp(op KeyOp) bool {
+	if len(k.Ops) == 0 {
+		return true
+	}
+	for _, o := range k.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// paramBytes returns the bstr value of the key type parameter label.
+// It returns nil if the parameter is not present.
+func (k *Key) paramBytes(label int64) ([]byte, error) {
+	value, ok := k.Params[label]
+	if !ok {
+		return nil, nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%v key: parameter %d: require bstr type", k.Type, label)
+	}
+	return b, nil
+}
+
+// curve returns the curve of an OKP or EC2 key.
+func (k *Key) curve() (Curve, error) {
+	crv, ok := intValue(k.Params[KeyLabelEC2Curve]) // same label for OKP
+	if !ok {
+		return 0, fmt.Errorf("%v key: crv: require int type", k.Type)
+	}
+	return Curve(crv), nil
+}
+
+// PublicKey returns the public key of the key.
+//
+// The returned key type is *ecdsa.PublicKey for EC2 keys, ed25519.PublicKey
+// for OKP keys on Ed25519, X25519PublicKey for OKP keys on X25519, and
+// *rsa.PublicKey for RSA keys.
+// The public key is derived from the private key if the public key parameters
+// are absent.
+func (k *Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.Type {
+	case KeyTypeEC2:
+		pub, _, err := k.ec2()
+		return pub, err
+	case KeyTypeOKP:
+		pub, _, err := k.okp()
+		return pub, err
+	case KeyTypeRSA:
+		return k.rsaPublicKey()
+	}
+	return nil, fmt.Errorf("%v key: unsupported key type", k.Type)
+}
+
+// PrivateKey returns the private key of the key.
+//
+// The returned key type is *ecdsa.PrivateKey for EC2 keys,
+// ed25519.PrivateKey for OKP keys on Ed25519, X25519PrivateKey for OKP keys
+// on X25519, and *rsa.PrivateKey for RSA keys.
+func (k *Key) PrivateKey() (crypto.PrivateKey, error) {
+	switch k.Type {
+	case KeyTypeEC2:
+		_, priv, err := k.ec2()
+		if err == nil && priv == nil {
+			return nil, fmt.Errorf("%v key: missing private key", k.Type)
+		}
+		return priv, err
+	case KeyTypeOKP:
+		_, priv, err := k.okp()
+		if err == nil && priv == nil {
+			return nil, fmt.Errorf("%v key: missing private key", k.Type)
+		}
+		return priv, err
+	case KeyTypeRSA:
+		return k.rsaPrivateKey()
+	}
+	return nil, fmt.Errorf("%v key: unsupported key type", k.Type)
+}
+
+// SymmetricKey returns the key value of a Symmetric key.
+func (k *Key) SymmetricKey() ([]byte, error) {
+	if k.Type != KeyTypeSymmetric {
+		return nil, fmt.Errorf("%v key: not a symmetric key", k.Type)
+	}
+	key, err := k.paramBytes(KeyLabelSymmetricK)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%v key: missing key value", k.Type)
+	}
+	return key, nil
+}
+
+// Signer returns a Signer for the private key.
+// The signing algorithm is the `alg` parameter of the key if present.
+// Otherwise, it is derived from the curve for EC2 and OKP keys.
+func (k *Key) Signer() (Signer, error) {
+	if !k.hasOp(KeyOpSign) {
+		return nil, fmt.Errorf("%v key: sign operation not allowed", k.Type)
+	}
+	alg, err := k.signatureAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	priv, err := k.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%v: %w", alg, ErrAlgorithmMismatch)
+	}
+	return NewSigner(alg, signer)
+}
+
+// Verifier returns a Verifier for the public key.
+// The signing algorithm is the `alg` parameter of the key if present.
+// Otherwise, it is derived from the curve for EC2 and OKP keys.
+func (k *Key) Verifier() (Verifier, error) {
+	if !k.hasOp(KeyOpVerify) {
+		return nil, fmt.Errorf("%v key: verify operation not allowed", k.Type)
+	}
+	alg, err := k.signatureAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := k.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(alg, pub)
+}
+
+// signatureAlgorithm returns the signing algorithm of the key.
+// The `alg` parameter, if present, must match the curve of EC2 and OKP keys.
+func (k *Key) signatureAlgorithm() (Algorithm, error) {
+	var alg Algorithm
+	switch k.Type {
+	case KeyTypeEC2, KeyTypeOKP:
+		crv, err := k.curve()
+		if err != nil {
+			return 0, err
+		}
+		switch crv {
+		case CurveP256:
+			alg = AlgorithmES256
+		case CurveP384:
+			alg = AlgorithmES384
+		case CurveP521:
+			alg = AlgorithmES512
+		case CurveEd25519:
+			alg = AlgorithmEd25519
+		}
+	}
+	if k.Algorithm == 0 {
+		if alg == 0 {
+			return 0, fmt.Errorf("%v key: %w", k.Type, ErrAlgorithmNotFound)
+		}
+		return alg, nil
+	}
+	if alg != 0 && alg != k.Algorithm {
+		return 0, fmt.Errorf("%v: %w", k.Algorithm, ErrAlgorithmMismatch)
+	}
+	return k.Algorithm, nil
+}
+
+// ec2 returns the public key and, if present, the private key of an EC2 key.
+func (k *Key) ec2() (*ecdsa.PublicKey, *ecdsa.PrivateKey, error) {
+	crv, err := k.curve()
+	if err != nil {
+		return nil, nil, err
+	}
+	ec := crv.elliptic()
+	if ec == nil {
+		return nil, nil, fmt.Errorf("%v key: unsupported curve %v", k.Type, crv)
+	}
+	size := curveSize(ec)
+	x, err := k.paramBytes(KeyLabelEC2X)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := k.paramBytes(KeyLabelEC2D)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// decode the public key
+	var pub *ecdsa.PublicKey
+	if x != nil {
+		if len(x) != size {
+			return nil, nil, fmt.Errorf("%v key: invalid x-coordinate", k.Type)
+		}
+		var y []byte
+		switch v := k.Params[KeyLabelEC2Y].(type) {
+		case []byte:
+			y = v
+		case bool:
+			// point compression
+			prefix := byte(0x02)
+			if v {
+				prefix = 0x03
+			}
+			_, py := elliptic.UnmarshalCompressed(ec, append([]byte{prefix}, x...))
+			if py == nil {
+				return nil, nil, fmt.Errorf("%v key: invalid point", k.Type)
+			}
+			y = py.FillBytes(make([]byte, size))
+		}
+		if len(y) != size {
+			return nil, nil, fmt.Errorf("%v key: invalid y-coordinate", k.Type)
+		}
+		pub = &ecdsa.PublicKey{
+			Curve: ec,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !ec.IsOnCurve(pub.X, pub.Y) {
+			return nil, nil, fmt.Errorf("%v key: invalid point", k.Type)
+		}
+	}
+
+	// decode the private key
+	if d == nil {
+		if pub == nil {
+			return nil, nil, fmt.Errorf("%v key: missing x-coordinate", k.Type)
+		}
+		return pub, nil, nil
+	}
+	n := new(big.Int).SetBytes(d)
+	if len(d) != size || n.Sign() == 0 || n.Cmp(ec.Params().N) >= 0 {
+		return nil, nil, fmt.Errorf("%v key: invalid d", k.Type)
+	}
+	dx, dy := ec.ScalarBaseMult(d)
+	if pub == nil {
+		pub = &ecdsa.PublicKey{
+			Curve: ec,
+			X:     dx,
+			Y:     dy,
+		}
+	} else if pub.X.Cmp(dx) != 0 || pub.Y.Cmp(dy) != 0 {
+		return nil, nil, fmt.Errorf("%v key: public and private keys mismatch", k.Type)
+	}
+	return pub, &ecdsa.PrivateKey{
+		PublicKey: *pub,
+		D:         n,
+	}, nil
+}
+
+// okp returns the public key and, if present, the private key of an OKP key.
+func (k *Key) okp() (crypto.PublicKey, crypto.PrivateKey, error) {
+	crv, err := k.curve()
+	if err != nil {
+		return nil, nil, err
+	}
+	x, err := k.paramBytes(KeyLabelOKPX)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := k.paramBytes(KeyLabelOKPD)
+	if err != nil {
+		return nil, nil, err
+	}
+	if x == nil && d == nil {
+		return nil, nil, fmt.Errorf("%v key: missing x", k.Type)
+	}
+	switch crv {
+	case CurveEd25519:
+		if x != nil && len(x) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("%v key: invalid x", k.Type)
+		}
+		if d == nil {
+			return ed25519.PublicKey(x), nil, nil
+		}
+		if len(d) != ed25519.SeedSize {
+			return nil, nil, fmt.Errorf("%v key: invalid d", k.Type)
+		}
+		priv := ed25519.NewKeyFromSeed(d)
+		pub := priv.Public().(ed25519.PublicKey)
+		if x != nil && !bytes.Equal(x, pub) {
+			return nil, nil, fmt.Errorf("%v key: public and private keys mismatch", k.Type)
+		}
+		return pub, priv, nil
+	case CurveX25519:
+		if x != nil && len(x) != curve25519.PointSize {
+			return nil, nil, fmt.Errorf("%v key: invalid x", k.Type)
+		}
+		if d == nil {
+			return X25519PublicKey(x), nil, nil
+		}
+		if len(d) != curve25519.ScalarSize {
+			return nil, nil, fmt.Errorf("%v key: invalid d", k.Type)
+		}
+		priv := X25519PrivateKey(d)
+		pub := priv.Public().(X25519PublicKey)
+		if x != nil && !bytes.Equal(x, pub) {
+			return nil, nil, fmt.Errorf("%v key: public and private keys mismatch", k.Type)
+		}
+		return pub, priv, nil
+	}
+	return nil, nil, fmt.Errorf("%v key: unsupported curve %v", k.Type, crv)
+}
+
+// rsaPublicKey returns the public key of an RSA key.
+func (k *Key) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := k.paramBytes(KeyLabelRSAN)
+	if err != nil {
+		return nil, err
+	}
+	e, err := k.paramBytes(KeyLabelRSAE)
+	if err != nil {
+		return nil, err
+	}
+	if len(n) == 0 || len(e) == 0 {
+		return nil, fmt.Errorf("%v key: missing n or e", k.Type)
+	}
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("%v key: invalid e", k.Type)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exp.Int64()),
+	}, nil
+}
+
+// rsaPrivateKey returns the private key of an RSA key.
+// Multi-prime keys are not supported.
+func (k *Key) rsaPrivateKey() (*rsa.PrivateKey, error) {
+	pub, err := k.rsaPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	var params [3][]byte
+	for i, label := range []int64{KeyLabelRSAD, KeyLabelRSAP, KeyLabelRSAQ} {
+		if params[i], err = k.paramBytes(label); err != nil {
+			return nil, err
+		}
+		if len(params[i]) == 0 {
+			return nil, fmt.Errorf("%v key: missing private key", k.Type)
+		}
+	}
+	priv := &rsa.PrivateKey{
+		PublicKey: *pub,
+		D:         new(big.Int).SetBytes(params[0]),
+		Primes: []*big.Int{
+			new(big.Int).SetBytes(params[1]),
+			new(big.Int).SetBytes(params[2]),
+		},
+	}
+	if err := priv.Validate(); err != nil {
+		return nil, fmt.Errorf("%v key: %w", k.Type, err)
+	}
+	priv.Precompute()
+	return priv, nil
+}
+
+// KeySet represents a COSE_KeySet structure:
+//
+//	COSE_KeySet = [+COSE_Key]
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-7
+type KeySet []Key
+
+// MarshalCBOR encodes KeySet into a COSE_KeySet object.
+func (ks KeySet) MarshalCBOR() ([]byte, error) {
+	if len(ks) == 0 {
+		return nil, errors.New("COSE_KeySet: require non-empty array")
+	}
+	keys := make([]map[interface{}]interface{}, len(ks))
+	for i := range ks {
+		m, err := ks[i].toMap()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = m
+	}
+	return encMode.Marshal(keys)
+}
+
+// UnmarshalCBOR decodes a COSE_KeySet object into KeySet.
+func (ks *KeySet) UnmarshalCBOR(data []byte) error {
+	if ks == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil KeySet pointer")
+	}
+	var keys []map[interface{}]interface{}
+	if err := decMode.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("COSE_KeySet: require non-empty array")
+	}
+	set := make(KeySet, len(keys))
+	for i, m := range keys {
+		if err := set[i].fromMap(m); err != nil {
+			return err
+		}
+	}
+	*ks = set
+	return nil
+}
+
+// Lookup returns the first key with the key ID kid, or nil if not found.
+func (ks KeySet) Lookup(kid []byte) *Key {
+	for i := range ks {
+		if ks[i].ID != nil && bytes.Equal(ks[i].ID, kid) {
+			return &ks[i]
+		}
+	}
+	return nil
+}
+
+// keyFromHeaderValue returns the Key of a header parameter value.
+func keyFromHeaderValue(value interface{}) (*Key, error) {
+	switch v := value.(type) {
+	case *Key:
+		return v, nil
+	case Key:
+		return &v, nil
+	case map[interface{}]interface{}:
+		var key Key
+		if err := key.fromMap(v); err != nil {
+			return nil, err
+		}
+		return &key, nil
+	}
+	return nil, errors.New("require COSE_Key type")
+}
+
+// curveFromElliptic returns the COSE curve of an elliptic curve.
+func curveFromElliptic(curve elliptic.Curve) (Curve, error) {
+	switch curve {
+	case elliptic.P256():
+		return CurveP256, nil
+	case elliptic.P384():
+		return CurveP384, nil
+	case elliptic.P521():
+		return CurveP521, nil
+	}
+	return 0, errors.New("unsupported curve")
+}
+
+// elliptic returns the elliptic curve of the COSE curve, or nil if c is not
+// a NIST curve.
+func (c Curve) elliptic() elliptic.Curve {
+	switch c {
+	case CurveP256:
+		return elliptic.P256()
+	case CurveP384:
+		return elliptic.P384()
+	case CurveP521:
+		return elliptic.P521()
+	}
+	return nil
+}
+
+// curveSize returns the size in bytes of the coordinates of curve.
+func curveSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
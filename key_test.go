@@ -0,0 +1,818 @@
+package cose
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestKeyType_String(t *testing.T) {
+	tests := []struct {
+		kt   KeyType
+		want string
+	}{
+		{KeyTypeOKP, "OKP"},
+		{KeyTypeEC2, "EC2"},
+		{KeyTypeRSA, "RSA"},
+		{KeyTypeSymmetric, "Symmetric"},
+		{7, "unknown key type value 7"},
+	}
+	for _, tt := range tests {
+		if got := tt.kt.String(); got != tt.want {
+			t.Errorf("KeyType.String() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestCurve_String(t *testing.T) {
+	tests := []struct {
+		c    Curve
+		want string
+	}{
+		{CurveP256, "P-256"},
+		{CurveP384, "P-384"},
+		{CurveP521, "P-521"},
+		{CurveX25519, "X25519"},
+		{CurveX448, "X448"},
+		{CurveEd25519, "Ed25519"},
+		{CurveEd448, "Ed448"},
+		{-1, "unknown curve value -1"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("Curve.String() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestKey_MarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     *Key
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "symmetric key",
+			key: &Key{
+				Type:      KeyTypeSymmetric,
+				ID:        []byte("kid"),
+				Algorithm: AlgorithmHMAC256_256,
+				Ops:       []KeyOp{KeyOpMACCreate, KeyOpMACVerify},
+				BaseIV:    []byte{0x01, 0x02},
+				Params: map[interface{}]interface{}{
+					KeyLabelSymmetricK: []byte{0xaa},
+				},
+			},
+			want: []byte{
+				0xa6,       // map
+				0x01, 0x04, // kty: Symmetric
+				0x02, 0x43, 0x6b, 0x69, 0x64, // kid: "kid"
+				0x03, 0x05, // alg: HMAC 256/256
+				0x04, 0x82, 0x09, 0x0a, // key_ops: [MAC create, MAC verify]
+				0x05, 0x42, 0x01, 0x02, // Base IV
+				0x20, 0x41, 0xaa, // k
+			},
+		},
+		{
+			name: "key type only",
+			key: &Key{
+				Type: KeyTypeOKP,
+			},
+			want: []byte{0xa1, 0x01, 0x01},
+		},
+		{
+			name: "various types of integer label",
+			key: &Key{
+				Type: KeyTypeEC2,
+				Params: map[interface{}]interface{}{
+					int(-1):  1,
+					int8(-2): []byte{0x01},
+					"foo":    "bar",
+				},
+			},
+			want: []byte{
+				0xa4,       // map
+				0x01, 0x02, // kty: EC2
+				0x20, 0x01, // crv: P-256
+				0x21, 0x41, 0x01, // x
+				0x63, 0x66, 0x6f, 0x6f, 0x63, 0x62, 0x61, 0x72, // foo: bar
+			},
+		},
+		{
+			name:    "nil key",
+			key:     nil,
+			wantErr: true,
+		},
+		{
+			name:    "missing key type",
+			key:     &Key{},
+			wantErr: true,
+		},
+		{
+			name: "common parameter in key type parameters",
+			key: &Key{
+				Type: KeyTypeEC2,
+				Params: map[interface{}]interface{}{
+					KeyLabelKeyID: []byte("kid"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated label",
+			key: &Key{
+				Type: KeyTypeEC2,
+				Params: map[interface{}]interface{}{
+					int(-1):   1,
+					int64(-1): 1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid label",
+			key: &Key{
+				Type: KeyTypeEC2,
+				Params: map[interface{}]interface{}{
+					1.5: 1,
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.key.MarshalCBOR()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Key.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Key.MarshalCBOR() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKey_UnmarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Key
+		wantErr bool
+	}{
+		{
+			name: "symmetric key",
+			data: []byte{
+				0xa6,       // map
+				0x01, 0x04, // kty: Symmetric
+				0x02, 0x43, 0x6b, 0x69, 0x64, // kid: "kid"
+				0x03, 0x05, // alg: HMAC 256/256
+				0x04, 0x82, 0x09, 0x0a, // key_ops: [MAC create, MAC verify]
+				0x05, 0x42, 0x01, 0x02, // Base IV
+				0x20, 0x41, 0xaa, // k
+			},
+			want: Key{
+				Type:      KeyTypeSymmetric,
+				ID:        []byte("kid"),
+				Algorithm: AlgorithmHMAC256_256,
+				Ops:       []KeyOp{KeyOpMACCreate, KeyOpMACVerify},
+				BaseIV:    []byte{0x01, 0x02},
+				Params: map[interface{}]interface{}{
+					KeyLabelSymmetricK: []byte{0xaa},
+				},
+			},
+		},
+		{
+			name: "key type only",
+			data: []byte{0xa1, 0x01, 0x01},
+			want: Key{
+				Type:   KeyTypeOKP,
+				Params: map[interface{}]interface{}{},
+			},
+		},
+		{
+			name: "custom parameter",
+			data: []byte{
+				0xa2,       // map
+				0x01, 0x02, // kty: EC2
+				0x63, 0x66, 0x6f, 0x6f, 0x63, 0x62, 0x61, 0x72, // foo: bar
+			},
+			want: Key{
+				Type: KeyTypeEC2,
+				Params: map[interface{}]interface{}{
+					"foo": "bar",
+				},
+			},
+		},
+		{
+			name:    "not a map",
+			data:    []byte{0x80},
+			wantErr: true,
+		},
+		{
+			name:    "missing key type",
+			data:    []byte{0xa1, 0x02, 0x40},
+			wantErr: true,
+		},
+		{
+			name:    "string key type",
+			data:    []byte{0xa1, 0x01, 0x63, 0x66, 0x6f, 0x6f},
+			wantErr: true,
+		},
+		{
+			name:    "zero key type",
+			data:    []byte{0xa1, 0x01, 0x00},
+			wantErr: true,
+		},
+		{
+			name:    "invalid kid",
+			data:    []byte{0xa2, 0x01, 0x02, 0x02, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "string alg",
+			data:    []byte{0xa2, 0x01, 0x02, 0x03, 0x63, 0x66, 0x6f, 0x6f},
+			wantErr: true,
+		},
+		{
+			name:    "invalid key_ops",
+			data:    []byte{0xa2, 0x01, 0x02, 0x04, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "empty key_ops",
+			data:    []byte{0xa2, 0x01, 0x02, 0x04, 0x80},
+			wantErr: true,
+		},
+		{
+			name:    "string key_ops",
+			data:    []byte{0xa2, 0x01, 0x02, 0x04, 0x81, 0x61, 0x61},
+			wantErr: true,
+		},
+		{
+			name:    "invalid Base IV",
+			data:    []byte{0xa2, 0x01, 0x02, 0x05, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "invalid label",
+			data:    []byte{0xa2, 0x01, 0x02, 0xf4, 0x01},
+			wantErr: true,
+		},
+		{
+			name:    "duplicated key",
+			data:    []byte{0xa2, 0x01, 0x02, 0x01, 0x02},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Key
+			if err := got.UnmarshalCBOR(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("Key.UnmarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Key.UnmarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKeyFromPrivate(t *testing.T) {
+	_, ed25519Key := generateTestEd25519Key(t)
+	tests := []struct {
+		name     string
+		key      crypto.PrivateKey
+		wantType KeyType
+	}{
+		{
+			name:     "ecdsa key",
+			key:      generateTestECDSAKey(t),
+			wantType: KeyTypeEC2,
+		},
+		{
+			name:     "ed25519 key",
+			key:      ed25519Key,
+			wantType: KeyTypeOKP,
+		},
+		{
+			name:     "rsa key",
+			key:      generateTestRSAKey(t),
+			wantType: KeyTypeRSA,
+		},
+		{
+			name:     "ecdsa P-384 key",
+			key:      generateTestECDHKey(t, CurveP384),
+			wantType: KeyTypeEC2,
+		},
+		{
+			name:     "x25519 key",
+			key:      generateTestX25519Key(t),
+			wantType: KeyTypeOKP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewKeyFromPrivate(tt.key)
+			if err != nil {
+				t.Fatalf("NewKeyFromPrivate() error = %v", err)
+			}
+			if key.Type != tt.wantType {
+				t.Fatalf("NewKeyFromPrivate() type = %v, want %v", key.Type, tt.wantType)
+			}
+
+			// round trip through CBOR
+			data, err := key.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("Key.MarshalCBOR() error = %v", err)
+			}
+			var decoded Key
+			if err := decoded.UnmarshalCBOR(data); err != nil {
+				t.Fatalf("Key.UnmarshalCBOR() error = %v", err)
+			}
+
+			priv, err := decoded.PrivateKey()
+			if err != nil {
+				t.Fatalf("Key.PrivateKey() error = %v", err)
+			}
+			if !tt.key.(interface{ Equal(crypto.PrivateKey) bool }).Equal(priv) {
+				t.Errorf("Key.PrivateKey() = %v, want %v", priv, tt.key)
+			}
+		})
+	}
+}
+
+func TestNewKeyFromPublic(t *testing.T) {
+	ed25519Pub, _ := generateTestEd25519Key(t)
+	tests := []struct {
+		name    string
+		key     crypto.PublicKey
+		wantErr bool
+	}{
+		{
+			name: "ecdsa key",
+			key:  generateTestECDSAKey(t).Public(),
+		},
+		{
+			name: "ed25519 key",
+			key:  ed25519Pub,
+		},
+		{
+			name: "rsa key",
+			key:  generateTestRSAKey(t).Public(),
+		},
+		{
+			name: "x25519 key",
+			key:  generateTestX25519Key(t).Public(),
+		},
+		{
+			name: "unsupported curve",
+			key: func() crypto.PublicKey {
+				key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+				if err != nil {
+					t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+				}
+				return key.Public()
+			}(),
+			wantErr: true,
+		},
+		{
+			name:    "invalid x25519 key",
+			key:     X25519PublicKey("foo"),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported key type",
+			key:     []byte("foo"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewKeyFromPublic(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewKeyFromPublic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			pub, err := key.PublicKey()
+			if err != nil {
+				t.Fatalf("Key.PublicKey() error = %v", err)
+			}
+			if !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(tt.key) {
+				t.Errorf("Key.PublicKey() = %v, want %v", pub, tt.key)
+			}
+			if _, err := key.PrivateKey(); err == nil {
+				t.Error("Key.PrivateKey() error = nil, wantErr true")
+			}
+		})
+	}
+}
+
+func TestKey_PublicKey_EC2(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	x := key.X.FillBytes(make([]byte, 32))
+	y := key.Y.FillBytes(make([]byte, 32))
+	d := key.D.FillBytes(make([]byte, 32))
+	compressed := elliptic.MarshalCompressed(elliptic.P256(), key.X, key.Y)
+	tests := []struct {
+		name    string
+		params  map[interface{}]interface{}
+		wantErr bool
+	}{
+		{
+			name: "uncompressed point",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveP256),
+				KeyLabelEC2X:     x,
+				KeyLabelEC2Y:     y,
+			},
+		},
+		{
+			name: "compressed point",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveP256),
+				KeyLabelEC2X:     x,
+				KeyLabelEC2Y:     compressed[0] == 0x03,
+			},
+		},
+		{
+			name: "private key only",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveP256),
+				KeyLabelEC2D:     d,
+			},
+		},
+		{
+			name: "missing curve",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2X: x,
+				KeyLabelEC2Y: y,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported curve",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveX25519),
+				KeyLabelEC2X:     x,
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing x-coordinate",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveP256),
+				KeyLabelEC2Y:     y,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid x-coordinate",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveP256),
+				KeyLabelEC2X:     x[1:],
+				KeyLabelEC2Y:     y,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid y-coordinate",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveP256),
+				KeyLabelEC2X:     x,
+				KeyLabelEC2Y:     "foo",
+			},
+			wantErr: true,
+		},
+		{
+			name: "point not on curve",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveP256),
+				KeyLabelEC2X:     x,
+				KeyLabelEC2Y:     x,
+			},
+			wantErr: true,
+		},
+		{
+			name: "public and private keys mismatch",
+			params: map[interface{}]interface{}{
+				KeyLabelEC2Curve: int64(CurveP256),
+				KeyLabelEC2X:     x,
+				KeyLabelEC2Y:     y,
+				KeyLabelEC2D:     generateTestECDSAKey(t).D.FillBytes(make([]byte, 32)),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Key{
+				Type:   KeyTypeEC2,
+				Params: tt.params,
+			}
+			got, err := k.PublicKey()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Key.PublicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !key.PublicKey.Equal(got) {
+				t.Errorf("Key.PublicKey() = %v, want %v", got, &key.PublicKey)
+			}
+		})
+	}
+}
+
+func TestKey_PrivateKey_Errors(t *testing.T) {
+	_, ed25519Key := generateTestEd25519Key(t)
+	x25519Key := generateTestX25519Key(t)
+	rsaKey, err := NewKeyFromPrivate(generateTestRSAKey(t))
+	if err != nil {
+		t.Fatalf("NewKeyFromPrivate() error = %v", err)
+	}
+	rsaKey.Params[KeyLabelRSAP] = []byte{0x03}
+	tests := []struct {
+		name string
+		key  *Key
+	}{
+		{
+			name: "symmetric key",
+			key:  NewKeySymmetric([]byte("foo")),
+		},
+		{
+			name: "missing EC2 private key",
+			key: func() *Key {
+				key, err := NewKeyFromPublic(generateTestECDSAKey(t).Public())
+				if err != nil {
+					t.Fatalf("NewKeyFromPublic() error = %v", err)
+				}
+				return key
+			}(),
+		},
+		{
+			name: "invalid Ed25519 private key",
+			key:  newKeyOKP(CurveEd25519, nil, ed25519Key.Seed()[1:]),
+		},
+		{
+			name: "Ed25519 public and private keys mismatch",
+			key:  newKeyOKP(CurveEd25519, make([]byte, ed25519.PublicKeySize), ed25519Key.Seed()),
+		},
+		{
+			name: "invalid X25519 private key",
+			key:  newKeyOKP(CurveX25519, nil, x25519Key[1:]),
+		},
+		{
+			name: "X25519 public and private keys mismatch",
+			key:  newKeyOKP(CurveX25519, make([]byte, 32), x25519Key),
+		},
+		{
+			name: "invalid EC2 private key",
+			key:  newKeyEC2(CurveP256, nil, nil, make([]byte, 32)),
+		},
+		{
+			name: "unsupported OKP curve",
+			key:  newKeyOKP(CurveEd448, make([]byte, 57), make([]byte, 57)),
+		},
+		{
+			name: "invalid RSA private key",
+			key:  rsaKey,
+		},
+		{
+			name: "missing RSA private key",
+			key: func() *Key {
+				key, err := NewKeyFromPublic(generateTestRSAKey(t).Public())
+				if err != nil {
+					t.Fatalf("NewKeyFromPublic() error = %v", err)
+				}
+				return key
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.key.PrivateKey(); err == nil {
+				t.Error("Key.PrivateKey() error = nil, wantErr true")
+			}
+		})
+	}
+}
+
+func TestKey_SymmetricKey(t *testing.T) {
+	key := NewKeySymmetric([]byte("foo"))
+	got, err := key.SymmetricKey()
+	if err != nil {
+		t.Fatalf("Key.SymmetricKey() error = %v", err)
+	}
+	if want := []byte("foo"); !bytes.Equal(got, want) {
+		t.Errorf("Key.SymmetricKey() = %v, want %v", got, want)
+	}
+
+	if _, err := NewKeySymmetric(nil).SymmetricKey(); err == nil {
+		t.Error("Key.SymmetricKey() error = nil, wantErr true")
+	}
+	if _, err := (&Key{Type: KeyTypeEC2}).SymmetricKey(); err == nil {
+		t.Error("Key.SymmetricKey() error = nil, wantErr true")
+	}
+}
+
+func TestKey_Signer(t *testing.T) {
+	_, ed25519Key := generateTestEd25519Key(t)
+	newKey := func(key crypto.PrivateKey, alg Algorithm, ops ...KeyOp) *Key {
+		k, err := NewKeyFromPrivate(key)
+		if err != nil {
+			t.Fatalf("NewKeyFromPrivate() error = %v", err)
+		}
+		k.Algorithm = alg
+		k.Ops = ops
+		return k
+	}
+	tests := []struct {
+		name    string
+		key     *Key
+		wantAlg Algorithm
+		wantErr bool
+	}{
+		{
+			name:    "EC2 key",
+			key:     newKey(generateTestECDSAKey(t), 0),
+			wantAlg: AlgorithmES256,
+		},
+		{
+			name:    "EC2 key with algorithm",
+			key:     newKey(generateTestECDSAKey(t), AlgorithmES256, KeyOpSign, KeyOpVerify),
+			wantAlg: AlgorithmES256,
+		},
+		{
+			name:    "Ed25519 key",
+			key:     newKey(ed25519Key, 0),
+			wantAlg: AlgorithmEd25519,
+		},
+		{
+			name:    "RSA key",
+			key:     newKey(generateTestRSAKey(t), AlgorithmPS256),
+			wantAlg: AlgorithmPS256,
+		},
+		{
+			name:    "RSA key without algorithm",
+			key:     newKey(generateTestRSAKey(t), 0),
+			wantErr: true,
+		},
+		{
+			name:    "algorithm mismatch",
+			key:     newKey(generateTestECDSAKey(t), AlgorithmES384),
+			wantErr: true,
+		},
+		{
+			name:    "operation not allowed",
+			key:     newKey(generateTestECDSAKey(t), 0, KeyOpVerify),
+			wantErr: true,
+		},
+		{
+			name:    "X25519 key",
+			key:     newKey(generateTestX25519Key(t), 0),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := tt.key.Signer()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Key.Signer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := signer.Algorithm(); got != tt.wantAlg {
+				t.Fatalf("Signer.Algorithm() = %v, want %v", got, tt.wantAlg)
+			}
+
+			// sign and verify with the key
+			verifier, err := tt.key.Verifier()
+			if err != nil {
+				t.Fatalf("Key.Verifier() error = %v", err)
+			}
+			content := []byte("hello world")
+			sig, err := signer.Sign(rand.Reader, content)
+			if err != nil {
+				t.Fatalf("Signer.Sign() error = %v", err)
+			}
+			if err := verifier.Verify(content, sig); err != nil {
+				t.Fatalf("Verifier.Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestKey_Verifier(t *testing.T) {
+	newKey := func(key crypto.PublicKey, alg Algorithm, ops ...KeyOp) *Key {
+		k, err := NewKeyFromPublic(key)
+		if err != nil {
+			t.Fatalf("NewKeyFromPublic() error = %v", err)
+		}
+		k.Algorithm = alg
+		k.Ops = ops
+		return k
+	}
+	tests := []struct {
+		name    string
+		key     *Key
+		wantAlg Algorithm
+		wantErr bool
+	}{
+		{
+			name:    "EC2 key",
+			key:     newKey(generateTestECDSAKey(t).Public(), 0, KeyOpVerify),
+			wantAlg: AlgorithmES256,
+		},
+		{
+			name:    "RSA key",
+			key:     newKey(generateTestRSAKey(t).Public(), AlgorithmPS512),
+			wantAlg: AlgorithmPS512,
+		},
+		{
+			name:    "operation not allowed",
+			key:     newKey(generateTestECDSAKey(t).Public(), 0, KeyOpSign),
+			wantErr: true,
+		},
+		{
+			name:    "symmetric key",
+			key:     NewKeySymmetric([]byte("foo")),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := tt.key.Verifier()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Key.Verifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := verifier.Algorithm(); got != tt.wantAlg {
+				t.Errorf("Verifier.Algorithm() = %v, want %v", got, tt.wantAlg)
+			}
+		})
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	ecdsaKey, err := NewKeyFromPublic(generateTestECDSAKey(t).Public())
+	if err != nil {
+		t.Fatalf("NewKeyFromPublic() error = %v", err)
+	}
+	ecdsaKey.ID = []byte("ecdsa")
+	symmetricKey := NewKeySymmetric([]byte("foo"))
+	symmetricKey.ID = []byte("symmetric")
+	set := KeySet{*ecdsaKey, *symmetricKey}
+
+	data, err := set.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("KeySet.MarshalCBOR() error = %v", err)
+	}
+	var got KeySet
+	if err := got.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("KeySet.UnmarshalCBOR() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, set) {
+		t.Fatalf("KeySet.UnmarshalCBOR() = %v, want %v", got, set)
+	}
+
+	if key := got.Lookup([]byte("symmetric")); key == nil || !reflect.DeepEqual(*key, *symmetricKey) {
+		t.Errorf("KeySet.Lookup() = %v, want %v", key, symmetricKey)
+	}
+	if key := got.Lookup([]byte("foo")); key != nil {
+		t.Errorf("KeySet.Lookup() = %v, want nil", key)
+	}
+	if key := got.Lookup(nil); key != nil {
+		t.Errorf("KeySet.Lookup() = %v, want nil", key)
+	}
+
+	// invalid key sets
+	if _, err := (KeySet{}).MarshalCBOR(); err == nil {
+		t.Error("KeySet.MarshalCBOR() error = nil, wantErr true")
+	}
+	if _, err := (KeySet{{}}).MarshalCBOR(); err == nil {
+		t.Error("KeySet.MarshalCBOR() error = nil, wantErr true")
+	}
+	for _, data := range [][]byte{
+		{0x80},             // empty array
+		{0xa1, 0x01, 0x02}, // not an array
+		{0x81, 0xa0},       // missing key type
+	} {
+		if err := got.UnmarshalCBOR(data); err == nil {
+			t.Errorf("KeySet.UnmarshalCBOR(%x) error = nil, wantErr true", data)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cose
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// aesKeyWrapIV is the default initial value defined by RFC 3394 section 2.2.3.
+var aesKeyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// aesKeyWrap wraps key with kek using the AES Key Wrap algorithm.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc3394#section-2.2.1
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, errors.New("key wrap: invalid key length")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	out := make([]byte, len(key)+8)
+	copy(out, aesKeyWrapIV)
+	copy(out[8:], key)
+	var buf [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[i*8:])
+			block.Encrypt(buf[:], buf[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	return out, nil
+}
+
+// aesKeyUnwrap unwraps wrapped with kek using the AES Key Wrap algorithm.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc3394#section-2.2.2
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, errors.New("key wrap: invalid wrapped key length")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	out := make([]byte, len(wrapped))
+	copy(out, wrapped)
+	var buf [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(out[:8])^t)
+			copy(buf[8:], out[i*8:])
+			block.Decrypt(buf[:], buf[:])
+			copy(out[:8], buf[:8])
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(out[:8], aesKeyWrapIV) != 1 {
+		return nil, ErrDecryption
+	}
+	return out[8:], nil
+}
+
+// aesKeyWrapper is an AES Key Wrap based KeyEncrypter and KeyDecrypter.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.2
+type aesKeyWrapper struct {
+	alg Algorithm
+	key []byte
+}
+
+// newAESKeyWrapper returns an AES Key Wrap based KeyEncrypter and
+// KeyDecrypter.
+func newAESKeyWrapper(alg Algorithm, key []byte) (*aesKeyWrapper, error) {
+	if size := alg.keySize(); len(key) != size {
+		return nil, fmt.Errorf("%v: require %d-byte key, got %d", alg, size, len(key))
+	}
+	return &aesKeyWrapper{
+		alg: alg,
+		key: key,
+	}, nil
+}
+
+// Algorithm returns the key management algorithm associated with the key.
+func (kw *aesKeyWrapper) Algorithm() Algorithm {
+	return kw.alg
+}
+
+// EncryptKey wraps the content key with the key encryption key.
+// If cek is nil, a random content key is generated using entropy from rand.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.2.1
+func (kw *aesKeyWrapper) EncryptKey(rand io.Reader, recipient *Recipient, alg Algorithm, cek []byte) ([]byte, error) {
+	if !recipient.Headers.isProtectedEmpty() {
+		return nil, fmt.Errorf("%v: protected header must be empty", kw.alg)
+	}
+	if cek == nil {
+		var err error
+		if cek, err = generateContentKey(rand, alg); err != nil {
+			return nil, err
+		}
+	}
+	wrapped, err := aesKeyWrap(kw.key, cek)
+	if err != nil {
+		return nil, err
+	}
+	recipient.Ciphertext = wrapped
+	return cek, nil
+}
+
+// DecryptKey unwraps the content key with the key encryption key.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.2.1
+func (kw *aesKeyWrapper) DecryptKey(recipient *Recipient, alg Algorithm) ([]byte, error) {
+	if !recipient.Headers.isProtectedEmpty() {
+		return nil, fmt.Errorf("%v: protected header must be empty", kw.alg)
+	}
+	cek, err := aesKeyUnwrap(kw.key, recipient.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if size := alg.keySize(); size > 0 && len(cek) != size {
+		return nil, ErrDecryption
+	}
+	return cek, nil
+}
@@ -0,0 +1,147 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func Test_aesKeyWrap(t *testing.T) {
+	// test vectors taken from RFC 3394 section 4.
+	tests := []struct {
+		name    string
+		kek     string
+		key     string
+		wrapped string
+	}{
+		{
+			name:    "128 bits of key data with a 128-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f",
+			key:     "00112233445566778899aabbccddeeff",
+			wrapped: "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5",
+		},
+		{
+			name:    "128 bits of key data with a 192-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f1011121314151617",
+			key:     "00112233445566778899aabbccddeeff",
+			wrapped: "96778b25ae6ca435f92b5b97c050aed2468ab8a17ad84e5d",
+		},
+		{
+			name:    "128 bits of key data with a 256-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			key:     "00112233445566778899aabbccddeeff",
+			wrapped: "64e8c3f9ce0f5ba263e9777905818a2a93c8191e7d6e8ae7",
+		},
+		{
+			name:    "256 bits of key data with a 256-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			key:     "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f",
+			wrapped: "28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kek := mustDecodeHex(t, tt.kek)
+			key := mustDecodeHex(t, tt.key)
+			want := mustDecodeHex(t, tt.wrapped)
+			got, err := aesKeyWrap(kek, key)
+			if err != nil {
+				t.Fatalf("aesKeyWrap() error = %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("aesKeyWrap() = %x, want %x", got, want)
+			}
+			got, err = aesKeyUnwrap(kek, want)
+			if err != nil {
+				t.Fatalf("aesKeyUnwrap() error = %v", err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Fatalf("aesKeyUnwrap() = %x, want %x", got, key)
+			}
+		})
+	}
+}
+
+func Test_aesKeyUnwrap_Failure(t *testing.T) {
+	kek := mustDecodeHex(t, "000102030405060708090a0b0c0d0e0f")
+	wrapped := mustDecodeHex(t, "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5")
+
+	// tampered wrapped key
+	tampered := append([]byte{}, wrapped...)
+	tampered[len(tampered)-1]++
+	if _, err := aesKeyUnwrap(kek, tampered); err != ErrDecryption {
+		t.Errorf("aesKeyUnwrap() error = %v, wantErr %v", err, ErrDecryption)
+	}
+
+	// invalid length
+	if _, err := aesKeyUnwrap(kek, wrapped[:16]); err == nil {
+		t.Errorf("aesKeyUnwrap() error = nil, wantErr true")
+	}
+	if _, err := aesKeyWrap(kek, []byte("short")); err == nil {
+		t.Errorf("aesKeyWrap() error = nil, wantErr true")
+	}
+
+	// invalid kek
+	if _, err := aesKeyUnwrap(kek[:5], wrapped); err == nil {
+		t.Errorf("aesKeyUnwrap() error = nil, wantErr true")
+	}
+}
+
+func Test_aesKeyWrapper(t *testing.T) {
+	tests := []struct {
+		alg     Algorithm
+		keySize int
+	}{
+		{alg: AlgorithmA128KW, keySize: 16},
+		{alg: AlgorithmA192KW, keySize: 24},
+		{alg: AlgorithmA256KW, keySize: 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg.String(), func(t *testing.T) {
+			key := make([]byte, tt.keySize)
+			if _, err := rand.Read(key); err != nil {
+				t.Fatalf("rand.Read() error = %v", err)
+			}
+			if _, err := NewKeyEncrypter(tt.alg, key[1:]); err == nil {
+				t.Fatalf("NewKeyEncrypter() error = nil, wantErr true")
+			}
+			encrypter, err := NewKeyEncrypter(tt.alg, key)
+			if err != nil {
+				t.Fatalf("NewKeyEncrypter() error = %v", err)
+			}
+			decrypter, err := NewKeyDecrypter(tt.alg, key)
+			if err != nil {
+				t.Fatalf("NewKeyDecrypter() error = %v", err)
+			}
+
+			// random content key
+			recipient := NewRecipient()
+			cek, err := encrypter.EncryptKey(rand.Reader, recipient, AlgorithmHMAC512_512, nil)
+			if err != nil {
+				t.Fatalf("EncryptKey() error = %v", err)
+			}
+			if len(cek) != 64 {
+				t.Fatalf("EncryptKey() key size = %d, want %d", len(cek), 64)
+			}
+			got, err := decrypter.DecryptKey(recipient, AlgorithmHMAC512_512)
+			if err != nil {
+				t.Fatalf("DecryptKey() error = %v", err)
+			}
+			if !bytes.Equal(got, cek) {
+				t.Fatalf("DecryptKey() = %x, want %x", got, cek)
+			}
+
+			// content key size mismatch
+			if _, err := decrypter.DecryptKey(recipient, AlgorithmHMAC256_256); err != ErrDecryption {
+				t.Fatalf("DecryptKey() error = %v, wantErr %v", err, ErrDecryption)
+			}
+
+			// protected header must be empty
+			recipient = NewRecipient()
+			recipient.Headers.Protected.SetAlgorithm(tt.alg)
+			if _, err := encrypter.EncryptKey(rand.Reader, recipient, AlgorithmHMAC256_256, nil); err == nil {
+				t.Fatalf("EncryptKey() error = nil, wantErr true")
+			}
+		})
+	}
+}
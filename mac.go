@@ -0,0 +1,245 @@
+package cose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// macMessage represents a COSE_Mac CBOR object:
+//
+//	COSE_Mac = [
+//	    Headers,
+//	    payload : bstr / nil,
+//	    tag : bstr,
+//	    recipients : [+COSE_recipient]
+//	]
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.1
+type macMessage struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   cbor.RawMessage
+	Unprotected cbor.RawMessage
+	Payload     byteString
+	Tag         byteString
+	Recipients  []cbor.RawMessage
+}
+
+// macMessagePrefix represents the fixed prefix of COSE_Mac_Tagged.
+var macMessagePrefix = []byte{
+	0xd8, 0x61, // #6.97
+	0x85, // Array of length 5
+}
+
+// MacMessage represents a decoded COSE_Mac message.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.1
+type MacMessage struct {
+	Headers    Headers
+	Payload    []byte
+	Tag        []byte
+	Recipients []Recipient
+}
+
+// NewMacMessage returns a MacMessage with header initialized.
+func NewMacMessage() *MacMessage {
+	return &MacMessage{
+		Headers: Headers{
+			Protected:   ProtectedHeader{},
+			Unprotected: UnprotectedHeader{},
+		},
+	}
+}
+
+// Kind returns MessageKindMac.
+func (m *MacMessage) Kind() MessageKind {
+	return MessageKindMac
+}
+
+// MessageHeaders returns the headers of the message body.
+func (m *MacMessage) MessageHeaders() *Headers {
+	if m == nil {
+		return nil
+	}
+	return &m.Headers
+}
+
+// MarshalCBOR encodes MacMessage into a COSE_Mac_Tagged object.
+func (m *MacMessage) MarshalCBOR() ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil MacMessage pointer")
+	}
+	if len(m.Tag) == 0 {
+		return nil, ErrEmptyTag
+	}
+	if len(m.Recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	protected, unprotected, err := m.Headers.marshal()
+	if err != nil {
+		return nil, err
+	}
+	recipients, err := marshalRecipients(m.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	content := macMessage{
+		Protected:   protected,
+		Unprotected: unprotected,
+		Payload:     m.Payload,
+		Tag:         m.Tag,
+		Recipients:  recipients,
+	}
+	return encMode.Marshal(cbor.Tag{
+		Number:  CBORTagMacMessage,
+		Content: content,
+	})
+}
+
+// UnmarshalCBOR decodes a COSE_Mac_Tagged object into MacMessage.
+func (m *MacMessage) UnmarshalCBOR(data []byte) error {
+	if m == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil MacMessage pointer")
+	}
+
+	if err := defaultDecoder.validate(data); err != nil {
+		return err
+	}
+
+	// fast message check
+	if !bytes.HasPrefix(data, macMessagePrefix) {
+		return errors.New("cbor: invalid COSE_Mac_Tagged object")
+	}
+
+	// decode to macMessage and parse
+	var raw macMessage
+	if err := decModeWithTagsForbidden.Unmarshal(data[2:], &raw); err != nil {
+		return err
+	}
+	if len(raw.Tag) == 0 {
+		return ErrEmptyTag
+	}
+	recipients, err := unmarshalRecipients(raw.Recipients)
+	if err != nil {
+		return err
+	}
+	msg := MacMessage{
+		Headers: Headers{
+			RawProtected:   raw.Protected,
+			RawUnprotected: raw.Unprotected,
+		},
+		Payload:    raw.Payload,
+		Tag:        raw.Tag,
+		Recipients: recipients,
+	}
+	if err := msg.Headers.UnmarshalFromRaw(); err != nil {
+		return err
+	}
+
+	*m = msg
+	return nil
+}
+
+// Create computes the authentication tag of a MacMessage and distributes the
+// MAC key to the recipients using the provided encrypters corresponding to the
+// recipients.
+// The MAC algorithm is taken from the `alg` header of m.Headers.Protected.
+//
+// If there are multiple recipients, a random MAC key is generated using
+// entropy from rand, and direct key management algorithms, including direct
+// key agreement, are rejected. Otherwise, the MAC key is chosen by the
+// encrypter of the single recipient.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func (m *MacMessage) Create(rand io.Reader, external []byte, encrypters ...KeyEncrypter) error {
+	if m == nil {
+		return errors.New("creating nil MacMessage")
+	}
+	if m.Payload == nil {
+		return ErrMissingPayload
+	}
+	if len(m.Tag) > 0 {
+		return errors.New("MacMessage already has tag bytes")
+	}
+	alg, err := m.Headers.Protected.Algorithm()
+	if err != nil {
+		return err
+	}
+
+	// distribute the MAC key
+	key, err := distributeKey(rand, m.Recipients, encrypters, alg)
+	if err != nil {
+		return err
+	}
+	macer, err := NewMACer(alg, key)
+	if err != nil {
+		return err
+	}
+
+	// compute the tag
+	toBeMACed, err := m.toBeMACed(external)
+	if err != nil {
+		return err
+	}
+	tag, err := macer.MAC(toBeMACed)
+	if err != nil {
+		return err
+	}
+
+	m.Tag = tag
+	return nil
+}
+
+// Verify recovers the MAC key using the provided decrypter and verifies the
+// authentication tag on the MacMessage, returning nil on success or a suitable
+// error if verification fails.
+// The MAC key is recovered from the first recipient whose algorithm matches the
+// decrypter.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func (m *MacMessage) Verify(external []byte, decrypter KeyDecrypter) error {
+	if m == nil {
+		return errors.New("verifying nil MacMessage")
+	}
+	if m.Payload == nil {
+		return ErrMissingPayload
+	}
+	if len(m.Tag) == 0 {
+		return ErrEmptyTag
+	}
+	alg, err := m.Headers.Protected.Algorithm()
+	if err != nil {
+		return err
+	}
+
+	// recover the MAC key
+	key, err := recoverKey(m.Recipients, nil, decrypter, alg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	verifier, err := NewMACVerifier(alg, key)
+	if err != nil {
+		return err
+	}
+
+	// verify the tag
+	toBeMACed, err := m.toBeMACed(external)
+	if err != nil {
+		return err
+	}
+	return verifier.Verify(toBeMACed, m.Tag)
+}
+
+// toBeMACed constructs MAC_structure, computes and returns ToBeMaced.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func (m *MacMessage) toBeMACed(external []byte) ([]byte, error) {
+	protected, err := m.Headers.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+	return macStructure("MAC", protected, external, m.Payload)
+}
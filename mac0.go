@@ -0,0 +1,254 @@
+package cose
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// mac0Message represents a COSE_Mac0 CBOR object:
+//
+//	COSE_Mac0 = [
+//	    Headers,
+//	    payload : bstr / nil,
+//	    tag : bstr,
+//	]
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.2
+type mac0Message struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   cbor.RawMessage
+	Unprotected cbor.RawMessage
+	Payload     byteString
+	Tag         byteString
+}
+
+// mac0MessagePrefix represents the fixed prefix of COSE_Mac0_Tagged.
+var mac0MessagePrefix = []byte{
+	0xd1, // #6.17
+	0x84, // Array of length 4
+}
+
+// Mac0Message represents a decoded COSE_Mac0 message.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.2
+type Mac0Message struct {
+	Headers Headers
+	Payload []byte
+	Tag     []byte
+}
+
+// NewMac0Message returns a Mac0Message with header initialized.
+func NewMac0Message() *Mac0Message {
+	return &Mac0Message{
+		Headers: Headers{
+			Protected:   ProtectedHeader{},
+			Unprotected: UnprotectedHeader{},
+		},
+	}
+}
+
+// Kind returns MessageKindMac0.
+func (m *Mac0Message) Kind() MessageKind {
+	return MessageKindMac0
+}
+
+// MessageHeaders returns the headers of the message body.
+func (m *Mac0Message) MessageHeaders() *Headers {
+	if m == nil {
+		return nil
+	}
+	return &m.Headers
+}
+
+// MarshalCBOR encodes Mac0Message into a COSE_Mac0_Tagged object.
+func (m *Mac0Message) MarshalCBOR() ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil Mac0Message pointer")
+	}
+	if len(m.Tag) == 0 {
+		return nil, ErrEmptyTag
+	}
+	protected, unprotected, err := m.Headers.marshal()
+	if err != nil {
+		return nil, err
+	}
+	content := mac0Message{
+		Protected:   protected,
+		Unprotected: unprotected,
+		Payload:     m.Payload,
+		Tag:         m.Tag,
+	}
+	return encMode.Marshal(cbor.Tag{
+		Number:  CBORTagMac0Message,
+		Content: content,
+	})
+}
+
+// UnmarshalCBOR decodes a COSE_Mac0_Tagged object into Mac0Message.
+func (m *Mac0Message) UnmarshalCBOR(data []byte) error {
+	if m == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil Mac0Message pointer")
+	}
+
+	if err := defaultDecoder.validate(data); err != nil {
+		return err
+	}
+
+	// fast message check
+	if !bytes.HasPrefix(data, mac0MessagePrefix) {
+		return errors.New("cbor: invalid COSE_Mac0_Tagged object")
+	}
+
+	// decode to mac0Message and parse
+	var raw mac0Message
+	if err := decModeWithTagsForbidden.Unmarshal(data[1:], &raw); err != nil {
+		return err
+	}
+	if len(raw.Tag) == 0 {
+		return ErrEmptyTag
+	}
+	msg := Mac0Message{
+		Headers: Headers{
+			RawProtected:   raw.Protected,
+			RawUnprotected: raw.Unprotected,
+		},
+		Payload: raw.Payload,
+		Tag:     raw.Tag,
+	}
+	if err := msg.Headers.UnmarshalFromRaw(); err != nil {
+		return err
+	}
+
+	*m = msg
+	return nil
+}
+
+// Create computes the authentication tag of a Mac0Message using the provided
+// MACer.
+// The tag is stored in m.Tag.
+//
+// Note that m.Tag is only valid as long as m.Headers.Protected and
+// m.Payload remain unchanged after calling this method.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func (m *Mac0Message) Create(external []byte, macer MACer) error {
+	if m == nil {
+		return errors.New("creating nil Mac0Message")
+	}
+	if m.Payload == nil {
+		return ErrMissingPayload
+	}
+	if len(m.Tag) > 0 {
+		return errors.New("Mac0Message already has tag bytes")
+	}
+
+	// check algorithm if present.
+	// `alg` header MUST be present if there is no externally supplied data.
+	alg := macer.Algorithm()
+	err := m.Headers.ensureMACAlgorithm(alg, external)
+	if err != nil {
+		return err
+	}
+
+	// compute the tag
+	toBeMACed, err := m.toBeMACed(external)
+	if err != nil {
+		return err
+	}
+	tag, err := macer.MAC(toBeMACed)
+	if err != nil {
+		return err
+	}
+
+	m.Tag = tag
+	return nil
+}
+
+// Verify verifies the authentication tag on the Mac0Message returning nil on
+// success or a suitable error if verification fails.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func (m *Mac0Message) Verify(external []byte, verifier MACVerifier) error {
+	if m == nil {
+		return errors.New("verifying nil Mac0Message")
+	}
+	if m.Payload == nil {
+		return ErrMissingPayload
+	}
+	if len(m.Tag) == 0 {
+		return ErrEmptyTag
+	}
+
+	// check algorithm if present.
+	// `alg` header MUST present if there is no externally supplied data.
+	alg := verifier.Algorithm()
+	err := m.Headers.ensureMACVerificationAlgorithm(alg, external)
+	if err != nil {
+		return err
+	}
+
+	// verify the tag
+	toBeMACed, err := m.toBeMACed(external)
+	if err != nil {
+		return err
+	}
+	return verifier.Verify(toBeMACed, m.Tag)
+}
+
+// toBeMACed constructs MAC_structure, computes and returns ToBeMaced.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func (m *Mac0Message) toBeMACed(external []byte) ([]byte, error) {
+	protected, err := m.Headers.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+	return macStructure("MAC0", protected, external, m.Payload)
+}
+
+// macStructure constructs MAC_structure and returns its encoding, ToBeMaced.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func macStructure(context string, protected cbor.RawMessage, external, payload []byte) ([]byte, error) {
+	// create a MAC_structure and populate it with the appropriate fields.
+	//
+	//   MAC_structure = [
+	//       context : "MAC" / "MAC0",
+	//       protected : empty_or_serialized_map,
+	//       external_aad : bstr,
+	//       payload : bstr
+	//   ]
+	if external == nil {
+		external = []byte{}
+	}
+	macStructure := []interface{}{
+		context,   // context
+		protected, // protected
+		external,  // external_aad
+		payload,   // payload
+	}
+
+	// create the value ToBeMaced by encoding the MAC_structure to a byte
+	// string.
+	return encMode.Marshal(macStructure)
+}
+
+// Mac0 computes the authentication tag of a Mac0Message using the provided
+// MACer and returns the encoded message.
+//
+// This method is a wrapper of `Mac0Message.Create()`.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-6.3
+func Mac0(macer MACer, headers Headers, payload []byte, external []byte) ([]byte, error) {
+	msg := Mac0Message{
+		Headers: headers,
+		Payload: payload,
+	}
+	err := msg.Create(external, macer)
+	if err != nil {
+		return nil, err
+	}
+	return msg.MarshalCBOR()
+}
@@ -0,0 +1,386 @@
+package cose
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestMac0Message_MarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       *Mac0Message
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "valid message",
+			m: &Mac0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelContentType: 42,
+					},
+				},
+				Payload: []byte("foo"),
+				Tag:     []byte("bar"),
+			},
+			want: []byte{
+				0xd1, // tag
+				0x84,
+				0x43, 0xa1, 0x01, 0x04, // protected
+				0xa1, 0x03, 0x18, 0x2a, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // tag
+			},
+		},
+		{
+			name:    "nil message",
+			m:       nil,
+			wantErr: true,
+		},
+		{
+			name: "nil payload",
+			m: &Mac0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+					},
+				},
+				Payload: nil,
+				Tag:     []byte("bar"),
+			},
+			want: []byte{
+				0xd1, // tag
+				0x84,
+				0x43, 0xa1, 0x01, 0x04, // protected
+				0xa0,                   // unprotected
+				0xf6,                   // payload
+				0x43, 0x62, 0x61, 0x72, // tag
+			},
+		},
+		{
+			name: "nil tag",
+			m: &Mac0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+					},
+				},
+				Payload: []byte("foo"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid protected header",
+			m: &Mac0Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: make(chan bool),
+					},
+				},
+				Payload: []byte("foo"),
+				Tag:     []byte("bar"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.MarshalCBOR()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Mac0Message.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Mac0Message.MarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMac0Message_UnmarshalCBOR(t *testing.T) {
+	// test nil pointer
+	t.Run("nil Mac0Message pointer", func(t *testing.T) {
+		var msg *Mac0Message
+		data := []byte{0xd1, 0x84, 0x40, 0xa0, 0xf6, 0x41, 0x00}
+		if err := msg.UnmarshalCBOR(data); err == nil {
+			t.Errorf("want error on nil *Mac0Message")
+		}
+	})
+
+	// test others
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Mac0Message
+		wantErr bool
+	}{
+		{
+			name: "valid message",
+			data: []byte{
+				0xd1, // tag
+				0x84,
+				0x43, 0xa1, 0x01, 0x04, // protected
+				0xa1, 0x03, 0x18, 0x2a, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // tag
+			},
+			want: Mac0Message{
+				Headers: Headers{
+					RawProtected: []byte{0x43, 0xa1, 0x01, 0x04},
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+					},
+					RawUnprotected: []byte{0xa1, 0x03, 0x18, 0x2a},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelContentType: int64(42),
+					},
+				},
+				Payload: []byte("foo"),
+				Tag:     []byte("bar"),
+			},
+		},
+		{
+			name: "nil payload",
+			data: []byte{
+				0xd1, // tag
+				0x84,
+				0x40,                   // protected
+				0xa0,                   // unprotected
+				0xf6,                   // payload
+				0x43, 0x62, 0x61, 0x72, // tag
+			},
+			want: Mac0Message{
+				Headers: Headers{
+					RawProtected:   []byte{0x40},
+					Protected:      ProtectedHeader{},
+					RawUnprotected: []byte{0xa0},
+					Unprotected:    UnprotectedHeader{},
+				},
+				Tag: []byte("bar"),
+			},
+		},
+		{
+			name: "COSE_Sign1 tag",
+			data: []byte{
+				0xd2, // tag
+				0x84,
+				0x40,                   // protected
+				0xa0,                   // unprotected
+				0xf6,                   // payload
+				0x43, 0x62, 0x61, 0x72, // tag
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty tag",
+			data: []byte{
+				0xd1, // tag
+				0x84,
+				0x40, // protected
+				0xa0, // unprotected
+				0xf6, // payload
+				0x40, // tag
+			},
+			wantErr: true,
+		},
+		{
+			name: "tag as nil",
+			data: []byte{
+				0xd1, // tag
+				0x84,
+				0x40, // protected
+				0xa0, // unprotected
+				0xf6, // payload
+				0xf6, // tag
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid protected header",
+			data: []byte{
+				0xd1, // tag
+				0x84,
+				0x41, 0x00, // protected
+				0xa0,       // unprotected
+				0xf6,       // payload
+				0x41, 0x00, // tag
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Mac0Message
+			if err := got.UnmarshalCBOR(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("Mac0Message.UnmarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mac0Message.UnmarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMac0Message_Create(t *testing.T) {
+	// test vector taken from
+	// https://github.com/cose-wg/Examples/blob/master/hmac-examples/HMac-01.json
+	key := mustDecodeHex(t, "849b57219dae48de646d07dbb533566e976686457c1491be3a76dcea6c427188")
+	macer, err := NewMACer(AlgorithmHMAC256_256, key)
+	if err != nil {
+		t.Fatalf("NewMACer() error = %v", err)
+	}
+	msg := NewMac0Message()
+	msg.Payload = []byte("This is the content.")
+	if err := msg.Create(nil, macer); err != nil {
+		t.Fatalf("Mac0Message.Create() error = %v", err)
+	}
+	got, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("Mac0Message.MarshalCBOR() error = %v", err)
+	}
+	want := mustDecodeHex(t, "d18443a10105a054546869732069732074686520636f6e74656e742e5820a1a848d3471f9d61ee49018d244c824772f223ad4f935293f1789fc3a08d8c58")
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Mac0Message.MarshalCBOR() = %x, want %x", got, want)
+	}
+
+	// create again
+	if err := msg.Create(nil, macer); err == nil {
+		t.Errorf("Mac0Message.Create() error = nil, wantErr true")
+	}
+
+	// algorithm mismatch
+	msg = NewMac0Message()
+	msg.Payload = []byte("This is the content.")
+	msg.Headers.Protected.SetAlgorithm(AlgorithmHMAC512_512)
+	wantErr := "algorithm mismatch: MACer HMAC 256/256: header HMAC 512/512"
+	if err := msg.Create(nil, macer); err == nil || err.Error() != wantErr {
+		t.Errorf("Mac0Message.Create() error = %v, wantErr %v", err, wantErr)
+	}
+
+	// missing payload
+	msg = NewMac0Message()
+	if err := msg.Create(nil, macer); err != ErrMissingPayload {
+		t.Errorf("Mac0Message.Create() error = %v, wantErr %v", err, ErrMissingPayload)
+	}
+
+	// nil message
+	msg = nil
+	if err := msg.Create(nil, macer); err == nil {
+		t.Errorf("Mac0Message.Create() error = nil, wantErr true")
+	}
+}
+
+func TestMac0Message_Verify(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	macer, err := NewMACer(AlgorithmHMAC256_64, key)
+	if err != nil {
+		t.Fatalf("NewMACer() error = %v", err)
+	}
+	verifier, err := NewMACVerifier(AlgorithmHMAC256_64, key)
+	if err != nil {
+		t.Fatalf("NewMACVerifier() error = %v", err)
+	}
+	external := []byte("external")
+	headers := Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+		},
+		Unprotected: UnprotectedHeader{
+			HeaderLabelKeyID: []byte("our-secret"),
+		},
+	}
+	data, err := Mac0(macer, headers, []byte("hello world"), external)
+	if err != nil {
+		t.Fatalf("Mac0() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		tamper   func(m *Mac0Message)
+		external []byte
+		verifier MACVerifier
+		wantErr  error
+	}{
+		{
+			name:     "valid message",
+			external: external,
+			verifier: verifier,
+		},
+		{
+			name:     "different external",
+			external: []byte("foo"),
+			verifier: verifier,
+			wantErr:  ErrVerification,
+		},
+		{
+			name:     "tampered payload",
+			tamper:   func(m *Mac0Message) { m.Payload = []byte("foobar") },
+			external: external,
+			verifier: verifier,
+			wantErr:  ErrVerification,
+		},
+		{
+			name:     "tampered tag",
+			tamper:   func(m *Mac0Message) { m.Tag[0]++ },
+			external: external,
+			verifier: verifier,
+			wantErr:  ErrVerification,
+		},
+		{
+			name:     "missing payload",
+			tamper:   func(m *Mac0Message) { m.Payload = nil },
+			external: external,
+			verifier: verifier,
+			wantErr:  ErrMissingPayload,
+		},
+		{
+			name:     "missing tag",
+			tamper:   func(m *Mac0Message) { m.Tag = nil },
+			external: external,
+			verifier: verifier,
+			wantErr:  ErrEmptyTag,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg Mac0Message
+			if err := msg.UnmarshalCBOR(data); err != nil {
+				t.Fatalf("Mac0Message.UnmarshalCBOR() error = %v", err)
+			}
+			if tt.tamper != nil {
+				tt.tamper(&msg)
+			}
+			if err := msg.Verify(tt.external, tt.verifier); err != tt.wantErr {
+				t.Errorf("Mac0Message.Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// algorithm mismatch
+	var msg Mac0Message
+	if err := msg.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("Mac0Message.UnmarshalCBOR() error = %v", err)
+	}
+	otherVerifier, err := NewMACVerifier(AlgorithmHMAC256_256, key)
+	if err != nil {
+		t.Fatalf("NewMACVerifier() error = %v", err)
+	}
+	wantErr := "algorithm mismatch: MAC verifier HMAC 256/256: header HMAC 256/64"
+	if err := msg.Verify(external, otherVerifier); err == nil || err.Error() != wantErr {
+		t.Errorf("Mac0Message.Verify() error = %v, wantErr %v", err, wantErr)
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex.DecodeString() error = %v", err)
+	}
+	return b
+}
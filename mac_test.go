@@ -0,0 +1,401 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMacMessage_MarshalCBOR(t *testing.T) {
+	recipient := Recipient{
+		Headers: Headers{
+			Unprotected: UnprotectedHeader{
+				HeaderLabelAlgorithm: AlgorithmDirect,
+			},
+		},
+		Ciphertext: []byte{},
+	}
+	tests := []struct {
+		name    string
+		m       *MacMessage
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "valid message",
+			m: &MacMessage{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelContentType: 42,
+					},
+				},
+				Payload:    []byte("foo"),
+				Tag:        []byte("bar"),
+				Recipients: []Recipient{recipient},
+			},
+			want: []byte{
+				0xd8, 0x61, // tag
+				0x85,
+				0x43, 0xa1, 0x01, 0x04, // protected
+				0xa1, 0x03, 0x18, 0x2a, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // tag
+				0x81, // recipients
+				0x83, 0x40, 0xa1, 0x01, 0x25, 0x40,
+			},
+		},
+		{
+			name:    "nil message",
+			m:       nil,
+			wantErr: true,
+		},
+		{
+			name: "nil tag",
+			m: &MacMessage{
+				Payload:    []byte("foo"),
+				Recipients: []Recipient{recipient},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no recipients",
+			m: &MacMessage{
+				Payload: []byte("foo"),
+				Tag:     []byte("bar"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid recipient",
+			m: &MacMessage{
+				Payload: []byte("foo"),
+				Tag:     []byte("bar"),
+				Recipients: []Recipient{
+					{
+						Headers: Headers{
+							Unprotected: UnprotectedHeader{
+								HeaderLabelKeyID: 42,
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.MarshalCBOR()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MacMessage.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("MacMessage.MarshalCBOR() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMacMessage_UnmarshalCBOR(t *testing.T) {
+	// test nil pointer
+	t.Run("nil MacMessage pointer", func(t *testing.T) {
+		var msg *MacMessage
+		data := []byte{0xd8, 0x61, 0x85, 0x40, 0xa0, 0xf6, 0x41, 0x00, 0x81, 0x83, 0x40, 0xa0, 0x40}
+		if err := msg.UnmarshalCBOR(data); err == nil {
+			t.Errorf("want error on nil *MacMessage")
+		}
+	})
+
+	// test others
+	tests := []struct {
+		name    string
+		data    []byte
+		want    MacMessage
+		wantErr bool
+	}{
+		{
+			name: "valid message",
+			data: []byte{
+				0xd8, 0x61, // tag
+				0x85,
+				0x43, 0xa1, 0x01, 0x04, // protected
+				0xa1, 0x03, 0x18, 0x2a, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // tag
+				0x81, // recipients
+				0x83, 0x40, 0xa1, 0x01, 0x25, 0x40,
+			},
+			want: MacMessage{
+				Headers: Headers{
+					RawProtected: []byte{0x43, 0xa1, 0x01, 0x04},
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+					},
+					RawUnprotected: []byte{0xa1, 0x03, 0x18, 0x2a},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelContentType: int64(42),
+					},
+				},
+				Payload: []byte("foo"),
+				Tag:     []byte("bar"),
+				Recipients: []Recipient{
+					{
+						Headers: Headers{
+							RawProtected:   []byte{0x40},
+							Protected:      ProtectedHeader{},
+							RawUnprotected: []byte{0xa1, 0x01, 0x25},
+							Unprotected: UnprotectedHeader{
+								HeaderLabelAlgorithm: int64(AlgorithmDirect),
+							},
+						},
+						Ciphertext: []byte{},
+					},
+				},
+			},
+		},
+		{
+			name: "COSE_Mac0 tag",
+			data: []byte{
+				0xd1, // tag
+				0x84,
+				0x40,       // protected
+				0xa0,       // unprotected
+				0xf6,       // payload
+				0x41, 0x00, // tag
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty tag",
+			data: []byte{
+				0xd8, 0x61, // tag
+				0x85,
+				0x40, // protected
+				0xa0, // unprotected
+				0xf6, // payload
+				0x40, // tag
+				0x81, // recipients
+				0x83, 0x40, 0xa1, 0x01, 0x25, 0x40,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no recipients",
+			data: []byte{
+				0xd8, 0x61, // tag
+				0x85,
+				0x40,       // protected
+				0xa0,       // unprotected
+				0xf6,       // payload
+				0x41, 0x00, // tag
+				0x80, // recipients
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid recipient",
+			data: []byte{
+				0xd8, 0x61, // tag
+				0x85,
+				0x40,       // protected
+				0xa0,       // unprotected
+				0xf6,       // payload
+				0x41, 0x00, // tag
+				0x81, // recipients
+				0x40,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got MacMessage
+			if err := got.UnmarshalCBOR(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("MacMessage.UnmarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MacMessage.UnmarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMacMessage_Create_Verify(t *testing.T) {
+	// prepare keys
+	newKey := func(size int) []byte {
+		key := make([]byte, size)
+		if _, err := rand.Read(key); err != nil {
+			t.Fatalf("rand.Read() error = %v", err)
+		}
+		return key
+	}
+	directKey := newKey(32)
+	kw128Key := newKey(16)
+	kw256Key := newKey(32)
+	mustEncrypter := func(alg Algorithm, key []byte) KeyEncrypter {
+		e, err := NewKeyEncrypter(alg, key)
+		if err != nil {
+			t.Fatalf("NewKeyEncrypter() error = %v", err)
+		}
+		return e
+	}
+	mustDecrypter := func(alg Algorithm, key []byte) KeyDecrypter {
+		d, err := NewKeyDecrypter(alg, key)
+		if err != nil {
+			t.Fatalf("NewKeyDecrypter() error = %v", err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name       string
+		alg        Algorithm
+		recipients int
+		encrypters []KeyEncrypter
+		decrypters []KeyDecrypter
+		wantErr    bool
+	}{
+		{
+			name:       "direct",
+			alg:        AlgorithmHMAC256_256,
+			recipients: 1,
+			encrypters: []KeyEncrypter{mustEncrypter(AlgorithmDirect, directKey)},
+			decrypters: []KeyDecrypter{mustDecrypter(AlgorithmDirect, directKey)},
+		},
+		{
+			name:       "key wrap",
+			alg:        AlgorithmHMAC512_512,
+			recipients: 2,
+			encrypters: []KeyEncrypter{
+				mustEncrypter(AlgorithmA128KW, kw128Key),
+				mustEncrypter(AlgorithmA256KW, kw256Key),
+			},
+			decrypters: []KeyDecrypter{
+				mustDecrypter(AlgorithmA128KW, kw128Key),
+				mustDecrypter(AlgorithmA256KW, kw256Key),
+			},
+		},
+		{
+			name:       "direct with multiple recipients",
+			alg:        AlgorithmHMAC256_256,
+			recipients: 2,
+			encrypters: []KeyEncrypter{
+				mustEncrypter(AlgorithmA128KW, kw128Key),
+				mustEncrypter(AlgorithmDirect, directKey),
+			},
+			wantErr: true,
+		},
+		{
+			name:       "direct first with multiple recipients",
+			alg:        AlgorithmHMAC256_256,
+			recipients: 2,
+			encrypters: []KeyEncrypter{
+				mustEncrypter(AlgorithmDirect, directKey),
+				mustEncrypter(AlgorithmA128KW, kw128Key),
+			},
+			wantErr: true,
+		},
+		{
+			name:       "encrypters mismatch",
+			alg:        AlgorithmHMAC256_256,
+			recipients: 2,
+			encrypters: []KeyEncrypter{mustEncrypter(AlgorithmDirect, directKey)},
+			wantErr:    true,
+		},
+		{
+			name:       "no recipients",
+			alg:        AlgorithmHMAC256_256,
+			recipients: 0,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := NewMacMessage()
+			msg.Headers.Protected.SetAlgorithm(tt.alg)
+			msg.Payload = []byte("hello world")
+			for i := 0; i < tt.recipients; i++ {
+				msg.Recipients = append(msg.Recipients, *NewRecipient())
+			}
+			external := []byte("external")
+			err := msg.Create(rand.Reader, external, tt.encrypters...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MacMessage.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			data, err := msg.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("MacMessage.MarshalCBOR() error = %v", err)
+			}
+
+			for _, decrypter := range tt.decrypters {
+				var got MacMessage
+				if err := got.UnmarshalCBOR(data); err != nil {
+					t.Fatalf("MacMessage.UnmarshalCBOR() error = %v", err)
+				}
+				if err := got.Verify(external, decrypter); err != nil {
+					t.Fatalf("MacMessage.Verify() error = %v", err)
+				}
+
+				// tamper the message
+				got.Payload = []byte("foobar")
+				if err := got.Verify(external, decrypter); err != ErrVerification {
+					t.Fatalf("MacMessage.Verify() error = %v, wantErr %v", err, ErrVerification)
+				}
+			}
+		})
+	}
+}
+
+func TestMacMessage_Verify_Failure(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	encrypter, err := NewKeyEncrypter(AlgorithmA128KW, key)
+	if err != nil {
+		t.Fatalf("NewKeyEncrypter() error = %v", err)
+	}
+	msg := NewMacMessage()
+	msg.Headers.Protected.SetAlgorithm(AlgorithmHMAC256_256)
+	msg.Payload = []byte("hello world")
+	msg.Recipients = []Recipient{*NewRecipient()}
+	if err := msg.Create(rand.Reader, nil, encrypter); err != nil {
+		t.Fatalf("MacMessage.Create() error = %v", err)
+	}
+
+	// create again
+	if err := msg.Create(rand.Reader, nil, encrypter); err == nil {
+		t.Errorf("MacMessage.Create() error = nil, wantErr true")
+	}
+
+	// wrong key
+	decrypter, err := NewKeyDecrypter(AlgorithmA128KW, []byte("fedcba9876543210"))
+	if err != nil {
+		t.Fatalf("NewKeyDecrypter() error = %v", err)
+	}
+	if err := msg.Verify(nil, decrypter); !errors.Is(err, ErrVerification) {
+		t.Errorf("MacMessage.Verify() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// no recipient with the algorithm
+	decrypter, err = NewKeyDecrypter(AlgorithmDirect, key)
+	if err != nil {
+		t.Fatalf("NewKeyDecrypter() error = %v", err)
+	}
+	if err := msg.Verify(nil, decrypter); !errors.Is(err, ErrVerification) {
+		t.Errorf("MacMessage.Verify() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// missing algorithm
+	msg.Headers.Protected = ProtectedHeader{}
+	if err := msg.Verify(nil, decrypter); err != ErrAlgorithmNotFound {
+		t.Errorf("MacMessage.Verify() error = %v, wantErr %v", err, ErrAlgorithmNotFound)
+	}
+}
@@ -0,0 +1,108 @@
+package cose
+
+import (
+	"crypto/hmac"
+	"fmt"
+)
+
+// MACer is an interface for symmetric keys to create COSE MAC tags.
+type MACer interface {
+	// Algorithm returns the MAC algorithm associated with the key.
+	Algorithm() Algorithm
+
+	// MAC computes the authentication tag of the content with the key.
+	// The resulting tag should follow RFC 9053 section 3.
+	//
+	// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-3
+	MAC(content []byte) ([]byte, error)
+}
+
+// MACVerifier is an interface for symmetric keys to verify COSE MAC tags.
+type MACVerifier interface {
+	// Algorithm returns the MAC algorithm associated with the key.
+	Algorithm() Algorithm
+
+	// Verify verifies the authentication tag of the content with the key,
+	// returning nil for success.
+	// Otherwise, it returns ErrVerification.
+	//
+	// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-3
+	Verify(content, tag []byte) error
+}
+
+// NewMACer returns a MACer with a given symmetric key.
+func NewMACer(alg Algorithm, key []byte) (MACer, error) {
+	return newHMAC(alg, key)
+}
+
+// NewMACVerifier returns a MACVerifier with a given symmetric key.
+func NewMACVerifier(alg Algorithm, key []byte) (MACVerifier, error) {
+	return newHMAC(alg, key)
+}
+
+// newHMAC returns a HMAC based MACer and MACVerifier.
+func newHMAC(alg Algorithm, key []byte) (*hmacMACer, error) {
+	var tagSize int
+	switch alg {
+	case AlgorithmHMAC256_64:
+		tagSize = 8
+	case AlgorithmHMAC256_256, AlgorithmHMAC384_384, AlgorithmHMAC512_512:
+		tagSize = alg.hashFunc().Size()
+	default:
+		return nil, ErrAlgorithmNotSupported
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%v: empty key", alg)
+	}
+	return &hmacMACer{
+		alg:     alg,
+		key:     key,
+		tagSize: tagSize,
+	}, nil
+}
+
+// hmacMACer is a HMAC w/ SHA-2 based MACer and MACVerifier.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-3.1
+type hmacMACer struct {
+	alg     Algorithm
+	key     []byte
+	tagSize int
+}
+
+// Algorithm returns the MAC algorithm associated with the key.
+func (hm *hmacMACer) Algorithm() Algorithm {
+	return hm.alg
+}
+
+// MAC computes the authentication tag of the content with the key.
+// The tag is truncated to the length defined by the algorithm.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-3.1
+func (hm *hmacMACer) MAC(content []byte) ([]byte, error) {
+	hash := hm.alg.hashFunc()
+	if !hash.Available() {
+		return nil, ErrUnavailableHashFunc
+	}
+	h := hmac.New(hash.New, hm.key)
+	if _, err := h.Write(content); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil)[:hm.tagSize], nil
+}
+
+// Verify verifies the authentication tag of the content with the key,
+// returning nil for success.
+// Otherwise, it returns ErrVerification.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-3.1
+func (hm *hmacMACer) Verify(content, tag []byte) error {
+	expected, err := hm.MAC(content)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expected, tag) {
+		return ErrVerification
+	}
+	return nil
+}
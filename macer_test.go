@@ -0,0 +1,144 @@
+package cose
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestNewMACer(t *testing.T) {
+	key := []byte("secret")
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		key     []byte
+		want    MACer
+		wantErr bool
+	}{
+		{
+			name: "HMAC 256/64",
+			alg:  AlgorithmHMAC256_64,
+			key:  key,
+			want: &hmacMACer{
+				alg:     AlgorithmHMAC256_64,
+				key:     key,
+				tagSize: 8,
+			},
+		},
+		{
+			name: "HMAC 256/256",
+			alg:  AlgorithmHMAC256_256,
+			key:  key,
+			want: &hmacMACer{
+				alg:     AlgorithmHMAC256_256,
+				key:     key,
+				tagSize: 32,
+			},
+		},
+		{
+			name: "HMAC 384/384",
+			alg:  AlgorithmHMAC384_384,
+			key:  key,
+			want: &hmacMACer{
+				alg:     AlgorithmHMAC384_384,
+				key:     key,
+				tagSize: 48,
+			},
+		},
+		{
+			name: "HMAC 512/512",
+			alg:  AlgorithmHMAC512_512,
+			key:  key,
+			want: &hmacMACer{
+				alg:     AlgorithmHMAC512_512,
+				key:     key,
+				tagSize: 64,
+			},
+		},
+		{
+			name:    "empty key",
+			alg:     AlgorithmHMAC256_256,
+			wantErr: true,
+		},
+		{
+			name:    "signature algorithm",
+			alg:     AlgorithmES256,
+			key:     key,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMACer(tt.alg, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewMACer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewMACer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hmacMACer(t *testing.T) {
+	// test vectors taken from RFC 4231 test case 2.
+	key := []byte("Jefe")
+	content := []byte("what do ya want for nothing?")
+	tests := []struct {
+		alg  Algorithm
+		want string
+	}{
+		{
+			alg:  AlgorithmHMAC256_64,
+			want: "5bdcc146bf60754e",
+		},
+		{
+			alg:  AlgorithmHMAC256_256,
+			want: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			alg:  AlgorithmHMAC384_384,
+			want: "af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+		},
+		{
+			alg:  AlgorithmHMAC512_512,
+			want: "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg.String(), func(t *testing.T) {
+			macer, err := NewMACer(tt.alg, key)
+			if err != nil {
+				t.Fatalf("NewMACer() error = %v", err)
+			}
+			if got := macer.Algorithm(); got != tt.alg {
+				t.Fatalf("Algorithm() = %v, want %v", got, tt.alg)
+			}
+			tag, err := macer.MAC(content)
+			if err != nil {
+				t.Fatalf("MAC() error = %v", err)
+			}
+			if got := hex.EncodeToString(tag); got != tt.want {
+				t.Fatalf("MAC() = %v, want %v", got, tt.want)
+			}
+
+			verifier, err := NewMACVerifier(tt.alg, key)
+			if err != nil {
+				t.Fatalf("NewMACVerifier() error = %v", err)
+			}
+			if err := verifier.Verify(content, tag); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			// tamper the tag
+			tag[0]++
+			if err := verifier.Verify(content, tag); err != ErrVerification {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, ErrVerification)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package cose
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// cborTagCWT is the CBOR tag of CBOR Web Tokens, which may wrap a tagged COSE
+// message.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8392#section-6
+const cborTagCWT = 61
+
+// MessageKind identifies the kind of a COSE message.
+// Application-defined message kinds should use negative values.
+type MessageKind int
+
+// Message kinds of the COSE messages supported by this library.
+const (
+	MessageKindSign1 MessageKind = iota + 1
+	MessageKindSign
+	MessageKindMac0
+	MessageKindMac
+	MessageKindEncrypt0
+	MessageKindEncrypt
+)
+
+// String returns the name of the COSE structure of the message kind.
+func (k MessageKind) String() string {
+	switch k {
+	case MessageKindSign1:
+		return "COSE_Sign1"
+	case MessageKindSign:
+		return "COSE_Sign"
+	case MessageKindMac0:
+		return "COSE_Mac0"
+	case MessageKindMac:
+		return "COSE_Mac"
+	case MessageKindEncrypt0:
+		return "COSE_Encrypt0"
+	case MessageKindEncrypt:
+		return "COSE_Encrypt"
+	}
+	return "unknown message kind " + strconv.Itoa(int(k))
+}
+
+// Message is a decoded COSE message.
+//
+// The headers of the message body are exposed by MessageHeaders rather than
+// Headers, since Go does not allow a method to share the name of the Headers
+// field holding them in the message types.
+type Message interface {
+	// Kind returns the kind of the message.
+	Kind() MessageKind
+
+	// MessageHeaders returns the headers of the message body.
+	MessageHeaders() *Headers
+
+	// MarshalCBOR encodes the message into a tagged COSE object.
+	MarshalCBOR() ([]byte, error)
+
+	// UnmarshalCBOR decodes a tagged COSE object into the message.
+	UnmarshalCBOR(data []byte) error
+}
+
+// builtInMessages maps the CBOR tags of the COSE messages supported by this
+// library to their constructors.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+var builtInMessages = map[uint64]func() Message{
+	CBORTagSign1Message:    func() Message { return &Sign1Message{} },
+	CBORTagSignMessage:     func() Message { return &SignMessage{} },
+	CBORTagMac0Message:     func() Message { return &Mac0Message{} },
+	CBORTagMacMessage:      func() Message { return &MacMessage{} },
+	CBORTagEncrypt0Message: func() Message { return &Encrypt0Message{} },
+	CBORTagEncryptMessage:  func() Message { return &EncryptMessage{} },
+}
+
+// extMessages contains the message types registered by RegisterMessage.
+var (
+	extMessages     = make(map[uint64]func() Message)
+	extMessagesLock sync.RWMutex
+)
+
+// RegisterMessage provides extensibility for Unmarshal to decode application
+// defined messages, or messages not yet supported by this library, identified
+// by the CBOR tag.
+// newMessage returns an empty message, into which the tagged object is decoded.
+// The tags of the existing messages, as well as the CWT tag, cannot be
+// re-registered.
+//
+// RegisterMessage is safe for concurrent use. It is typically called from an
+// init function.
+func RegisterMessage(tag uint64, newMessage func() Message) error {
+	if newMessage == nil {
+		return errors.New("message constructor is required")
+	}
+	if _, ok := builtInMessages[tag]; ok || tag == cborTagCWT {
+		return fmt.Errorf("%w: tag %d", ErrMessageRegistered, tag)
+	}
+	extMessagesLock.Lock()
+	defer extMessagesLock.Unlock()
+	if _, ok := extMessages[tag]; ok {
+		return fmt.Errorf("%w: tag %d", ErrMessageRegistered, tag)
+	}
+	extMessages[tag] = newMessage
+	return nil
+}
+
+// lookupMessage returns the constructor of a built-in or registered message.
+func lookupMessage(tag uint64) (func() Message, bool) {
+	if newMessage, ok := builtInMessages[tag]; ok {
+		return newMessage, true
+	}
+	extMessagesLock.RLock()
+	defer extMessagesLock.RUnlock()
+	newMessage, ok := extMessages[tag]
+	return newMessage, ok
+}
+
+// Unmarshal decodes a tagged COSE message, dispatching on its CBOR tag to the
+// built-in or registered message types.
+// A message wrapped in the CWT tag is unwrapped first.
+//
+// Use a type switch or Kind to access the decoded message, e.g.
+//
+//	switch msg := msg.(type) {
+//	case *cose.Sign1Message:
+//	    err = msg.Verify(nil, verifier)
+//	}
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+func Unmarshal(data []byte) (Message, error) {
+	tag, content, err := decodeTagHead(data)
+	if err != nil {
+		return nil, err
+	}
+	if tag == cborTagCWT {
+		data = content
+		tag, _, err = decodeTagHead(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	newMessage, ok := lookupMessage(tag)
+	if !ok {
+		return nil, fmt.Errorf("%w: tag %d", ErrMessageNotSupported, tag)
+	}
+	msg := newMessage()
+	if err := msg.UnmarshalCBOR(data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,284 @@
+package cose
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	recipient := Recipient{
+		Headers: Headers{
+			Unprotected: UnprotectedHeader{
+				HeaderLabelAlgorithm: AlgorithmDirect,
+			},
+		},
+		Ciphertext: []byte{},
+	}
+	macHeaders := Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+		},
+	}
+	encryptHeaders := Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: AlgorithmA128GCM,
+		},
+		Unprotected: UnprotectedHeader{
+			HeaderLabelIV: []byte("iv"),
+		},
+	}
+	tests := []struct {
+		name     string
+		m        Message
+		wantKind MessageKind
+	}{
+		{
+			name: "COSE_Sign1",
+			m: &Sign1Message{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmES256,
+					},
+				},
+				Payload:   []byte("foo"),
+				Signature: []byte("bar"),
+			},
+			wantKind: MessageKindSign1,
+		},
+		{
+			name: "COSE_Sign",
+			m: &SignMessage{
+				Payload: []byte("foo"),
+				Signatures: []*Signature{
+					{
+						Headers: Headers{
+							Protected: ProtectedHeader{
+								HeaderLabelAlgorithm: AlgorithmES256,
+							},
+						},
+						Signature: []byte("bar"),
+					},
+				},
+			},
+			wantKind: MessageKindSign,
+		},
+		{
+			name: "COSE_Mac0",
+			m: &Mac0Message{
+				Headers: macHeaders,
+				Payload: []byte("foo"),
+				Tag:     []byte("bar"),
+			},
+			wantKind: MessageKindMac0,
+		},
+		{
+			name: "COSE_Mac",
+			m: &MacMessage{
+				Headers:    macHeaders,
+				Payload:    []byte("foo"),
+				Tag:        []byte("bar"),
+				Recipients: []Recipient{recipient},
+			},
+			wantKind: MessageKindMac,
+		},
+		{
+			name: "COSE_Encrypt0",
+			m: &Encrypt0Message{
+				Headers:    encryptHeaders,
+				Ciphertext: []byte("foo"),
+			},
+			wantKind: MessageKindEncrypt0,
+		},
+		{
+			name: "COSE_Encrypt",
+			m: &EncryptMessage{
+				Headers:    encryptHeaders,
+				Ciphertext: []byte("foo"),
+				Recipients: []Recipient{recipient},
+			},
+			wantKind: MessageKindEncrypt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.m.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("MarshalCBOR() error = %v", err)
+			}
+			for _, data := range [][]byte{data, append([]byte{0xd8, 0x3d}, data...)} {
+				got, err := Unmarshal(data)
+				if err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if reflect.TypeOf(got) != reflect.TypeOf(tt.m) {
+					t.Errorf("Unmarshal() = %T, want %T", got, tt.m)
+				}
+				if kind := got.Kind(); kind != tt.wantKind {
+					t.Errorf("Message.Kind() = %v, want %v", kind, tt.wantKind)
+				}
+				if kind := tt.m.Kind(); kind != tt.wantKind {
+					t.Errorf("Message.Kind() = %v, want %v", kind, tt.wantKind)
+				}
+				protected, err := tt.m.MessageHeaders().MarshalProtected()
+				if err != nil {
+					t.Fatalf("Headers.MarshalProtected() error = %v", err)
+				}
+				if got := got.MessageHeaders().RawProtected; !bytes.Equal(got, protected) {
+					t.Errorf("Message.MessageHeaders().RawProtected = %x, want %x", got, protected)
+				}
+				encoded, err := got.MarshalCBOR()
+				if err != nil {
+					t.Fatalf("MarshalCBOR() error = %v", err)
+				}
+				if !bytes.Equal(encoded, bytes.TrimPrefix(data, []byte{0xd8, 0x3d})) {
+					t.Errorf("MarshalCBOR() = %x, want %x", encoded, data)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "empty data",
+			data:    nil,
+			wantErr: io.EOF,
+		},
+		{
+			name: "untagged message",
+			data: []byte{0x84, 0x40, 0xa0, 0x40, 0x40},
+		},
+		{
+			name:    "unknown tag",
+			data:    []byte{0xd8, 0x64, 0x84},
+			wantErr: ErrMessageNotSupported,
+		},
+		{
+			name:    "unknown tag in CWT",
+			data:    []byte{0xd8, 0x3d, 0xd8, 0x64, 0x84},
+			wantErr: ErrMessageNotSupported,
+		},
+		{
+			name: "untagged message in CWT",
+			data: []byte{0xd8, 0x3d, 0x84},
+		},
+		{
+			name:    "truncated tag head",
+			data:    []byte{0xda, 0x00, 0x01},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "invalid tag head",
+			data: []byte{0xdf},
+		},
+		{
+			name: "invalid message",
+			data: []byte{0xd2, 0x84, 0x40, 0xa0, 0x40, 0x40},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal(tt.data)
+			if err == nil {
+				t.Fatal("Unmarshal() error = nil, wantErr true")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// testMessage is an application-defined message holding a tagged bstr.
+type testMessage struct {
+	Headers Headers
+	Content []byte
+}
+
+func (m *testMessage) Kind() MessageKind {
+	return -1
+}
+
+func (m *testMessage) MessageHeaders() *Headers {
+	return &m.Headers
+}
+
+func (m *testMessage) MarshalCBOR() ([]byte, error) {
+	return append([]byte{0xda, 0x00, 0x01, 0x00, 0x00, 0x41}, m.Content...), nil
+}
+
+func (m *testMessage) UnmarshalCBOR(data []byte) error {
+	_, content, err := decodeTagHead(data)
+	if err != nil {
+		return err
+	}
+	return decMode.Unmarshal(content, &m.Content)
+}
+
+func TestRegisterMessage(t *testing.T) {
+	const tag = 0x10000
+	t.Cleanup(func() {
+		extMessagesLock.Lock()
+		delete(extMessages, tag)
+		extMessagesLock.Unlock()
+	})
+	data := []byte{0xda, 0x00, 0x01, 0x00, 0x00, 0x41, 0x2a}
+	if _, err := Unmarshal(data); !errors.Is(err, ErrMessageNotSupported) {
+		t.Errorf("Unmarshal() error = %v, wantErr %v", err, ErrMessageNotSupported)
+	}
+
+	newMessage := func() Message { return &testMessage{} }
+	if err := RegisterMessage(tag, newMessage); err != nil {
+		t.Fatalf("RegisterMessage() error = %v", err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := (&testMessage{Content: []byte{0x2a}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %v, want %v", got, want)
+	}
+	if kind := got.Kind(); kind.String() != "unknown message kind -1" {
+		t.Errorf("MessageKind.String() = %v, want %v", kind, "unknown message kind -1")
+	}
+
+	// re-registration
+	for _, tag := range []uint64{tag, CBORTagSign1Message, 61} {
+		if err := RegisterMessage(tag, newMessage); !errors.Is(err, ErrMessageRegistered) {
+			t.Errorf("RegisterMessage(%d) error = %v, wantErr %v", tag, err, ErrMessageRegistered)
+		}
+	}
+	if err := RegisterMessage(tag+1, nil); err == nil {
+		t.Error("RegisterMessage() error = nil, wantErr true")
+	}
+}
+
+func TestMessageKind_String(t *testing.T) {
+	tests := []struct {
+		kind MessageKind
+		want string
+	}{
+		{MessageKindSign1, "COSE_Sign1"},
+		{MessageKindSign, "COSE_Sign"},
+		{MessageKindMac0, "COSE_Mac0"},
+		{MessageKindMac, "COSE_Mac"},
+		{MessageKindEncrypt0, "COSE_Encrypt0"},
+		{MessageKindEncrypt, "COSE_Encrypt"},
+		{0, "unknown message kind 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.kind.String(); got != tt.want {
+				t.Errorf("MessageKind.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package cose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// KeyEncrypter is an interface for key management algorithms to distribute the
+// content key to a recipient.
+type KeyEncrypter interface {
+	// Algorithm returns the key management algorithm.
+	Algorithm() Algorithm
+
+	// EncryptKey distributes the content key cek of the content algorithm alg
+	// to the recipient, possibly using entropy from rand.
+	// The encrypted key is stored in recipient.Ciphertext and the algorithm
+	// specific parameters are added to recipient.Headers.
+	//
+	// If cek is nil, the content key is chosen by the key management
+	// algorithm: direct algorithms use the shared key, and key wrapping
+	// algorithms generate a random key.
+	// The content key in use is returned.
+	//
+	// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-8.5
+	EncryptKey(rand io.Reader, recipient *Recipient, alg Algorithm, cek []byte) ([]byte, error)
+}
+
+// KeyDecrypter is an interface for key management algorithms to recover the
+// content key from a recipient.
+type KeyDecrypter interface {
+	// Algorithm returns the key management algorithm.
+	Algorithm() Algorithm
+
+	// DecryptKey recovers the content key of the content algorithm alg from
+	// the recipient.
+	// It returns ErrDecryption if the content key cannot be recovered.
+	//
+	// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-8.5
+	DecryptKey(recipient *Recipient, alg Algorithm) ([]byte, error)
+}
+
+// NewKeyEncrypter returns a KeyEncrypter with a given symmetric key for the
+// direct and AES Key Wrap key management algorithms.
+func NewKeyEncrypter(alg Algorithm, key []byte) (KeyEncrypter, error) {
+	return newSymmetricKeyManager(alg, key)
+}
+
+// NewKeyDecrypter returns a KeyDecrypter with a given symmetric key for the
+// direct and AES Key Wrap key management algorithms.
+func NewKeyDecrypter(alg Algorithm, key []byte) (KeyDecrypter, error) {
+	return newSymmetricKeyManager(alg, key)
+}
+
+// symmetricKeyManager is a KeyEncrypter and a KeyDecrypter with a symmetric
+// key.
+type symmetricKeyManager interface {
+	KeyEncrypter
+	KeyDecrypter
+}
+
+// newSymmetricKeyManager returns a KeyEncrypter and KeyDecrypter with a
+// symmetric key.
+func newSymmetricKeyManager(alg Algorithm, key []byte) (symmetricKeyManager, error) {
+	switch alg {
+	case AlgorithmDirect:
+		if len(key) == 0 {
+			return nil, fmt.Errorf("%v: empty key", alg)
+		}
+		return &directKey{
+			key: key,
+		}, nil
+	case AlgorithmA128KW, AlgorithmA192KW, AlgorithmA256KW:
+		return newAESKeyWrapper(alg, key)
+	default:
+		return nil, ErrAlgorithmNotSupported
+	}
+}
+
+// directKey is a KeyEncrypter and KeyDecrypter using a shared secret as the
+// content key.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.1.1
+type directKey struct {
+	key []byte
+}
+
+// Algorithm returns the key management algorithm.
+func (dk *directKey) Algorithm() Algorithm {
+	return AlgorithmDirect
+}
+
+// EncryptKey returns the shared secret as the content key.
+// Since the content key is not transported, the direct algorithm can only be
+// used when there is a single recipient.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.1.1
+func (dk *directKey) EncryptKey(_ io.Reader, recipient *Recipient, alg Algorithm, cek []byte) ([]byte, error) {
+	if cek != nil {
+		return nil, errors.New("direct: content key must not be shared with other recipients")
+	}
+	if !recipient.Headers.isProtectedEmpty() {
+		return nil, errors.New("direct: protected header must be empty")
+	}
+	if size := alg.keySize(); size > 0 && len(dk.key) != size {
+		return nil, fmt.Errorf("direct: %v: require %d-byte key, got %d", alg, size, len(dk.key))
+	}
+	recipient.Ciphertext = []byte{}
+	return dk.key, nil
+}
+
+// DecryptKey returns the shared secret as the content key.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9053#section-6.1.1
+func (dk *directKey) DecryptKey(recipient *Recipient, alg Algorithm) ([]byte, error) {
+	if len(recipient.Ciphertext) != 0 {
+		return nil, errors.New("direct: ciphertext must be empty")
+	}
+	if size := alg.keySize(); size > 0 && len(dk.key) != size {
+		return nil, ErrDecryption
+	}
+	return dk.key, nil
+}
+
+// generateContentKey returns a random content key for alg.
+func generateContentKey(rand io.Reader, alg Algorithm) ([]byte, error) {
+	size := alg.keySize()
+	if size == 0 {
+		return nil, fmt.Errorf("%v: unknown content key size", alg)
+	}
+	cek := make([]byte, size)
+	if _, err := io.ReadFull(rand, cek); err != nil {
+		return nil, err
+	}
+	return cek, nil
+}
+
+// recipient represents a COSE_recipient CBOR object:
+//
+//	COSE_recipient = [
+//	    Headers,
+//	    ciphertext : bstr / nil,
+//	    ? recipients : [+COSE_recipient]
+//	]
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.1
+type recipient struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   cbor.RawMessage
+	Unprotected cbor.RawMessage
+	Ciphertext  byteString
+}
+
+// recipientWithRecipients represents a COSE_recipient CBOR object with
+// nested recipients.
+type recipientWithRecipients struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   cbor.RawMessage
+	Unprotected cbor.RawMessage
+	Ciphertext  byteString
+	Recipients  []cbor.RawMessage
+}
+
+// recipientPrefix represents the fixed prefix of COSE_recipient without
+// nested recipients.
+var recipientPrefix = []byte{
+	0x83, // Array of length 3
+}
+
+// recipientWithRecipientsPrefix represents the fixed prefix of
+// COSE_recipient with nested recipients.
+var recipientWithRecipientsPrefix = []byte{
+	0x84, // Array of length 4
+}
+
+// Recipient represents a decoded COSE_recipient.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-5.1
+type Recipient struct {
+	Headers    Headers
+	Ciphertext []byte
+	Recipients []Recipient
+}
+
+// NewRecipient returns a Recipient with header initialized.
+func NewRecipient() *Recipient {
+	return &Recipient{
+		Headers: Headers{
+			Protected:   ProtectedHeader{},
+			Unprotected: UnprotectedHeader{},
+		},
+	}
+}
+
+// MarshalCBOR encodes Recipient into a COSE_recipient object.
+func (r *Recipient) MarshalCBOR() ([]byte, error) {
+	if r == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil Recipient pointer")
+	}
+	protected, unprotected, err := r.Headers.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Recipients) == 0 {
+		return encMode.Marshal(recipient{
+			Protected:   protected,
+			Unprotected: unprotected,
+			Ciphertext:  r.Ciphertext,
+		})
+	}
+	recipients, err := marshalRecipients(r.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(recipientWithRecipients{
+		Protected:   protected,
+		Unprotected: unprotected,
+		Ciphertext:  r.Ciphertext,
+		Recipients:  recipients,
+	})
+}
+
+// UnmarshalCBOR decodes a COSE_recipient object into Recipient.
+func (r *Recipient) UnmarshalCBOR(data []byte) error {
+	if r == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil Recipient pointer")
+	}
+
+	// decode to recipient and parse
+	var rcpt Recipient
+	switch {
+	case bytes.HasPrefix(data, recipientPrefix):
+		var raw recipient
+		if err := decModeWithTagsForbidden.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		rcpt = Recipient{
+			Headers: Headers{
+				RawProtected:   raw.Protected,
+				RawUnprotected: raw.Unprotected,
+			},
+			Ciphertext: raw.Ciphertext,
+		}
+	case bytes.HasPrefix(data, recipientWithRecipientsPrefix):
+		var raw recipientWithRecipients
+		if err := decModeWithTagsForbidden.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		recipients, err := unmarshalRecipients(raw.Recipients)
+		if err != nil {
+			return err
+		}
+		rcpt = Recipient{
+			Headers: Headers{
+				RawProtected:   raw.Protected,
+				RawUnprotected: raw.Unprotected,
+			},
+			Ciphertext: raw.Ciphertext,
+			Recipients: recipients,
+		}
+	default:
+		return errors.New("cbor: invalid Recipient object")
+	}
+	if err := rcpt.Headers.UnmarshalFromRaw(); err != nil {
+		return err
+	}
+
+	*r = rcpt
+	return nil
+}
+
+// encryptKey distributes the content key to the recipient.
+// The `alg` header is set to the algorithm of the encrypter in the
+// unprotected header if it is not present.
+func (r *Recipient) encryptKey(rand io.Reader, encrypter KeyEncrypter, alg Algorithm, cek []byte) ([]byte, error) {
+	if r == nil {
+		return nil, errors.New("encrypting key for nil Recipient")
+	}
+	if err := r.Headers.ensureRecipientAlgorithm(encrypter.Algorithm()); err != nil {
+		return nil, err
+	}
+	return encrypter.EncryptKey(rand, r, alg, cek)
+}
+
+// decryptKey recovers the content key from the recipient.
+func (r *Recipient) decryptKey(decrypter KeyDecrypter, alg Algorithm) ([]byte, error) {
+	if r == nil {
+		return nil, errors.New("decrypting key for nil Recipient")
+	}
+	candidate, err := r.Headers.algorithm()
+	if err != nil {
+		return nil, err
+	}
+	if candidate != decrypter.Algorithm() {
+		return nil, fmt.Errorf("%w: decrypter %v: header %v", ErrAlgorithmMismatch, decrypter.Algorithm(), candidate)
+	}
+	return decrypter.DecryptKey(r, alg)
+}
+
+// distributeKey distributes a content key for alg to the recipients, and
+// returns the content key.
+// If there are multiple recipients, a random content key is generated using
+// entropy from rand, and direct key management algorithms are rejected since
+// their keys must not be shared. Otherwise, the content key is chosen by the
+// encrypter of the single recipient.
+func distributeKey(rand io.Reader, recipients []Recipient, encrypters []KeyEncrypter, alg Algorithm) ([]byte, error) {
+	switch len(recipients) {
+	case 0:
+		return nil, ErrNoRecipients
+	case len(encrypters):
+		// no ops
+	default:
+		return nil, fmt.Errorf("%d encrypters for %d recipients", len(encrypters), len(recipients))
+	}
+	if len(recipients) == 1 {
+		return recipients[0].encryptKey(rand, encrypters[0], alg, nil)
+	}
+	for _, encrypter := range encrypters {
+		if keyAlg := encrypter.Algorithm(); isDirectKeyAlgorithm(keyAlg) {
+			return nil, fmt.Errorf("%v: content key must not be shared with other recipients", keyAlg)
+		}
+	}
+	cek, err := generateContentKey(rand, alg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range recipients {
+		if _, err := recipients[i].encryptKey(rand, encrypters[i], alg, cek); err != nil {
+			return nil, err
+		}
+	}
+	return cek, nil
+}
+
+// isDirectKeyAlgorithm reports whether the key management algorithm alg uses
+// the shared or derived key as the content key.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-8.5
+func isDirectKeyAlgorithm(alg Algorithm) bool {
+	if alg == AlgorithmDirect {
+		return true
+	}
+	_, keyWrap, err := ecdhParams(alg)
+	return err == nil && keyWrap == 0
+}
+
+// recoverKey recovers the content key for alg from the first recipient
+// accepting the decrypter.
+// If kid is not nil, only the recipients with a matching `kid` header are
+// tried.
+//
+// If a recipient has nested recipients, the key of that recipient layer is
+// recovered from the nested recipients first, and then used to recover the
+// content key.
+func recoverKey(recipients []Recipient, kid []byte, decrypter KeyDecrypter, alg Algorithm) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	var lastErr error
+	for i := range recipients {
+		r := &recipients[i]
+		candidate, err := r.Headers.algorithm()
+		if err != nil {
+			continue
+		}
+		var cek []byte
+		switch {
+		case candidate == decrypter.Algorithm():
+			if !r.matchKeyID(kid) {
+				continue
+			}
+			cek, err = r.decryptKey(decrypter, alg)
+		case len(r.Recipients) > 0:
+			cek, err = r.recoverNestedKey(kid, decrypter, candidate, alg)
+			if errors.Is(err, ErrAlgorithmNotFound) {
+				continue
+			}
+		default:
+			continue
+		}
+		if err == nil {
+			return cek, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		return nil, fmt.Errorf("%w: no recipient for %v", ErrAlgorithmNotFound, decrypter.Algorithm())
+	}
+	return nil, lastErr
+}
+
+// recoverNestedKey recovers the key of the recipient layer using the key
+// management algorithm keyAlg from the nested recipients, and then recovers
+// the content key for alg with it.
+func (r *Recipient) recoverNestedKey(kid []byte, decrypter KeyDecrypter, keyAlg, alg Algorithm) ([]byte, error) {
+	key, err := recoverKey(r.Recipients, kid, decrypter, keyAlg)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := NewKeyDecrypter(keyAlg, key)
+	if err != nil {
+		return nil, err
+	}
+	return r.decryptKey(layer, alg)
+}
+
+// matchKeyID reports whether the `kid` header of the recipient matches kid.
+// A nil kid matches any recipient.
+func (r *Recipient) matchKeyID(kid []byte) bool {
+	if kid == nil {
+		return true
+	}
+	candidate, err := r.Headers.headerBytes(HeaderLabelKeyID)
+	if err != nil {
+		return false
+	}
+	return candidate != nil && bytes.Equal(candidate, kid)
+}
+
+// marshalRecipients encodes recipients into COSE_recipient objects.
+func marshalRecipients(recipients []Recipient) ([]cbor.RawMessage, error) {
+	encoded := make([]cbor.RawMessage, 0, len(recipients))
+	for i := range recipients {
+		data, err := recipients[i].MarshalCBOR()
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, data)
+	}
+	return encoded, nil
+}
+
+// unmarshalRecipients decodes COSE_recipient objects into recipients.
+func unmarshalRecipients(encoded []cbor.RawMessage) ([]Recipient, error) {
+	if len(encoded) == 0 {
+		return nil, ErrNoRecipients
+	}
+	recipients := make([]Recipient, len(encoded))
+	for i, data := range encoded {
+		if err := recipients[i].UnmarshalCBOR(data); err != nil {
+			return nil, err
+		}
+	}
+	return recipients, nil
+}
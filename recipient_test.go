@@ -0,0 +1,357 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRecipient_MarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       *Recipient
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "direct recipient",
+			r: &Recipient{
+				Headers: Headers{
+					Unprotected: UnprotectedHeader{
+						HeaderLabelAlgorithm: AlgorithmDirect,
+					},
+				},
+				Ciphertext: []byte{},
+			},
+			want: []byte{
+				0x83,             // array of length 3
+				0x40,             // protected
+				0xa1, 0x01, 0x25, // unprotected
+				0x40, // ciphertext
+			},
+		},
+		{
+			name: "nil ciphertext",
+			r: &Recipient{
+				Headers: Headers{
+					Unprotected: UnprotectedHeader{
+						HeaderLabelAlgorithm: AlgorithmDirect,
+					},
+				},
+			},
+			want: []byte{
+				0x83,             // array of length 3
+				0x40,             // protected
+				0xa1, 0x01, 0x25, // unprotected
+				0xf6, // ciphertext
+			},
+		},
+		{
+			name: "nested recipients",
+			r: &Recipient{
+				Headers: Headers{
+					Unprotected: UnprotectedHeader{
+						HeaderLabelAlgorithm: AlgorithmA128KW,
+					},
+				},
+				Ciphertext: []byte("foo"),
+				Recipients: []Recipient{
+					{
+						Headers: Headers{
+							Unprotected: UnprotectedHeader{
+								HeaderLabelAlgorithm: AlgorithmDirect,
+							},
+						},
+						Ciphertext: []byte{},
+					},
+				},
+			},
+			want: []byte{
+				0x84,             // array of length 4
+				0x40,             // protected
+				0xa1, 0x01, 0x22, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+				0x81,             // recipients
+				0x83,             // array of length 3
+				0x40,             // protected
+				0xa1, 0x01, 0x25, // unprotected
+				0x40, // ciphertext
+			},
+		},
+		{
+			name:    "nil recipient",
+			r:       nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid header",
+			r: &Recipient{
+				Headers: Headers{
+					Unprotected: UnprotectedHeader{
+						HeaderLabelKeyID: 42,
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.MarshalCBOR()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Recipient.MarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Recipient.MarshalCBOR() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecipient_UnmarshalCBOR(t *testing.T) {
+	// test nil pointer
+	t.Run("nil Recipient pointer", func(t *testing.T) {
+		var r *Recipient
+		data := []byte{0x83, 0x40, 0xa0, 0x40}
+		if err := r.UnmarshalCBOR(data); err == nil {
+			t.Errorf("want error on nil *Recipient")
+		}
+	})
+
+	// test others
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Recipient
+		wantErr bool
+	}{
+		{
+			name: "direct recipient",
+			data: []byte{
+				0x83,             // array of length 3
+				0x40,             // protected
+				0xa1, 0x01, 0x25, // unprotected
+				0x40, // ciphertext
+			},
+			want: Recipient{
+				Headers: Headers{
+					RawProtected: []byte{0x40},
+					Protected:    ProtectedHeader{},
+					RawUnprotected: []byte{
+						0xa1, 0x01, 0x25,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelAlgorithm: int64(AlgorithmDirect),
+					},
+				},
+				Ciphertext: []byte{},
+			},
+		},
+		{
+			name: "nested recipients",
+			data: []byte{
+				0x84,             // array of length 4
+				0x40,             // protected
+				0xa1, 0x01, 0x22, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // ciphertext
+				0x81,             // recipients
+				0x83,             // array of length 3
+				0x40,             // protected
+				0xa1, 0x01, 0x25, // unprotected
+				0x40, // ciphertext
+			},
+			want: Recipient{
+				Headers: Headers{
+					RawProtected:   []byte{0x40},
+					Protected:      ProtectedHeader{},
+					RawUnprotected: []byte{0xa1, 0x01, 0x22},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelAlgorithm: int64(AlgorithmA128KW),
+					},
+				},
+				Ciphertext: []byte("foo"),
+				Recipients: []Recipient{
+					{
+						Headers: Headers{
+							RawProtected:   []byte{0x40},
+							Protected:      ProtectedHeader{},
+							RawUnprotected: []byte{0xa1, 0x01, 0x25},
+							Unprotected: UnprotectedHeader{
+								HeaderLabelAlgorithm: int64(AlgorithmDirect),
+							},
+						},
+						Ciphertext: []byte{},
+					},
+				},
+			},
+		},
+		{
+			name: "empty nested recipients",
+			data: []byte{
+				0x84, // array of length 4
+				0x40, // protected
+				0xa0, // unprotected
+				0x40, // ciphertext
+				0x80, // recipients
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid array length",
+			data: []byte{
+				0x82, // array of length 2
+				0x40, // protected
+				0xa0, // unprotected
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ciphertext",
+			data: []byte{
+				0x83, // array of length 3
+				0x40, // protected
+				0xa0, // unprotected
+				0x80, // ciphertext
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid unprotected header",
+			data: []byte{
+				0x83, // array of length 3
+				0x40, // protected
+				0x40, // unprotected
+				0x40, // ciphertext
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Recipient
+			if err := got.UnmarshalCBOR(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("Recipient.UnmarshalCBOR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Recipient.UnmarshalCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKeyEncrypter(t *testing.T) {
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		key     []byte
+		want    KeyEncrypter
+		wantErr bool
+	}{
+		{
+			name: "direct",
+			alg:  AlgorithmDirect,
+			key:  []byte("secret"),
+			want: &directKey{
+				key: []byte("secret"),
+			},
+		},
+		{
+			name:    "direct with empty key",
+			alg:     AlgorithmDirect,
+			wantErr: true,
+		},
+		{
+			name: "A128KW",
+			alg:  AlgorithmA128KW,
+			key:  []byte("0123456789abcdef"),
+			want: &aesKeyWrapper{
+				alg: AlgorithmA128KW,
+				key: []byte("0123456789abcdef"),
+			},
+		},
+		{
+			name:    "A256KW with short key",
+			alg:     AlgorithmA256KW,
+			key:     []byte("0123456789abcdef"),
+			wantErr: true,
+		},
+		{
+			name:    "unknown algorithm",
+			alg:     AlgorithmES256,
+			key:     []byte("secret"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewKeyEncrypter(tt.alg, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeyEncrypter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewKeyEncrypter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_directKey(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read() error = %v", err)
+	}
+	encrypter, err := NewKeyEncrypter(AlgorithmDirect, key)
+	if err != nil {
+		t.Fatalf("NewKeyEncrypter() error = %v", err)
+	}
+	decrypter, err := NewKeyDecrypter(AlgorithmDirect, key)
+	if err != nil {
+		t.Fatalf("NewKeyDecrypter() error = %v", err)
+	}
+
+	// content key is the shared secret
+	recipient := NewRecipient()
+	cek, err := encrypter.EncryptKey(rand.Reader, recipient, AlgorithmHMAC256_256, nil)
+	if err != nil {
+		t.Fatalf("EncryptKey() error = %v", err)
+	}
+	if !bytes.Equal(cek, key) {
+		t.Fatalf("EncryptKey() = %x, want %x", cek, key)
+	}
+	if recipient.Ciphertext == nil || len(recipient.Ciphertext) != 0 {
+		t.Fatalf("EncryptKey() ciphertext = %v, want empty", recipient.Ciphertext)
+	}
+	got, err := decrypter.DecryptKey(recipient, AlgorithmHMAC256_256)
+	if err != nil {
+		t.Fatalf("DecryptKey() error = %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Fatalf("DecryptKey() = %x, want %x", got, key)
+	}
+
+	// content key shared with other recipients
+	if _, err := encrypter.EncryptKey(rand.Reader, NewRecipient(), AlgorithmHMAC256_256, key); err == nil {
+		t.Fatalf("EncryptKey() error = nil, wantErr true")
+	}
+
+	// content key size mismatch
+	if _, err := encrypter.EncryptKey(rand.Reader, NewRecipient(), AlgorithmHMAC512_512, nil); err == nil {
+		t.Fatalf("EncryptKey() error = nil, wantErr true")
+	}
+	if _, err := decrypter.DecryptKey(recipient, AlgorithmHMAC512_512); err != ErrDecryption {
+		t.Fatalf("DecryptKey() error = %v, wantErr %v", err, ErrDecryption)
+	}
+
+	// ciphertext must be empty
+	recipient.Ciphertext = []byte("foo")
+	if _, err := decrypter.DecryptKey(recipient, AlgorithmHMAC256_256); err == nil {
+		t.Fatalf("DecryptKey() error = nil, wantErr true")
+	}
+}
@@ -0,0 +1,130 @@
+package cose
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+)
+
+// VerifierResolver resolves the Verifier of a signature from the headers of
+// the signature, typically using the kid or the x5chain header parameters.
+type VerifierResolver interface {
+	// ResolveVerifier returns the Verifier for the signature with the given
+	// headers.
+	ResolveVerifier(headers *Headers) (Verifier, error)
+}
+
+// VerifierResolverFunc is an adapter to allow the use of ordinary functions as
+// VerifierResolver.
+type VerifierResolverFunc func(headers *Headers) (Verifier, error)
+
+// ResolveVerifier calls f(headers).
+func (f VerifierResolverFunc) ResolveVerifier(headers *Headers) (Verifier, error) {
+	return f(headers)
+}
+
+// KeyIDResolver is a VerifierResolver mapping key identifiers to public keys.
+// The Verifier is created for the public key identified by the kid header
+// parameter, and for the algorithm of the protected header.
+type KeyIDResolver map[string]crypto.PublicKey
+
+// ResolveVerifier returns the Verifier for the public key identified by the
+// kid header parameter.
+func (r KeyIDResolver) ResolveVerifier(headers *Headers) (Verifier, error) {
+	kid, err := resolveKeyID(headers)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := r[string(kid)]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %x", ErrKeyNotFound, kid)
+	}
+	alg, err := headers.Protected.Algorithm()
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(alg, key)
+}
+
+// bindsKeyID marks KeyIDResolver as resolving verifiers by key identifier.
+func (r KeyIDResolver) bindsKeyID() {}
+
+// KeyIDVerifiers is a VerifierResolver mapping key identifiers to verifiers.
+// The Verifier is the one registered for the kid header parameter.
+type KeyIDVerifiers map[string]Verifier
+
+// ResolveVerifier returns the Verifier registered for the kid header parameter.
+func (v KeyIDVerifiers) ResolveVerifier(headers *Headers) (Verifier, error) {
+	kid, err := resolveKeyID(headers)
+	if err != nil {
+		return nil, err
+	}
+	verifier, ok := v[string(kid)]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %x", ErrKeyNotFound, kid)
+	}
+	return verifier, nil
+}
+
+// bindsKeyID marks KeyIDVerifiers as resolving verifiers by key identifier.
+func (v KeyIDVerifiers) bindsKeyID() {}
+
+// ResolveVerifier returns the Verifier of the key identified by the kid header
+// parameter.
+// If the key does not specify its algorithm, the algorithm of the protected
+// header is used.
+func (ks KeySet) ResolveVerifier(headers *Headers) (Verifier, error) {
+	kid, err := resolveKeyID(headers)
+	if err != nil {
+		return nil, err
+	}
+	key := ks.Lookup(kid)
+	if key == nil {
+		return nil, fmt.Errorf("%w: kid %x", ErrKeyNotFound, kid)
+	}
+	if key.Algorithm == 0 {
+		if alg, err := headers.Protected.Algorithm(); err == nil {
+			k := *key
+			k.Algorithm = alg
+			key = &k
+		}
+	}
+	return key.Verifier()
+}
+
+// bindsKeyID marks KeySet as resolving verifiers by key identifier.
+func (ks KeySet) bindsKeyID() {}
+
+// CertificateChainResolver is a VerifierResolver validating the certificate
+// chain of the x5chain header parameter with Options.
+// See NewCertificateChainVerifier.
+type CertificateChainResolver struct {
+	// Options are the options used to verify the certificate chain.
+	Options x509.VerifyOptions
+}
+
+// ResolveVerifier returns the Verifier for the public key of the end-entity
+// certificate of the validated certificate chain.
+func (r *CertificateChainResolver) ResolveVerifier(headers *Headers) (Verifier, error) {
+	return NewCertificateChainVerifier(headers, r.Options)
+}
+
+// keyIDBinder is implemented by the resolvers binding the kid header parameter
+// to the resolved Verifier, such that a verified signature is made by the key
+// registered for its kid.
+type keyIDBinder interface {
+	bindsKeyID()
+}
+
+// resolveKeyID gets the kid header parameter, which is required for resolving
+// keys by their identifier.
+func resolveKeyID(headers *Headers) ([]byte, error) {
+	kid, err := headers.KeyID()
+	if err != nil {
+		return nil, err
+	}
+	if kid == nil {
+		return nil, fmt.Errorf("%w: missing kid", ErrKeyNotFound)
+	}
+	return kid, nil
+}
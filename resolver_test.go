@@ -0,0 +1,308 @@
+package cose
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+)
+
+func TestKeyIDResolver_ResolveVerifier(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	resolver := KeyIDResolver{
+		"1": key.Public(),
+	}
+	tests := []struct {
+		name    string
+		headers Headers
+		wantErr error
+	}{
+		{
+			name: "protected kid",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: AlgorithmES256,
+					HeaderLabelKeyID:     []byte("1"),
+				},
+			},
+		},
+		{
+			name: "unprotected kid",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: AlgorithmES256,
+				},
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("1"),
+				},
+			},
+		},
+		{
+			name: "unknown kid",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: AlgorithmES256,
+					HeaderLabelKeyID:     []byte("2"),
+				},
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "missing kid",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: AlgorithmES256,
+				},
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "invalid kid",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: AlgorithmES256,
+					HeaderLabelKeyID:     "1",
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+		{
+			name: "missing algorithm",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelKeyID: []byte("1"),
+				},
+			},
+			wantErr: ErrAlgorithmNotFound,
+		},
+		{
+			name: "algorithm mismatch",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: AlgorithmPS256,
+					HeaderLabelKeyID:     []byte("1"),
+				},
+			},
+			wantErr: ErrAlgorithmMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.ResolveVerifier(&tt.headers)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("KeyIDResolver.ResolveVerifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.Algorithm() != AlgorithmES256 {
+				t.Errorf("Verifier.Algorithm() = %v, want %v", got.Algorithm(), AlgorithmES256)
+			}
+		})
+	}
+}
+
+func TestKeyIDVerifiers_ResolveVerifier(t *testing.T) {
+	verifier, err := NewVerifier(AlgorithmES256, generateTestECDSAKey(t).Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	resolver := KeyIDVerifiers{
+		"1": verifier,
+	}
+	tests := []struct {
+		name    string
+		headers Headers
+		wantErr error
+	}{
+		{
+			name: "protected kid",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelKeyID: []byte("1"),
+				},
+			},
+		},
+		{
+			name: "unprotected kid",
+			headers: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("1"),
+				},
+			},
+		},
+		{
+			name: "unknown kid",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelKeyID: []byte("2"),
+				},
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:    "missing kid",
+			headers: Headers{},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "invalid kid",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelKeyID: "1",
+				},
+			},
+			wantErr: ErrInvalidHeaderValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.ResolveVerifier(&tt.headers)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("KeyIDVerifiers.ResolveVerifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got != verifier {
+				t.Errorf("KeyIDVerifiers.ResolveVerifier() = %v, want %v", got, verifier)
+			}
+		})
+	}
+}
+
+func TestKeySet_ResolveVerifier(t *testing.T) {
+	ecKey, err := NewKeyFromPublic(generateTestECDSAKey(t).Public())
+	if err != nil {
+		t.Fatalf("NewKeyFromPublic() error = %v", err)
+	}
+	ecKey.ID = []byte("ec")
+	rsaKey, err := NewKeyFromPublic(generateTestRSAKey(t).Public())
+	if err != nil {
+		t.Fatalf("NewKeyFromPublic() error = %v", err)
+	}
+	rsaKey.ID = []byte("rsa")
+	keySet := KeySet{*ecKey, *rsaKey}
+
+	tests := []struct {
+		name    string
+		headers Headers
+		want    Algorithm
+		wantErr error
+	}{
+		{
+			name: "algorithm derived from the key",
+			headers: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("ec"),
+				},
+			},
+			want: AlgorithmES256,
+		},
+		{
+			name: "algorithm from the header",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: AlgorithmPS384,
+				},
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("rsa"),
+				},
+			},
+			want: AlgorithmPS384,
+		},
+		{
+			name: "missing algorithm",
+			headers: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("rsa"),
+				},
+			},
+			wantErr: ErrAlgorithmNotFound,
+		},
+		{
+			name: "algorithm mismatch",
+			headers: Headers{
+				Protected: ProtectedHeader{
+					HeaderLabelAlgorithm: AlgorithmES384,
+				},
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("ec"),
+				},
+			},
+			wantErr: ErrAlgorithmMismatch,
+		},
+		{
+			name: "unknown kid",
+			headers: Headers{
+				Unprotected: UnprotectedHeader{
+					HeaderLabelKeyID: []byte("foo"),
+				},
+			},
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:    "missing kid",
+			headers: Headers{},
+			wantErr: ErrKeyNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keySet.ResolveVerifier(&tt.headers)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("KeySet.ResolveVerifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.Algorithm() != tt.want {
+				t.Errorf("Verifier.Algorithm() = %v, want %v", got.Algorithm(), tt.want)
+			}
+		})
+	}
+	if rsaKey.Algorithm != 0 {
+		t.Errorf("KeySet.ResolveVerifier() modified the key algorithm to %v", rsaKey.Algorithm)
+	}
+}
+
+func TestCertificateChainResolver_ResolveVerifier(t *testing.T) {
+	root := newTestCertificate(t, "root", true, nil)
+	leaf := newTestCertificate(t, "leaf", false, root)
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	resolver := &CertificateChainResolver{
+		Options: x509.VerifyOptions{
+			Roots:     roots,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		},
+	}
+
+	var headers Headers
+	headers.Protected = ProtectedHeader{
+		HeaderLabelAlgorithm: AlgorithmES256,
+	}
+	headers.SetCertificateChain([]*x509.Certificate{leaf.cert})
+	got, err := resolver.ResolveVerifier(&headers)
+	if err != nil {
+		t.Fatalf("CertificateChainResolver.ResolveVerifier() error = %v", err)
+	}
+	if got.Algorithm() != AlgorithmES256 {
+		t.Errorf("Verifier.Algorithm() = %v, want %v", got.Algorithm(), AlgorithmES256)
+	}
+
+	// untrusted certificate
+	other := newTestCertificate(t, "other", false, nil)
+	headers.SetCertificateChain([]*x509.Certificate{other.cert})
+	if _, err := resolver.ResolveVerifier(&headers); err == nil {
+		t.Error("CertificateChainResolver.ResolveVerifier() error = nil, wantErr true")
+	}
+}
+
+func TestVerifierResolverFunc_ResolveVerifier(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	want, err := NewVerifier(AlgorithmES256, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	resolver := VerifierResolverFunc(func(headers *Headers) (Verifier, error) {
+		return want, nil
+	})
+	got, err := resolver.ResolveVerifier(&Headers{})
+	if err != nil {
+		t.Fatalf("VerifierResolverFunc.ResolveVerifier() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("VerifierResolverFunc.ResolveVerifier() = %v, want %v", got, want)
+	}
+}
@@ -3,9 +3,44 @@ package cose
 import (
 	"crypto"
 	"crypto/rsa"
+	"errors"
 	"io"
 )
 
+// checkRSAKey ensures key is a RSA public key suitable for RSASSA-PSS.
+func checkRSAKey(key crypto.PublicKey) error {
+	vk, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return ErrAlgorithmMismatch
+	}
+	// RFC 8230 6.1 requires RSA keys having a minimum size of 2048 bits.
+	// Reference: https://www.rfc-editor.org/rfc/rfc8230.html#section-6.1
+	if vk.N.BitLen() < 2048 {
+		return errors.New("RSA key must be at least 2048 bits long")
+	}
+	return nil
+}
+
+// newRSASigner returns a RSASSA-PSS signer with a checked key.
+func newRSASigner(alg Algorithm, key crypto.Signer) (Signer, error) {
+	return &rsaSigner{
+		alg: alg,
+		key: key,
+	}, nil
+}
+
+// newRSAVerifier returns a RSASSA-PSS verifier with a checked key.
+func newRSAVerifier(alg Algorithm, key crypto.PublicKey) (Verifier, error) {
+	vk, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrAlgorithmMismatch
+	}
+	return &rsaVerifier{
+		alg: alg,
+		key: vk,
+	}, nil
+}
+
 // rsaSigner is a RSASSA-PSS based signer with a generic crypto.Signer.
 //
 // Reference: https://www.rfc-editor.org/rfc/rfc8230.html#section-2
@@ -24,14 +59,22 @@ func (rs *rsaSigner) Algorithm() Algorithm {
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8
 func (rs *rsaSigner) Sign(rand io.Reader, content []byte) ([]byte, error) {
-	hash := rs.alg.hashFunc()
-	digest, err := computeHash(hash, content)
+	digest, err := rs.alg.computeHash(content)
 	if err != nil {
 		return nil, err
 	}
+	return rs.SignDigest(rand, digest)
+}
+
+// SignDigest signs message digest with the private key, using entropy from
+// rand.
+// The resulting signature should follow RFC 8152 section 8.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8
+func (rs *rsaSigner) SignDigest(rand io.Reader, digest []byte) ([]byte, error) {
 	return rs.key.Sign(rand, digest, &rsa.PSSOptions{
 		SaltLength: rsa.PSSSaltLengthEqualsHash, // defined in RFC 8230 sec 2
-		Hash:       hash,
+		Hash:       rs.alg.hashFunc(),
 	})
 }
 
@@ -48,18 +91,31 @@ func (rv *rsaVerifier) Algorithm() Algorithm {
 	return rv.alg
 }
 
+// PublicKey returns the public key of the verifier.
+func (rv *rsaVerifier) PublicKey() crypto.PublicKey {
+	return rv.key
+}
+
 // Verify verifies message content with the public key, returning nil for
 // success.
 // Otherwise, it returns ErrVerification.
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8
 func (rv *rsaVerifier) Verify(content []byte, signature []byte) error {
-	hash := rv.alg.hashFunc()
-	digest, err := computeHash(hash, content)
+	digest, err := rv.alg.computeHash(content)
 	if err != nil {
 		return err
 	}
-	if err := rsa.VerifyPSS(rv.key, hash, digest, signature, &rsa.PSSOptions{
+	return rv.VerifyDigest(digest, signature)
+}
+
+// VerifyDigest verifies message digest with the public key, returning nil for
+// success.
+// Otherwise, it returns ErrVerification.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8
+func (rv *rsaVerifier) VerifyDigest(digest []byte, signature []byte) error {
+	if err := rsa.VerifyPSS(rv.key, rv.alg.hashFunc(), digest, signature, &rsa.PSSOptions{
 		SaltLength: rsa.PSSSaltLengthEqualsHash, // defined in RFC 8230 sec 2
 	}); err != nil {
 		return ErrVerification
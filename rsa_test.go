@@ -49,6 +49,25 @@ func Test_rsaSigner(t *testing.T) {
 	if err := verifier.Verify(content, sig); err != nil {
 		t.Fatalf("Verifier.Verify() error = %v", err)
 	}
+
+	// sign / verify digest round trip
+	digest, err := alg.computeHash(content)
+	if err != nil {
+		t.Fatalf("computeHash() error = %v", err)
+	}
+	sig, err = signer.(DigestSigner).SignDigest(rand.Reader, digest)
+	if err != nil {
+		t.Fatalf("SignDigest() error = %v", err)
+	}
+	if err := verifier.Verify(content, sig); err != nil {
+		t.Fatalf("Verifier.Verify() error = %v", err)
+	}
+	if err := verifier.(DigestVerifier).VerifyDigest(digest, sig); err != nil {
+		t.Fatalf("Verifier.VerifyDigest() error = %v", err)
+	}
+	if err := verifier.(DigestVerifier).VerifyDigest(content, sig); err != ErrVerification {
+		t.Fatalf("Verifier.VerifyDigest() error = %v, wantErr %v", err, ErrVerification)
+	}
 }
 
 func Test_rsaSigner_SignHashFailure(t *testing.T) {
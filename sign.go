@@ -92,7 +92,11 @@ func (s *Signature) UnmarshalCBOR(data []byte) error {
 	if s == nil {
 		return errors.New("cbor: UnmarshalCBOR on nil Signature pointer")
 	}
+	return s.unmarshal(defaultDecoder, data)
+}
 
+// unmarshal decodes a COSE_Signature object into Signature using the decoder.
+func (s *Signature) unmarshal(d *decoder, data []byte) error {
 	// fast signature check
 	if !bytes.HasPrefix(data, signaturePrefix) {
 		return errors.New("cbor: invalid Signature object")
@@ -100,7 +104,7 @@ func (s *Signature) UnmarshalCBOR(data []byte) error {
 
 	// decode to signature and parse
 	var raw signature
-	if err := decModeWithTagsForbidden.Unmarshal(data, &raw); err != nil {
+	if err := d.modeWithTagsForbidden.Unmarshal(data, &raw); err != nil {
 		return err
 	}
 	if len(raw.Signature) == 0 {
@@ -113,7 +117,7 @@ func (s *Signature) UnmarshalCBOR(data []byte) error {
 		},
 		Signature: raw.Signature,
 	}
-	if err := sig.Headers.UnmarshalFromRaw(); err != nil {
+	if err := sig.Headers.unmarshalFromRaw(d); err != nil {
 		return err
 	}
 
@@ -124,6 +128,8 @@ func (s *Signature) UnmarshalCBOR(data []byte) error {
 // Sign signs a Signature using the provided Signer.
 // Signing a COSE_Signature requires the encoded protected header and the
 // payload of its parent message.
+// If signer implements DigestSigner, the digest of ToBeSigned is signed instead
+// of ToBeSigned itself.
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
 //
@@ -157,7 +163,7 @@ func (s *Signature) Sign(rand io.Reader, signer Signer, protected cbor.RawMessag
 	if err != nil {
 		return err
 	}
-	sig, err := signer.Sign(rand, toBeSigned)
+	sig, err := signContent(rand, signer, toBeSigned)
 	if err != nil {
 		return err
 	}
@@ -170,6 +176,8 @@ func (s *Signature) Sign(rand io.Reader, signer Signer, protected cbor.RawMessag
 // if verification fails.
 // Verifying a COSE_Signature requires the encoded protected header and the
 // payload of its parent message.
+// If verifier implements DigestVerifier, the signature is verified against the
+// digest of ToBeSigned.
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
 //
@@ -204,7 +212,99 @@ func (s *Signature) Verify(verifier Verifier, protected cbor.RawMessage, payload
 	if err != nil {
 		return err
 	}
-	return verifier.Verify(toBeSigned, s.Signature)
+	return verifyContent(verifier, toBeSigned, s.Signature)
+}
+
+// CheckCritical ensures all the labels of the crit header parameter of the
+// Signature are understood, either by this library or by the application as
+// listed in understood.
+// It returns a *CriticalHeaderError listing the labels not understood, in which
+// case the signature must be rejected.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (s *Signature) CheckCritical(understood ...interface{}) error {
+	if s == nil {
+		return errors.New("checking nil Signature")
+	}
+	return s.Headers.Protected.CheckCritical(understood...)
+}
+
+// Countersign signs the Signature with countersig using the provided Signer,
+// and appends countersig to the Countersignature version 2 header parameter of
+// the unprotected header of s.
+// The Signature is required to be signed already. Signing or verifying it is
+// not affected, as the unprotected header is not covered by its signature.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (s *Signature) Countersign(rand io.Reader, external []byte, signer Signer, countersig *CountersignatureV2) error {
+	if s == nil {
+		return errors.New("countersigning nil Signature")
+	}
+	return countersign(rand, &s.Headers, s, external, signer, countersig)
+}
+
+// Countersign0 signs the Signature using the provided Signer, and sets the
+// abbreviated countersignature to the Countersignature0 version 2 header
+// parameter of the unprotected header of s.
+// The algorithm and the key of an abbreviated countersignature are not
+// identified by the message, and must be known from the context.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.2
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (s *Signature) Countersign0(rand io.Reader, external []byte, signer Signer) error {
+	if s == nil {
+		return errors.New("countersigning nil Signature")
+	}
+	return countersign0(rand, &s.Headers, s, external, signer)
+}
+
+// VerifyCountersignatures verifies the countersignatures of the
+// Countersignature version 2 header parameter of s against the
+// corresponding verifier, returning nil on success or a suitable error if
+// verification fails.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (s *Signature) VerifyCountersignatures(external []byte, verifiers ...Verifier) error {
+	if s == nil {
+		return errors.New("verifying nil Signature")
+	}
+	return verifyCountersignatures(&s.Headers, s, external, verifiers)
+}
+
+// VerifyCountersignature0 verifies the abbreviated countersignature of the
+// Countersignature0 version 2 header parameter of s, returning nil on
+// success or a suitable error if verification fails.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.2
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (s *Signature) VerifyCountersignature0(external []byte, verifier Verifier) error {
+	if s == nil {
+		return errors.New("verifying nil Signature")
+	}
+	return verifyCountersignature0(&s.Headers, s, external, verifier)
 }
 
 // toBeSigned constructs Sig_structure, computes and returns ToBeSigned.
@@ -293,6 +393,29 @@ func NewSignMessage() *SignMessage {
 	}
 }
 
+// Kind returns MessageKindSign.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) Kind() MessageKind {
+	return MessageKindSign
+}
+
+// MessageHeaders returns the headers of the message body.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) MessageHeaders() *Headers {
+	if m == nil {
+		return nil
+	}
+	return &m.Headers
+}
+
 // MarshalCBOR encodes SignMessage into a COSE_Sign_Tagged object.
 //
 // # Experimental
@@ -303,27 +426,10 @@ func (m *SignMessage) MarshalCBOR() ([]byte, error) {
 	if m == nil {
 		return nil, errors.New("cbor: MarshalCBOR on nil SignMessage pointer")
 	}
-	if len(m.Signatures) == 0 {
-		return nil, ErrNoSignatures
-	}
-	protected, unprotected, err := m.Headers.marshal()
+	content, err := m.content()
 	if err != nil {
 		return nil, err
 	}
-	signatures := make([]cbor.RawMessage, 0, len(m.Signatures))
-	for _, sig := range m.Signatures {
-		sigCBOR, err := sig.MarshalCBOR()
-		if err != nil {
-			return nil, err
-		}
-		signatures = append(signatures, sigCBOR)
-	}
-	content := signMessage{
-		Protected:   protected,
-		Unprotected: unprotected,
-		Payload:     m.Payload,
-		Signatures:  signatures,
-	}
 	return encMode.Marshal(cbor.Tag{
 		Number:  CBORTagSignMessage,
 		Content: content,
@@ -340,15 +446,71 @@ func (m *SignMessage) UnmarshalCBOR(data []byte) error {
 	if m == nil {
 		return errors.New("cbor: UnmarshalCBOR on nil SignMessage pointer")
 	}
+	return m.unmarshalTagged(defaultDecoder, data)
+}
+
+// UnmarshalCBORWithOptions decodes a COSE_Sign_Tagged object into SignMessage
+// with the limits of opts, in place of those set by SetDecOptions.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) UnmarshalCBORWithOptions(data []byte, opts DecOptions) error {
+	if m == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil SignMessage pointer")
+	}
+	d, err := opts.decoder()
+	if err != nil {
+		return err
+	}
+	return m.unmarshalTagged(d, data)
+}
+
+// unmarshalTagged decodes a COSE_Sign_Tagged object into SignMessage using the
+// decoder.
+func (m *SignMessage) unmarshalTagged(d *decoder, data []byte) error {
+	if err := d.validate(data); err != nil {
+		return err
+	}
 
 	// fast message check
 	if !bytes.HasPrefix(data, signMessagePrefix) {
 		return errors.New("cbor: invalid COSE_Sign_Tagged object")
 	}
+	return m.unmarshal(d, data[2:])
+}
+
+// content returns the COSE_Sign object of the SignMessage.
+func (m *SignMessage) content() (signMessage, error) {
+	if len(m.Signatures) == 0 {
+		return signMessage{}, ErrNoSignatures
+	}
+	protected, unprotected, err := m.Headers.marshal()
+	if err != nil {
+		return signMessage{}, err
+	}
+	signatures := make([]cbor.RawMessage, 0, len(m.Signatures))
+	for _, sig := range m.Signatures {
+		sigCBOR, err := sig.MarshalCBOR()
+		if err != nil {
+			return signMessage{}, err
+		}
+		signatures = append(signatures, sigCBOR)
+	}
+	return signMessage{
+		Protected:   protected,
+		Unprotected: unprotected,
+		Payload:     m.Payload,
+		Signatures:  signatures,
+	}, nil
+}
 
+// unmarshal decodes a COSE_Sign object into SignMessage using the decoder.
+func (m *SignMessage) unmarshal(d *decoder, data []byte) error {
 	// decode to signMessage and parse
 	var raw signMessage
-	if err := decModeWithTagsForbidden.Unmarshal(data[2:], &raw); err != nil {
+	if err := d.modeWithTagsForbidden.Unmarshal(data, &raw); err != nil {
 		return err
 	}
 	if len(raw.Signatures) == 0 {
@@ -357,7 +519,7 @@ func (m *SignMessage) UnmarshalCBOR(data []byte) error {
 	signatures := make([]*Signature, 0, len(raw.Signatures))
 	for _, sigCBOR := range raw.Signatures {
 		sig := &Signature{}
-		if err := sig.UnmarshalCBOR(sigCBOR); err != nil {
+		if err := sig.unmarshal(d, sigCBOR); err != nil {
 			return err
 		}
 		signatures = append(signatures, sig)
@@ -370,7 +532,7 @@ func (m *SignMessage) UnmarshalCBOR(data []byte) error {
 		Payload:    raw.Payload,
 		Signatures: signatures,
 	}
-	if err := msg.Headers.UnmarshalFromRaw(); err != nil {
+	if err := msg.Headers.unmarshalFromRaw(d); err != nil {
 		return err
 	}
 
@@ -396,6 +558,199 @@ func (m *SignMessage) Sign(rand io.Reader, external []byte, signers ...Signer) e
 	if m.Payload == nil {
 		return ErrMissingPayload
 	}
+	return m.sign(rand, m.Payload, external, signers)
+}
+
+// SignDetached signs a SignMessage with a detached payload using the provided
+// signers corresponding to the signatures.
+// The signatures are computed over the detached content, which is not stored
+// in the message. m.Payload must be nil so that the message is encoded with a
+// nil payload.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) SignDetached(rand io.Reader, detached, external []byte, signers ...Signer) error {
+	if m == nil {
+		return errors.New("signing nil SignMessage")
+	}
+	if err := m.checkDetached(detached); err != nil {
+		return err
+	}
+	return m.sign(rand, detached, external, signers)
+}
+
+// AddSignature signs the SignMessage using the provided Signer and appends the
+// new signature with the given headers to m.Signatures.
+// The existing signatures are left untouched, so that signers can co-sign a
+// message one at a time.
+// The signature is computed against the encoded body protected header, which
+// is m.Headers.RawProtected for a decoded message, and must not be changed
+// afterwards.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-4.1
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) AddSignature(rand io.Reader, external []byte, signer Signer, headers Headers) error {
+	if m == nil {
+		return errors.New("signing nil SignMessage")
+	}
+	if m.Payload == nil {
+		return ErrMissingPayload
+	}
+	return m.addSignature(rand, m.Payload, external, signer, headers)
+}
+
+// AddSignatureDetached signs a SignMessage with a detached payload using the
+// provided Signer and appends the new signature with the given headers to
+// m.Signatures, as AddSignature does.
+// The signature is computed over the detached content, which is not stored in
+// the message. m.Payload must be nil.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) AddSignatureDetached(rand io.Reader, detached, external []byte, signer Signer, headers Headers) error {
+	if m == nil {
+		return errors.New("signing nil SignMessage")
+	}
+	if err := m.checkDetached(detached); err != nil {
+		return err
+	}
+	return m.addSignature(rand, detached, external, signer, headers)
+}
+
+// addSignature signs the payload and appends the new signature.
+func (m *SignMessage) addSignature(rand io.Reader, payload, external []byte, signer Signer, headers Headers) error {
+	sig, err := m.newSignature(rand, payload, external, signer, headers)
+	if err != nil {
+		return err
+	}
+	m.Signatures = append(m.Signatures, sig)
+	return nil
+}
+
+// ReplaceSignature signs the SignMessage using the provided Signer, and
+// replaces the signature at index with the new signature with the given
+// headers.
+// The other signatures are left untouched.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) ReplaceSignature(index int, rand io.Reader, external []byte, signer Signer, headers Headers) error {
+	if m == nil {
+		return errors.New("signing nil SignMessage")
+	}
+	if m.Payload == nil {
+		return ErrMissingPayload
+	}
+	return m.replaceSignature(index, rand, m.Payload, external, signer, headers)
+}
+
+// ReplaceSignatureDetached signs a SignMessage with a detached payload using
+// the provided Signer, and replaces the signature at index with the new
+// signature with the given headers, as ReplaceSignature does.
+// The signature is computed over the detached content, which is not stored in
+// the message. m.Payload must be nil.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) ReplaceSignatureDetached(index int, rand io.Reader, detached, external []byte, signer Signer, headers Headers) error {
+	if m == nil {
+		return errors.New("signing nil SignMessage")
+	}
+	if err := m.checkDetached(detached); err != nil {
+		return err
+	}
+	return m.replaceSignature(index, rand, detached, external, signer, headers)
+}
+
+// replaceSignature signs the payload and replaces the signature at index.
+func (m *SignMessage) replaceSignature(index int, rand io.Reader, payload, external []byte, signer Signer, headers Headers) error {
+	if err := m.checkSignatureIndex(index); err != nil {
+		return err
+	}
+	sig, err := m.newSignature(rand, payload, external, signer, headers)
+	if err != nil {
+		return err
+	}
+	m.Signatures[index] = sig
+	return nil
+}
+
+// RemoveSignature removes the signature at index from m.Signatures.
+// The other signatures remain valid, as each signature is computed
+// independently.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) RemoveSignature(index int) error {
+	if m == nil {
+		return errors.New("removing signature from nil SignMessage")
+	}
+	if err := m.checkSignatureIndex(index); err != nil {
+		return err
+	}
+	// copy so that the slices sharing the array are not modified
+	m.Signatures = append(m.Signatures[:index:index], m.Signatures[index+1:]...)
+	return nil
+}
+
+// checkSignatureIndex ensures index refers to a signature of the SignMessage.
+func (m *SignMessage) checkSignatureIndex(index int) error {
+	if index < 0 || index >= len(m.Signatures) {
+		return fmt.Errorf("signature index %d out of range [0, %d)", index, len(m.Signatures))
+	}
+	return nil
+}
+
+// checkDetached ensures the content is detached from the SignMessage.
+func (m *SignMessage) checkDetached(detached []byte) error {
+	if detached == nil {
+		return ErrMissingPayload
+	}
+	if m.Payload != nil {
+		return errors.New("SignMessage has an attached payload")
+	}
+	return nil
+}
+
+// newSignature signs the payload with the given headers against the encoded
+// body protected header.
+func (m *SignMessage) newSignature(rand io.Reader, payload, external []byte, signer Signer, headers Headers) (*Signature, error) {
+	var protected cbor.RawMessage
+	protected, err := m.Headers.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+	sig := &Signature{
+		Headers: headers,
+	}
+	if err := sig.Sign(rand, signer, protected, payload, external); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// sign signs the payload with the signers corresponding to the signatures.
+func (m *SignMessage) sign(rand io.Reader, payload, external []byte, signers []Signer) error {
 	switch len(m.Signatures) {
 	case 0:
 		return ErrNoSignatures
@@ -414,7 +769,7 @@ func (m *SignMessage) Sign(rand io.Reader, external []byte, signers ...Signer) e
 
 	// sign message accordingly
 	for i, signature := range m.Signatures {
-		if err := signature.Sign(rand, signers[i], protected, m.Payload, external); err != nil {
+		if err := signature.Sign(rand, signers[i], protected, payload, external); err != nil {
 			return err
 		}
 	}
@@ -425,8 +780,8 @@ func (m *SignMessage) Sign(rand io.Reader, external []byte, signers ...Signer) e
 // Verify verifies the signatures on the SignMessage against the corresponding
 // verifier, returning nil on success or a suitable error if verification fails.
 //
-// See `Signature.Verify()` for advanced verification scenarios like threshold
-// policies.
+// See `SignMessage.VerifyWithPolicy()` for threshold policies, and
+// `Signature.Verify()` for advanced verification scenarios.
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
 //
@@ -441,6 +796,265 @@ func (m *SignMessage) Verify(external []byte, verifiers ...Verifier) error {
 	if m.Payload == nil {
 		return ErrMissingPayload
 	}
+	return m.verify(m.Payload, external, verifiers)
+}
+
+// CheckCritical ensures all the labels of the crit header parameters of the
+// SignMessage and of its signatures are understood, either by this library or
+// by the application as listed in understood.
+// It returns an error wrapping a *CriticalHeaderError listing the labels not
+// understood, in which case the message must be rejected.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) CheckCritical(understood ...interface{}) error {
+	if m == nil {
+		return errors.New("checking nil SignMessage")
+	}
+	if err := m.Headers.Protected.CheckCritical(understood...); err != nil {
+		return err
+	}
+	for i, sig := range m.Signatures {
+		if err := sig.CheckCritical(understood...); err != nil {
+			return fmt.Errorf("signature %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// VerifyWithOptions verifies the signatures on the SignMessage as Verify does,
+// and enforces the verification policy specified by opts on each signature.
+// The headers of the message body apply to all the signatures, so that a
+// required protected header can be present in either the protected header of
+// the body or of the signature.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) VerifyWithOptions(external []byte, opts VerifyOptions, verifiers ...Verifier) error {
+	if m == nil {
+		return errors.New("verifying nil SignMessage")
+	}
+	if len(m.Signatures) != len(verifiers) {
+		// let Verify report the mismatch
+		return m.Verify(external, verifiers...)
+	}
+	for i, sig := range m.Signatures {
+		if sig == nil {
+			return errors.New("verifying nil Signature")
+		}
+		if err := opts.check(verifiers[i], &m.Headers, &sig.Headers); err != nil {
+			return fmt.Errorf("signature %d: %w", i, err)
+		}
+	}
+	return m.Verify(external, verifiers...)
+}
+
+// VerifyWithResolver verifies the signatures on the SignMessage using the
+// Verifier resolved from the headers of each signature, returning nil on
+// success or a suitable error if verification fails.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) VerifyWithResolver(external []byte, resolver VerifierResolver) error {
+	if m == nil {
+		return errors.New("verifying nil SignMessage")
+	}
+	verifiers := make([]Verifier, len(m.Signatures))
+	for i, sig := range m.Signatures {
+		if sig == nil {
+			return errors.New("verifying nil Signature")
+		}
+		verifier, err := resolver.ResolveVerifier(&sig.Headers)
+		if err != nil {
+			return fmt.Errorf("signature %d: %w", i, err)
+		}
+		verifiers[i] = verifier
+	}
+	return m.Verify(external, verifiers...)
+}
+
+// Countersign signs the SignMessage with countersig using the provided Signer,
+// and appends countersig to the Countersignature version 2 header parameter of
+// the unprotected header of m.
+// The countersignature covers the protected header and the payload of the
+// SignMessage, but not its signatures, which can be countersigned individually
+// with Signature.Countersign().
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (m *SignMessage) Countersign(rand io.Reader, external []byte, signer Signer, countersig *CountersignatureV2) error {
+	if m == nil {
+		return errors.New("countersigning nil SignMessage")
+	}
+	return countersign(rand, &m.Headers, m, external, signer, countersig)
+}
+
+// Countersign0 signs the SignMessage using the provided Signer, and sets the
+// abbreviated countersignature to the Countersignature0 version 2 header
+// parameter of the unprotected header of m.
+// The algorithm and the key of an abbreviated countersignature are not
+// identified by the message, and must be known from the context.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.2
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (m *SignMessage) Countersign0(rand io.Reader, external []byte, signer Signer) error {
+	if m == nil {
+		return errors.New("countersigning nil SignMessage")
+	}
+	return countersign0(rand, &m.Headers, m, external, signer)
+}
+
+// VerifyCountersignatures verifies the countersignatures of the
+// Countersignature version 2 header parameter of m against the
+// corresponding verifier, returning nil on success or a suitable error if
+// verification fails.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (m *SignMessage) VerifyCountersignatures(external []byte, verifiers ...Verifier) error {
+	if m == nil {
+		return errors.New("verifying nil SignMessage")
+	}
+	return verifyCountersignatures(&m.Headers, m, external, verifiers)
+}
+
+// VerifyCountersignature0 verifies the abbreviated countersignature of the
+// Countersignature0 version 2 header parameter of m, returning nil on
+// success or a suitable error if verification fails.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.2
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (m *SignMessage) VerifyCountersignature0(external []byte, verifier Verifier) error {
+	if m == nil {
+		return errors.New("verifying nil SignMessage")
+	}
+	return verifyCountersignature0(&m.Headers, m, external, verifier)
+}
+
+// VerifyWithPolicy verifies the signatures on the SignMessage against a set of
+// verifiers, in any order, and checks the results against the policy.
+// Each signature is verified if any of the verifiers succeeds, and signatures
+// verified by the same verifier are counted as a single signer.
+// Since the verifiers are not bound to key identifiers, a kid header parameter
+// does not identify the signer, and the policy must not require key
+// identifiers. Use VerifyWithPolicyResolver with KeyIDVerifiers instead.
+//
+// The result reports the verification of each signature, and is returned
+// along with the error if the policy is not satisfied.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) VerifyWithPolicy(external []byte, policy SignaturePolicy, verifiers ...Verifier) (*PolicyResult, error) {
+	if m == nil {
+		return nil, errors.New("verifying nil SignMessage")
+	}
+	if len(verifiers) == 0 {
+		return nil, errors.New("no verifiers")
+	}
+	if len(policy.RequiredKeyIDs) > 0 {
+		return nil, errors.New("required key identifiers: verifiers not bound to key identifiers")
+	}
+	return m.verifyWithPolicy(external, policy, func(_ int, sig *Signature, protected cbor.RawMessage, payload, external []byte) ([]byte, interface{}, error) {
+		for i, verifier := range verifiers {
+			if err := sig.Verify(verifier, protected, payload, external); err == nil {
+				return nil, i, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("%w: no verifier succeeded", ErrVerification)
+	})
+}
+
+// VerifyWithPolicyResolver verifies the signatures on the SignMessage using
+// the Verifier resolved from the headers of each signature, and checks the
+// results against the policy.
+// A signature fails if its Verifier cannot be resolved, and signatures of the
+// same public key or, if the Verifier does not provide its public key, of the
+// same key identifier are counted as a single signer.
+// The key identifier of a signature is taken from its kid header parameter.
+// It identifies the signer only if the resolver looks up the key by kid, as
+// KeyIDVerifiers, KeyIDResolver and KeySet do, and the policy must not require
+// key identifiers with any other resolver, such as CertificateChainResolver.
+//
+// The result reports the verification of each signature, and is returned
+// along with the error if the policy is not satisfied.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) VerifyWithPolicyResolver(external []byte, policy SignaturePolicy, resolver VerifierResolver) (*PolicyResult, error) {
+	if m == nil {
+		return nil, errors.New("verifying nil SignMessage")
+	}
+	if _, ok := resolver.(keyIDBinder); !ok && len(policy.RequiredKeyIDs) > 0 {
+		return nil, errors.New("required key identifiers: resolver not bound to key identifiers")
+	}
+	return m.verifyWithPolicy(external, policy, func(index int, sig *Signature, protected cbor.RawMessage, payload, external []byte) ([]byte, interface{}, error) {
+		kid, err := sig.Headers.KeyID()
+		if err != nil {
+			return nil, nil, err
+		}
+		verifier, err := resolver.ResolveVerifier(&sig.Headers)
+		if err != nil {
+			return kid, nil, err
+		}
+		if err := sig.Verify(verifier, protected, payload, external); err != nil {
+			return kid, nil, err
+		}
+		return kid, resolvedSigner(verifier, kid, index), nil
+	})
+}
+
+// VerifyDetached verifies the signatures on the SignMessage with a detached
+// payload against the corresponding verifier, returning nil on success or a
+// suitable error if verification fails.
+// m.Payload must be nil.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *SignMessage) VerifyDetached(detached, external []byte, verifiers ...Verifier) error {
+	if m == nil {
+		return errors.New("verifying nil SignMessage")
+	}
+	if err := m.checkDetached(detached); err != nil {
+		return err
+	}
+	return m.verify(detached, external, verifiers)
+}
+
+// verify verifies the signatures on the payload against the corresponding
+// verifier.
+func (m *SignMessage) verify(payload, external []byte, verifiers []Verifier) error {
 	switch len(m.Signatures) {
 	case 0:
 		return ErrNoSignatures
@@ -459,9 +1073,97 @@ func (m *SignMessage) Verify(external []byte, verifiers ...Verifier) error {
 
 	// verify message accordingly
 	for i, signature := range m.Signatures {
-		if err := signature.Verify(verifiers[i], protected, m.Payload, external); err != nil {
+		if err := signature.Verify(verifiers[i], protected, payload, external); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// untaggedSignMessagePrefix represents the fixed prefix of COSE_Sign.
+var untaggedSignMessagePrefix = []byte{
+	0x84, // Array of length 4
+}
+
+// UntaggedSignMessage represents a decoded COSE_Sign message, which is encoded
+// without the COSE_Sign_Tagged tag as required by protocols where the message
+// type is known from the context.
+//
+// UntaggedSignMessage shares the headers and the signing and verification
+// logic of SignMessage, and can be converted from and to a SignMessage to
+// access its other methods.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-4.1
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+type UntaggedSignMessage SignMessage
+
+// MarshalCBOR encodes UntaggedSignMessage into a COSE_Sign object.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *UntaggedSignMessage) MarshalCBOR() ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil UntaggedSignMessage pointer")
+	}
+	content, err := (*SignMessage)(m).content()
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(content)
+}
+
+// UnmarshalCBOR decodes a COSE_Sign object into UntaggedSignMessage.
+// Tagged messages are rejected.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *UntaggedSignMessage) UnmarshalCBOR(data []byte) error {
+	if m == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil UntaggedSignMessage pointer")
+	}
+
+	if err := defaultDecoder.validate(data); err != nil {
+		return err
+	}
+
+	// fast message check
+	if !bytes.HasPrefix(data, untaggedSignMessagePrefix) {
+		return errors.New("cbor: invalid COSE_Sign object")
+	}
+	return (*SignMessage)(m).unmarshal(defaultDecoder, data)
+}
+
+// Sign signs an UntaggedSignMessage using the provided signers corresponding
+// to the signatures.
+//
+// See `SignMessage.Sign()` for details.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *UntaggedSignMessage) Sign(rand io.Reader, external []byte, signers ...Signer) error {
+	return (*SignMessage)(m).Sign(rand, external, signers...)
+}
+
+// Verify verifies the signatures on the UntaggedSignMessage against the
+// corresponding verifier, returning nil on success or a suitable error if
+// verification fails.
+//
+// See `SignMessage.Verify()` for details.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+func (m *UntaggedSignMessage) Verify(external []byte, verifiers ...Verifier) error {
+	return (*SignMessage)(m).Verify(external, verifiers...)
+}
@@ -2,6 +2,7 @@ package cose
 
 import (
 	"bytes"
+	"crypto"
 	"errors"
 	"io"
 
@@ -50,24 +51,28 @@ func NewSign1Message() *Sign1Message {
 	}
 }
 
+// Kind returns MessageKindSign1.
+func (m *Sign1Message) Kind() MessageKind {
+	return MessageKindSign1
+}
+
+// MessageHeaders returns the headers of the message body.
+func (m *Sign1Message) MessageHeaders() *Headers {
+	if m == nil {
+		return nil
+	}
+	return &m.Headers
+}
+
 // MarshalCBOR encodes Sign1Message into a COSE_Sign1_Tagged object.
 func (m *Sign1Message) MarshalCBOR() ([]byte, error) {
 	if m == nil {
 		return nil, errors.New("cbor: MarshalCBOR on nil Sign1Message pointer")
 	}
-	if len(m.Signature) == 0 {
-		return nil, ErrEmptySignature
-	}
-	protected, unprotected, err := m.Headers.marshal()
+	content, err := m.content()
 	if err != nil {
 		return nil, err
 	}
-	content := sign1Message{
-		Protected:   protected,
-		Unprotected: unprotected,
-		Payload:     m.Payload,
-		Signature:   m.Signature,
-	}
 	return encMode.Marshal(cbor.Tag{
 		Number:  CBORTagSign1Message,
 		Content: content,
@@ -79,15 +84,59 @@ func (m *Sign1Message) UnmarshalCBOR(data []byte) error {
 	if m == nil {
 		return errors.New("cbor: UnmarshalCBOR on nil Sign1Message pointer")
 	}
+	return m.unmarshalTagged(defaultDecoder, data)
+}
+
+// UnmarshalCBORWithOptions decodes a COSE_Sign1_Tagged object into
+// Sign1Message with the limits of opts, in place of those set by
+// SetDecOptions.
+func (m *Sign1Message) UnmarshalCBORWithOptions(data []byte, opts DecOptions) error {
+	if m == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil Sign1Message pointer")
+	}
+	d, err := opts.decoder()
+	if err != nil {
+		return err
+	}
+	return m.unmarshalTagged(d, data)
+}
+
+// unmarshalTagged decodes a COSE_Sign1_Tagged object into Sign1Message using
+// the decoder.
+func (m *Sign1Message) unmarshalTagged(d *decoder, data []byte) error {
+	if err := d.validate(data); err != nil {
+		return err
+	}
 
 	// fast message check
 	if !bytes.HasPrefix(data, sign1MessagePrefix) {
 		return errors.New("cbor: invalid COSE_Sign1_Tagged object")
 	}
+	return m.unmarshal(d, data[1:])
+}
+
+// content returns the COSE_Sign1 object of the Sign1Message.
+func (m *Sign1Message) content() (sign1Message, error) {
+	if len(m.Signature) == 0 {
+		return sign1Message{}, ErrEmptySignature
+	}
+	protected, unprotected, err := m.Headers.marshal()
+	if err != nil {
+		return sign1Message{}, err
+	}
+	return sign1Message{
+		Protected:   protected,
+		Unprotected: unprotected,
+		Payload:     m.Payload,
+		Signature:   m.Signature,
+	}, nil
+}
 
+// unmarshal decodes a COSE_Sign1 object into Sign1Message using the decoder.
+func (m *Sign1Message) unmarshal(d *decoder, data []byte) error {
 	// decode to sign1Message and parse
 	var raw sign1Message
-	if err := decModeWithTagsForbidden.Unmarshal(data[1:], &raw); err != nil {
+	if err := d.modeWithTagsForbidden.Unmarshal(data, &raw); err != nil {
 		return err
 	}
 	if len(raw.Signature) == 0 {
@@ -101,7 +150,7 @@ func (m *Sign1Message) UnmarshalCBOR(data []byte) error {
 		Payload:   raw.Payload,
 		Signature: raw.Signature,
 	}
-	if err := msg.Headers.UnmarshalFromRaw(); err != nil {
+	if err := msg.Headers.unmarshalFromRaw(d); err != nil {
 		return err
 	}
 
@@ -111,11 +160,13 @@ func (m *Sign1Message) UnmarshalCBOR(data []byte) error {
 
 // Sign signs a Sign1Message using the provided Signer.
 // The signature is stored in m.Signature.
+// If signer implements DigestSigner, the digest of ToBeSigned is signed instead
+// of ToBeSigned itself.
 //
 // Note that m.Signature is only valid as long as m.Headers.Protected and
 // m.Payload remain unchanged after calling this method.
 // It is possible to modify m.Headers.Unprotected after signing,
-// i.e., add counter signatures or timestamps.
+// i.e., add countersignatures with Countersign() or timestamps.
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
 func (m *Sign1Message) Sign(rand io.Reader, external []byte, signer Signer) error {
@@ -125,6 +176,31 @@ func (m *Sign1Message) Sign(rand io.Reader, external []byte, signer Signer) erro
 	if m.Payload == nil {
 		return ErrMissingPayload
 	}
+	return m.sign(rand, m.Payload, external, signer)
+}
+
+// SignDetached signs a Sign1Message with a detached payload using the provided
+// Signer.
+// The signature is computed over the detached content, which is not stored in
+// the message. m.Payload must be nil so that the message is encoded with a
+// nil payload.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+func (m *Sign1Message) SignDetached(rand io.Reader, detached, external []byte, signer Signer) error {
+	if m == nil {
+		return errors.New("signing nil Sign1Message")
+	}
+	if detached == nil {
+		return ErrMissingPayload
+	}
+	if m.Payload != nil {
+		return errors.New("Sign1Message has an attached payload")
+	}
+	return m.sign(rand, detached, external, signer)
+}
+
+// sign signs the payload and stores the signature in m.Signature.
+func (m *Sign1Message) sign(rand io.Reader, payload, external []byte, signer Signer) error {
 	if len(m.Signature) > 0 {
 		return errors.New("Sign1Message signature already has signature bytes")
 	}
@@ -138,11 +214,11 @@ func (m *Sign1Message) Sign(rand io.Reader, external []byte, signer Signer) erro
 	}
 
 	// sign the message
-	toBeSigned, err := m.toBeSigned(external)
+	toBeSigned, err := m.toBeSigned(payload, external)
 	if err != nil {
 		return err
 	}
-	sig, err := signer.Sign(rand, toBeSigned)
+	sig, err := signContent(rand, signer, toBeSigned)
 	if err != nil {
 		return err
 	}
@@ -153,6 +229,8 @@ func (m *Sign1Message) Sign(rand io.Reader, external []byte, signer Signer) erro
 
 // Verify verifies the signature on the Sign1Message returning nil on success or
 // a suitable error if verification fails.
+// If verifier implements DigestVerifier, the signature is verified against the
+// digest of ToBeSigned.
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
 func (m *Sign1Message) Verify(external []byte, verifier Verifier) error {
@@ -162,6 +240,69 @@ func (m *Sign1Message) Verify(external []byte, verifier Verifier) error {
 	if m.Payload == nil {
 		return ErrMissingPayload
 	}
+	return m.verify(m.Payload, external, verifier)
+}
+
+// CheckCritical ensures all the labels of the crit header parameter of the
+// Sign1Message are understood, either by this library or by the application as
+// listed in understood.
+// It returns a *CriticalHeaderError listing the labels not understood, in which
+// case the message must be rejected.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-3.1
+func (m *Sign1Message) CheckCritical(understood ...interface{}) error {
+	if m == nil {
+		return errors.New("checking nil Sign1Message")
+	}
+	return m.Headers.Protected.CheckCritical(understood...)
+}
+
+// VerifyWithOptions verifies the signature on the Sign1Message as Verify does,
+// and enforces the verification policy specified by opts.
+func (m *Sign1Message) VerifyWithOptions(external []byte, opts VerifyOptions, verifier Verifier) error {
+	if m == nil {
+		return errors.New("verifying nil Sign1Message")
+	}
+	if err := opts.check(verifier, &m.Headers); err != nil {
+		return err
+	}
+	return m.Verify(external, verifier)
+}
+
+// VerifyWithResolver verifies the signature on the Sign1Message using the
+// Verifier resolved from the headers of the message, returning nil on success
+// or a suitable error if verification fails.
+func (m *Sign1Message) VerifyWithResolver(external []byte, resolver VerifierResolver) error {
+	if m == nil {
+		return errors.New("verifying nil Sign1Message")
+	}
+	verifier, err := resolver.ResolveVerifier(&m.Headers)
+	if err != nil {
+		return err
+	}
+	return m.Verify(external, verifier)
+}
+
+// VerifyDetached verifies the signature on the Sign1Message with a detached
+// payload, returning nil on success or a suitable error if verification fails.
+// m.Payload must be nil.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+func (m *Sign1Message) VerifyDetached(detached, external []byte, verifier Verifier) error {
+	if m == nil {
+		return errors.New("verifying nil Sign1Message")
+	}
+	if detached == nil {
+		return ErrMissingPayload
+	}
+	if m.Payload != nil {
+		return errors.New("Sign1Message has an attached payload")
+	}
+	return m.verify(detached, external, verifier)
+}
+
+// verify verifies the signature on the payload.
+func (m *Sign1Message) verify(payload, external []byte, verifier Verifier) error {
 	if len(m.Signature) == 0 {
 		return ErrEmptySignature
 	}
@@ -175,17 +316,221 @@ func (m *Sign1Message) Verify(external []byte, verifier Verifier) error {
 	}
 
 	// verify the message
-	toBeSigned, err := m.toBeSigned(external)
+	toBeSigned, err := m.toBeSigned(payload, external)
+	if err != nil {
+		return err
+	}
+	return verifyContent(verifier, toBeSigned, m.Signature)
+}
+
+// Countersign signs the Sign1Message with countersig using the provided Signer,
+// and appends countersig to the Countersignature version 2 header parameter of
+// the unprotected header of m.
+// The Sign1Message is required to be signed already. Signing or verifying it is
+// not affected, as the unprotected header is not covered by its signature.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (m *Sign1Message) Countersign(rand io.Reader, external []byte, signer Signer, countersig *CountersignatureV2) error {
+	if m == nil {
+		return errors.New("countersigning nil Sign1Message")
+	}
+	return countersign(rand, &m.Headers, m, external, signer, countersig)
+}
+
+// Countersign0 signs the Sign1Message using the provided Signer, and sets the
+// abbreviated countersignature to the Countersignature0 version 2 header
+// parameter of the unprotected header of m.
+// The algorithm and the key of an abbreviated countersignature are not
+// identified by the message, and must be known from the context.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.2
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (m *Sign1Message) Countersign0(rand io.Reader, external []byte, signer Signer) error {
+	if m == nil {
+		return errors.New("countersigning nil Sign1Message")
+	}
+	return countersign0(rand, &m.Headers, m, external, signer)
+}
+
+// VerifyCountersignatures verifies the countersignatures of the
+// Countersignature version 2 header parameter of m against the
+// corresponding verifier, returning nil on success or a suitable error if
+// verification fails.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.1
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (m *Sign1Message) VerifyCountersignatures(external []byte, verifiers ...Verifier) error {
+	if m == nil {
+		return errors.New("verifying nil Sign1Message")
+	}
+	return verifyCountersignatures(&m.Headers, m, external, verifiers)
+}
+
+// VerifyCountersignature0 verifies the abbreviated countersignature of the
+// Countersignature0 version 2 header parameter of m, returning nil on
+// success or a suitable error if verification fails.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9338#section-3.2
+//
+// # Experimental
+//
+// Notice: The COSE Countersignature API is EXPERIMENTAL and may be changed or
+// removed in a later release.
+func (m *Sign1Message) VerifyCountersignature0(external []byte, verifier Verifier) error {
+	if m == nil {
+		return errors.New("verifying nil Sign1Message")
+	}
+	return verifyCountersignature0(&m.Headers, m, external, verifier)
+}
+
+// SignStream signs a Sign1Message with a detached payload of length bytes read
+// from payload, using the provided DigestSigner.
+// The Sig_structure is hashed incrementally so that the payload is never held
+// in memory. m.Payload must be nil so that the message is encoded with a nil
+// payload.
+//
+// It returns io.ErrUnexpectedEOF if payload has fewer than length bytes.
+// Any bytes after the first length bytes are not read.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-4.4
+func (m *Sign1Message) SignStream(rand io.Reader, payload io.Reader, length int64, external []byte, signer DigestSigner) error {
+	if m == nil {
+		return errors.New("signing nil Sign1Message")
+	}
+	if payload == nil {
+		return ErrMissingPayload
+	}
+	if m.Payload != nil {
+		return errors.New("Sign1Message has an attached payload")
+	}
+	if len(m.Signature) > 0 {
+		return errors.New("Sign1Message signature already has signature bytes")
+	}
+
+	// check algorithm if present.
+	// `alg` header MUST be present if there is no externally supplied data.
+	alg := signer.Algorithm()
+	err := m.Headers.ensureSigningAlgorithm(alg, external)
+	if err != nil {
+		return err
+	}
+
+	// sign the digest of the message
+	digest, err := m.digestToBeSigned(alg.hashFunc(), payload, length, external)
+	if err != nil {
+		return err
+	}
+	sig, err := signer.SignDigest(rand, digest)
+	if err != nil {
+		return err
+	}
+
+	m.Signature = sig
+	return nil
+}
+
+// VerifyStream verifies the signature on the Sign1Message with a detached
+// payload of length bytes read from payload, using the provided
+// DigestVerifier.
+// The Sig_structure is hashed incrementally so that the payload is never held
+// in memory. m.Payload must be nil.
+//
+// It returns io.ErrUnexpectedEOF if payload has fewer than length bytes.
+// Any bytes after the first length bytes are not read.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-4.4
+func (m *Sign1Message) VerifyStream(payload io.Reader, length int64, external []byte, verifier DigestVerifier) error {
+	if m == nil {
+		return errors.New("verifying nil Sign1Message")
+	}
+	if payload == nil {
+		return ErrMissingPayload
+	}
+	if m.Payload != nil {
+		return errors.New("Sign1Message has an attached payload")
+	}
+	if len(m.Signature) == 0 {
+		return ErrEmptySignature
+	}
+
+	// check algorithm if present.
+	// `alg` header MUST present if there is no externally supplied data.
+	alg := verifier.Algorithm()
+	err := m.Headers.ensureVerificationAlgorithm(alg, external)
+	if err != nil {
+		return err
+	}
+
+	// verify the digest of the message
+	digest, err := m.digestToBeSigned(alg.hashFunc(), payload, length, external)
 	if err != nil {
 		return err
 	}
-	return verifier.Verify(toBeSigned, m.Signature)
+	return verifier.VerifyDigest(digest, m.Signature)
+}
+
+// digestToBeSigned constructs Sig_structure with a payload of length bytes read
+// from payload, and returns the digest of ToBeSigned computed by hash.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-4.4
+func (m *Sign1Message) digestToBeSigned(hash crypto.Hash, payload io.Reader, length int64, external []byte) ([]byte, error) {
+	if length < 0 {
+		return nil, errors.New("negative payload length")
+	}
+	if !hash.Available() {
+		return nil, ErrUnavailableHashFunc
+	}
+	var protected cbor.RawMessage
+	protected, err := m.Headers.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+	if external == nil {
+		external = []byte{}
+	}
+
+	// encode the Sig_structure up to the head of the payload bstr, so that
+	// the payload content can be streamed afterwards.
+	prefix, err := encMode.Marshal([]interface{}{
+		"Signature1", // context
+		protected,    // body_protected
+		external,     // external_aad
+	})
+	if err != nil {
+		return nil, err
+	}
+	prefix[0]++ // array of length 4 instead of 3
+	h := hash.New()
+	h.Write(prefix)
+	h.Write(encodeBstrHead(uint64(length)))
+
+	// hash the payload content
+	if n, err := io.CopyN(h, payload, length); err != nil {
+		if err == io.EOF && n < length {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return h.Sum(nil), nil
 }
 
 // toBeSigned constructs Sig_structure, computes and returns ToBeSigned.
 //
 // Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-4.4
-func (m *Sign1Message) toBeSigned(external []byte) ([]byte, error) {
+func (m *Sign1Message) toBeSigned(payload, external []byte) ([]byte, error) {
 	// create a Sig_structure and populate it with the appropriate fields.
 	//
 	//   Sig_structure = [
@@ -206,7 +551,7 @@ func (m *Sign1Message) toBeSigned(external []byte) ([]byte, error) {
 		"Signature1", // context
 		protected,    // body_protected
 		external,     // external_aad
-		m.Payload,    // payload
+		payload,      // payload
 	}
 
 	// create the value ToBeSigned by encoding the Sig_structure to a byte
@@ -230,3 +575,115 @@ func Sign1(rand io.Reader, signer Signer, headers Headers, payload []byte, exter
 	}
 	return msg.MarshalCBOR()
 }
+
+// Sign1Detached signs a Sign1Message with a detached payload using the provided
+// Signer. The payload is encoded as nil in the returned message.
+//
+// This method is a wrapper of `Sign1Message.SignDetached()`.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-2
+func Sign1Detached(rand io.Reader, signer Signer, headers Headers, detached []byte, external []byte) ([]byte, error) {
+	msg := Sign1Message{
+		Headers: headers,
+	}
+	err := msg.SignDetached(rand, detached, external, signer)
+	if err != nil {
+		return nil, err
+	}
+	return msg.MarshalCBOR()
+}
+
+// untaggedSign1MessagePrefix represents the fixed prefix of COSE_Sign1.
+var untaggedSign1MessagePrefix = []byte{
+	0x84, // Array of length 4
+}
+
+// UntaggedSign1Message represents a decoded COSE_Sign1 message, which is
+// encoded without the COSE_Sign1_Tagged tag as required by protocols where
+// the message type is known from the context.
+//
+// UntaggedSign1Message shares the headers and the signing and verification
+// logic of Sign1Message, and can be converted from and to a Sign1Message to
+// access its other methods.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-4.2
+type UntaggedSign1Message Sign1Message
+
+// MarshalCBOR encodes UntaggedSign1Message into a COSE_Sign1 object.
+func (m *UntaggedSign1Message) MarshalCBOR() ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("cbor: MarshalCBOR on nil UntaggedSign1Message pointer")
+	}
+	content, err := (*Sign1Message)(m).content()
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(content)
+}
+
+// UnmarshalCBOR decodes a COSE_Sign1 object into UntaggedSign1Message.
+// Tagged messages are rejected.
+func (m *UntaggedSign1Message) UnmarshalCBOR(data []byte) error {
+	if m == nil {
+		return errors.New("cbor: UnmarshalCBOR on nil UntaggedSign1Message pointer")
+	}
+
+	if err := defaultDecoder.validate(data); err != nil {
+		return err
+	}
+
+	// fast message check
+	if !bytes.HasPrefix(data, untaggedSign1MessagePrefix) {
+		return errors.New("cbor: invalid COSE_Sign1 object")
+	}
+	return (*Sign1Message)(m).unmarshal(defaultDecoder, data)
+}
+
+// Sign signs an UntaggedSign1Message using the provided Signer.
+//
+// See `Sign1Message.Sign()` for details.
+func (m *UntaggedSign1Message) Sign(rand io.Reader, external []byte, signer Signer) error {
+	return (*Sign1Message)(m).Sign(rand, external, signer)
+}
+
+// SignDetached signs an UntaggedSign1Message with a detached payload using the
+// provided Signer.
+//
+// See `Sign1Message.SignDetached()` for details.
+func (m *UntaggedSign1Message) SignDetached(rand io.Reader, detached, external []byte, signer Signer) error {
+	return (*Sign1Message)(m).SignDetached(rand, detached, external, signer)
+}
+
+// Verify verifies the signature on the UntaggedSign1Message returning nil on
+// success or a suitable error if verification fails.
+//
+// See `Sign1Message.Verify()` for details.
+func (m *UntaggedSign1Message) Verify(external []byte, verifier Verifier) error {
+	return (*Sign1Message)(m).Verify(external, verifier)
+}
+
+// VerifyDetached verifies the signature on the UntaggedSign1Message with a
+// detached payload, returning nil on success or a suitable error if
+// verification fails.
+//
+// See `Sign1Message.VerifyDetached()` for details.
+func (m *UntaggedSign1Message) VerifyDetached(detached, external []byte, verifier Verifier) error {
+	return (*Sign1Message)(m).VerifyDetached(detached, external, verifier)
+}
+
+// Sign1Untagged signs an UntaggedSign1Message using the provided Signer.
+//
+// This method is a wrapper of `UntaggedSign1Message.Sign()`.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-4.2
+func Sign1Untagged(rand io.Reader, signer Signer, headers Headers, payload []byte, external []byte) ([]byte, error) {
+	msg := UntaggedSign1Message{
+		Headers: headers,
+		Payload: payload,
+	}
+	err := msg.Sign(rand, external, signer)
+	if err != nil {
+		return nil, err
+	}
+	return msg.MarshalCBOR()
+}
@@ -2,7 +2,12 @@ package cose
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"errors"
+	"io"
 	"reflect"
 	"testing"
 )
@@ -220,6 +225,29 @@ func TestSign1Message_UnmarshalCBOR(t *testing.T) {
 				Signature: []byte("bar"),
 			},
 		},
+		{
+			name: "unsupported algorithm",
+			data: []byte{
+				0xd2, // tag
+				0x84,
+				0x45, 0xa1, 0x01, 0x39, 0x01, 0x00, // protected: RS256
+				0xa0,                   // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // signature
+			},
+			want: Sign1Message{
+				Headers: Headers{
+					RawProtected: []byte{0x45, 0xa1, 0x01, 0x39, 0x01, 0x00},
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: Algorithm(-257),
+					},
+					RawUnprotected: []byte{0xa0},
+					Unprotected:    UnprotectedHeader{},
+				},
+				Payload:   []byte("foo"),
+				Signature: []byte("bar"),
+			},
+		},
 		{
 			name: "valid message with nil payload",
 			data: []byte{
@@ -396,6 +424,198 @@ func TestSign1Message_UnmarshalCBOR(t *testing.T) {
 	}
 }
 
+func TestSign1Message_UnmarshalCBORWithOptions(t *testing.T) {
+	// test nil pointer
+	t.Run("nil Sign1Message pointer", func(t *testing.T) {
+		var msg *Sign1Message
+		data := []byte{0xd2, 0x84, 0x40, 0xa0, 0xf6, 0x41, 0x00}
+		if err := msg.UnmarshalCBORWithOptions(data, DecOptions{}); err == nil {
+			t.Errorf("want error on nil *Sign1Message")
+		}
+	})
+
+	// test others
+	nested := []interface{}{[]interface{}{[]interface{}{[]interface{}{int64(1)}}}}
+	elements := make([]interface{}, 17)
+	for i := range elements {
+		elements[i] = int64(i)
+	}
+	msg := &Sign1Message{
+		Headers: Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+			Unprotected: UnprotectedHeader{
+				HeaderLabelContentType: int64(42),
+				int64(256):             nested,
+				int64(257):             elements,
+			},
+		},
+		Payload:   []byte("foo"),
+		Signature: []byte("bar"),
+	}
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("Sign1Message.MarshalCBOR() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		opts    DecOptions
+		wantErr bool
+	}{
+		{
+			name: "default limits",
+			opts: DecOptions{},
+		},
+		{
+			name: "within limits",
+			opts: DecOptions{
+				MaxNestedLevels:  6,
+				MaxArrayElements: 17,
+				MaxMapPairs:      16,
+				MaxMessageSize:   len(data),
+				MaxHeaders:       3,
+			},
+		},
+		{
+			name:    "nested levels exceeded",
+			opts:    DecOptions{MaxNestedLevels: 5},
+			wantErr: true,
+		},
+		{
+			name:    "array elements exceeded",
+			opts:    DecOptions{MaxArrayElements: 16},
+			wantErr: true,
+		},
+		{
+			name:    "message size exceeded",
+			opts:    DecOptions{MaxMessageSize: len(data) - 1},
+			wantErr: true,
+		},
+		{
+			name:    "headers exceeded",
+			opts:    DecOptions{MaxHeaders: 2},
+			wantErr: true,
+		},
+		{
+			name:    "invalid options",
+			opts:    DecOptions{MaxNestedLevels: 1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Sign1Message
+			err := got.UnmarshalCBORWithOptions(data, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Sign1Message.UnmarshalCBORWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got.Headers.Unprotected, msg.Headers.Unprotected) {
+				t.Errorf("Sign1Message.UnmarshalCBORWithOptions() Unprotected = %v, want %v", got.Headers.Unprotected, msg.Headers.Unprotected)
+			}
+		})
+	}
+}
+
+func TestSign1Message_UnmarshalCBORWithOptions_Deterministic(t *testing.T) {
+	opts := DecOptions{Deterministic: true}
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{
+			name: "deterministic message",
+			data: []byte{
+				0xd2, // tag
+				0x84,
+				0x45, 0xa2, 0x01, 0x26, 0x03, 0x00, // protected
+				0xa2, 0x04, 0x41, 0x01, 0x20, 0x00, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // signature
+			},
+		},
+		{
+			name: "non-shortest integer in protected header",
+			data: []byte{
+				0xd2, // tag
+				0x84,
+				0x44, 0xa1, 0x01, 0x38, 0x06, // protected
+				0xa0,                   // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // signature
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsorted protected header",
+			data: []byte{
+				0xd2, // tag
+				0x84,
+				0x45, 0xa2, 0x03, 0x00, 0x01, 0x26, // protected
+				0xa0,                   // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // signature
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsorted unprotected header",
+			data: []byte{
+				0xd2, // tag
+				0x84,
+				0x43, 0xa1, 0x01, 0x26, // protected
+				0xa2, 0x20, 0x00, 0x04, 0x41, 0x01, // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // signature
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-shortest payload length",
+			data: []byte{
+				0xd2, // tag
+				0x84,
+				0x43, 0xa1, 0x01, 0x26, // protected
+				0xa0,                         // unprotected
+				0x58, 0x03, 0x66, 0x6f, 0x6f, // payload
+				0x43, 0x62, 0x61, 0x72, // signature
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg Sign1Message
+			err := msg.UnmarshalCBORWithOptions(tt.data, opts)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNonDeterministicEncoding) {
+					t.Fatalf("Sign1Message.UnmarshalCBORWithOptions() error = %v, wantErr %v", err, ErrNonDeterministicEncoding)
+				}
+				// accepted without the deterministic mode
+				if err := msg.UnmarshalCBOR(tt.data); err != nil {
+					t.Fatalf("Sign1Message.UnmarshalCBOR() error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Sign1Message.UnmarshalCBORWithOptions() error = %v", err)
+			}
+
+			// deterministic messages re-encode bit-identically
+			msg.Headers.RawProtected = nil
+			msg.Headers.RawUnprotected = nil
+			got, err := msg.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("Sign1Message.MarshalCBOR() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("Sign1Message.MarshalCBOR() = %x, want %x", got, tt.data)
+			}
+		})
+	}
+}
+
 func TestSign1Message_Sign(t *testing.T) {
 	// generate key and set up signer / verifier
 	alg := AlgorithmES256
@@ -837,3 +1057,738 @@ func TestSign1Message_Verify(t *testing.T) {
 		}
 	})
 }
+
+func TestSign1Message_SignDetached(t *testing.T) {
+	// generate key and set up signer / verifier
+	alg := AlgorithmES256
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(alg, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	detached := []byte("hello world")
+
+	tests := []struct {
+		name             string
+		msg              *Sign1Message
+		detached         []byte
+		externalOnSign   []byte
+		detachedOnVerify []byte
+		externalOnVerify []byte
+		wantSignErr      bool
+		wantVerifyErr    bool
+	}{
+		{
+			name:             "round trip",
+			msg:              NewSign1Message(),
+			detached:         detached,
+			detachedOnVerify: detached,
+		},
+		{
+			name:             "round trip with external",
+			msg:              NewSign1Message(),
+			detached:         detached,
+			externalOnSign:   []byte("foo"),
+			detachedOnVerify: detached,
+			externalOnVerify: []byte("foo"),
+		},
+		{
+			name:             "empty detached payload",
+			msg:              NewSign1Message(),
+			detached:         []byte{},
+			detachedOnVerify: []byte{},
+		},
+		{
+			name:             "detached payload mismatch",
+			msg:              NewSign1Message(),
+			detached:         detached,
+			detachedOnVerify: []byte("foo"),
+			wantVerifyErr:    true,
+		},
+		{
+			name:             "missing detached payload on verify",
+			msg:              NewSign1Message(),
+			detached:         detached,
+			detachedOnVerify: nil,
+			wantVerifyErr:    true,
+		},
+		{
+			name:        "missing detached payload on sign",
+			msg:         NewSign1Message(),
+			wantSignErr: true,
+		},
+		{
+			name: "attached payload",
+			msg: &Sign1Message{
+				Payload: []byte("foo"),
+			},
+			detached:    detached,
+			wantSignErr: true,
+		},
+		{
+			name:        "nil message",
+			msg:         nil,
+			detached:    detached,
+			wantSignErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.SignDetached(rand.Reader, tt.detached, tt.externalOnSign, signer)
+			if (err != nil) != tt.wantSignErr {
+				t.Fatalf("Sign1Message.SignDetached() error = %v, wantErr %v", err, tt.wantSignErr)
+			}
+			if tt.wantSignErr {
+				return
+			}
+
+			// round trip through CBOR
+			data, err := tt.msg.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("Sign1Message.MarshalCBOR() error = %v", err)
+			}
+			var msg Sign1Message
+			if err := msg.UnmarshalCBOR(data); err != nil {
+				t.Fatalf("Sign1Message.UnmarshalCBOR() error = %v", err)
+			}
+			if msg.Payload != nil {
+				t.Fatalf("Sign1Message.Payload = %v, want nil", msg.Payload)
+			}
+			if err := msg.Verify(tt.externalOnVerify, verifier); err != ErrMissingPayload {
+				t.Errorf("Sign1Message.Verify() error = %v, wantErr %v", err, ErrMissingPayload)
+			}
+
+			err = msg.VerifyDetached(tt.detachedOnVerify, tt.externalOnVerify, verifier)
+			if (err != nil) != tt.wantVerifyErr {
+				t.Errorf("Sign1Message.VerifyDetached() error = %v, wantErr %v", err, tt.wantVerifyErr)
+			}
+		})
+	}
+
+	// special cases
+	t.Run("attached payload on verify", func(t *testing.T) {
+		msg := NewSign1Message()
+		msg.Payload = detached
+		if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+			t.Fatalf("Sign1Message.Sign() error = %v", err)
+		}
+		if err := msg.VerifyDetached(detached, nil, verifier); err == nil {
+			t.Error("Sign1Message.VerifyDetached() error = nil, wantErr true")
+		}
+	})
+}
+
+func TestSign1Detached(t *testing.T) {
+	// generate key and set up signer / verifier
+	alg := AlgorithmES256
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(alg, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	headers := Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: alg,
+		},
+	}
+	detached := []byte("hello world")
+
+	data, err := Sign1Detached(rand.Reader, signer, headers, detached, nil)
+	if err != nil {
+		t.Fatalf("Sign1Detached() error = %v", err)
+	}
+	var msg Sign1Message
+	if err := msg.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("Sign1Message.UnmarshalCBOR() error = %v", err)
+	}
+	if err := msg.VerifyDetached(detached, nil, verifier); err != nil {
+		t.Errorf("Sign1Message.VerifyDetached() error = %v", err)
+	}
+
+	if _, err := Sign1Detached(rand.Reader, signer, headers, nil, nil); err != ErrMissingPayload {
+		t.Errorf("Sign1Detached() error = %v, wantErr %v", err, ErrMissingPayload)
+	}
+}
+
+func TestSign1Message_SignStream(t *testing.T) {
+	// generate key and set up signer / verifier
+	alg := AlgorithmES256
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(alg, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	digestSigner := signer.(DigestSigner)
+	digestVerifier := verifier.(DigestVerifier)
+	payload := bytes.Repeat([]byte("hello world"), 10000)
+
+	tests := []struct {
+		name          string
+		msg           *Sign1Message
+		payload       io.Reader
+		length        int64
+		external      []byte
+		wantSignErr   error
+		wantVerifyErr bool
+	}{
+		{
+			name:    "round trip",
+			msg:     NewSign1Message(),
+			payload: bytes.NewReader(payload),
+			length:  int64(len(payload)),
+		},
+		{
+			name:     "round trip with external",
+			msg:      NewSign1Message(),
+			payload:  bytes.NewReader(payload),
+			length:   int64(len(payload)),
+			external: []byte("foo"),
+		},
+		{
+			name:    "empty payload",
+			msg:     NewSign1Message(),
+			payload: bytes.NewReader(nil),
+			length:  0,
+		},
+		{
+			name:    "trailing data not signed",
+			msg:     NewSign1Message(),
+			payload: bytes.NewReader(payload),
+			length:  int64(len(payload) - 1),
+		},
+		{
+			name:        "short payload",
+			msg:         NewSign1Message(),
+			payload:     bytes.NewReader(payload),
+			length:      int64(len(payload) + 1),
+			wantSignErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "nil payload",
+			msg:         NewSign1Message(),
+			wantSignErr: ErrMissingPayload,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.SignStream(rand.Reader, tt.payload, tt.length, tt.external, digestSigner)
+			if err != tt.wantSignErr {
+				t.Fatalf("Sign1Message.SignStream() error = %v, wantErr %v", err, tt.wantSignErr)
+			}
+			if tt.wantSignErr != nil {
+				return
+			}
+
+			// verify in memory and by streaming
+			detached := payload[:tt.length]
+			if err := tt.msg.VerifyDetached(detached, tt.external, verifier); err != nil {
+				t.Errorf("Sign1Message.VerifyDetached() error = %v", err)
+			}
+			if err := tt.msg.VerifyStream(bytes.NewReader(detached), tt.length, tt.external, digestVerifier); err != nil {
+				t.Errorf("Sign1Message.VerifyStream() error = %v", err)
+			}
+			if err := tt.msg.VerifyStream(bytes.NewReader(payload), tt.length+1, tt.external, digestVerifier); err == nil {
+				t.Error("Sign1Message.VerifyStream() error = nil, wantErr true")
+			}
+		})
+	}
+
+	// special cases
+	t.Run("attached payload", func(t *testing.T) {
+		msg := NewSign1Message()
+		msg.Payload = payload
+		if err := msg.SignStream(rand.Reader, bytes.NewReader(payload), int64(len(payload)), nil, digestSigner); err == nil {
+			t.Error("Sign1Message.SignStream() error = nil, wantErr true")
+		}
+		if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+			t.Fatalf("Sign1Message.Sign() error = %v", err)
+		}
+		if err := msg.VerifyStream(bytes.NewReader(payload), int64(len(payload)), nil, digestVerifier); err == nil {
+			t.Error("Sign1Message.VerifyStream() error = nil, wantErr true")
+		}
+	})
+	t.Run("negative length", func(t *testing.T) {
+		msg := NewSign1Message()
+		if err := msg.SignStream(rand.Reader, bytes.NewReader(payload), -1, nil, digestSigner); err == nil {
+			t.Error("Sign1Message.SignStream() error = nil, wantErr true")
+		}
+	})
+	t.Run("algorithm mismatch", func(t *testing.T) {
+		msg := NewSign1Message()
+		msg.Headers.Protected.SetAlgorithm(AlgorithmES512)
+		if err := msg.SignStream(rand.Reader, bytes.NewReader(payload), int64(len(payload)), nil, digestSigner); err == nil {
+			t.Error("Sign1Message.SignStream() error = nil, wantErr true")
+		}
+	})
+	t.Run("missing signature", func(t *testing.T) {
+		msg := NewSign1Message()
+		if err := msg.VerifyStream(bytes.NewReader(payload), int64(len(payload)), nil, digestVerifier); err != ErrEmptySignature {
+			t.Errorf("Sign1Message.VerifyStream() error = %v, wantErr %v", err, ErrEmptySignature)
+		}
+	})
+}
+
+func TestSign1Message_digestToBeSigned(t *testing.T) {
+	msg := NewSign1Message()
+	msg.Headers.Protected.SetAlgorithm(AlgorithmES256)
+	hash := crypto.SHA256
+	for _, length := range []int{0, 23, 24, 255, 256, 65535, 65536} {
+		payload := make([]byte, length)
+		toBeSigned, err := msg.toBeSigned(payload, []byte("foo"))
+		if err != nil {
+			t.Fatalf("Sign1Message.toBeSigned() error = %v", err)
+		}
+		want, err := computeHash(hash, toBeSigned)
+		if err != nil {
+			t.Fatalf("computeHash() error = %v", err)
+		}
+		got, err := msg.digestToBeSigned(hash, bytes.NewReader(payload), int64(length), []byte("foo"))
+		if err != nil {
+			t.Fatalf("Sign1Message.digestToBeSigned() error = %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Sign1Message.digestToBeSigned() length %d = %x, want %x", length, got, want)
+		}
+	}
+
+	if _, err := msg.digestToBeSigned(0, bytes.NewReader(nil), 0, nil); err != ErrUnavailableHashFunc {
+		t.Errorf("Sign1Message.digestToBeSigned() error = %v, wantErr %v", err, ErrUnavailableHashFunc)
+	}
+}
+
+func TestSign1Message_VerifyWithResolver(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(AlgorithmES256, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	msg := NewSign1Message()
+	msg.Headers.SetKeyID([]byte("1"))
+	msg.Payload = []byte("hello world")
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		t.Fatalf("Sign1Message.Sign() error = %v", err)
+	}
+
+	resolver := KeyIDResolver{
+		"1": key.Public(),
+	}
+	if err := msg.VerifyWithResolver(nil, resolver); err != nil {
+		t.Errorf("Sign1Message.VerifyWithResolver() error = %v", err)
+	}
+
+	// wrong key
+	resolver["1"] = generateTestECDSAKey(t).Public()
+	if err := msg.VerifyWithResolver(nil, resolver); err != ErrVerification {
+		t.Errorf("Sign1Message.VerifyWithResolver() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// unknown key
+	delete(resolver, "1")
+	if err := msg.VerifyWithResolver(nil, resolver); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Sign1Message.VerifyWithResolver() error = %v, wantErr %v", err, ErrKeyNotFound)
+	}
+
+	// nil message
+	var nilMsg *Sign1Message
+	if err := nilMsg.VerifyWithResolver(nil, resolver); err == nil {
+		t.Error("Sign1Message.VerifyWithResolver() error = nil, wantErr true")
+	}
+}
+
+func TestSign1Message_VerifyWithOptions(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	signer, err := NewSigner(AlgorithmES384, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(AlgorithmES384, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	msg := NewSign1Message()
+	msg.Headers.SetKeyID([]byte("1"))
+	msg.Payload = []byte("hello world")
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		t.Fatalf("Sign1Message.Sign() error = %v", err)
+	}
+
+	opts := VerifyOptions{
+		Algorithms:               []Algorithm{AlgorithmES384, AlgorithmES512},
+		MinECKeySize:             384,
+		RequiredProtectedHeaders: []interface{}{HeaderLabelKeyID},
+	}
+	if err := msg.VerifyWithOptions(nil, opts, verifier); err != nil {
+		t.Errorf("Sign1Message.VerifyWithOptions() error = %v", err)
+	}
+
+	opts.Algorithms = []Algorithm{AlgorithmES512}
+	if err := msg.VerifyWithOptions(nil, opts, verifier); !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Errorf("Sign1Message.VerifyWithOptions() error = %v, wantErr %v", err, ErrAlgorithmNotAllowed)
+	}
+
+	msg.Payload = []byte("foobar")
+	if err := msg.VerifyWithOptions(nil, VerifyOptions{}, verifier); err != ErrVerification {
+		t.Errorf("Sign1Message.VerifyWithOptions() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	var nilMsg *Sign1Message
+	if err := nilMsg.VerifyWithOptions(nil, VerifyOptions{}, verifier); err == nil {
+		t.Error("Sign1Message.VerifyWithOptions() error = nil, wantErr true")
+	}
+}
+
+func TestSign1Message_CheckCritical(t *testing.T) {
+	msg := &Sign1Message{
+		Headers: Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+				HeaderLabelCritical:  []interface{}{int64(-65537)},
+				int64(-65537):        "foo",
+			},
+		},
+		Payload:   []byte("hello world"),
+		Signature: []byte{0x01},
+	}
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("Sign1Message.MarshalCBOR() error = %v", err)
+	}
+	var decoded Sign1Message
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("Sign1Message.UnmarshalCBOR() error = %v", err)
+	}
+
+	var critErr *CriticalHeaderError
+	if err := decoded.CheckCritical(); !errors.As(err, &critErr) {
+		t.Fatalf("Sign1Message.CheckCritical() error = %v, want *CriticalHeaderError", err)
+	}
+	if want := []interface{}{int64(-65537)}; !reflect.DeepEqual(critErr.Labels, want) {
+		t.Errorf("CriticalHeaderError.Labels = %v, want %v", critErr.Labels, want)
+	}
+	if err := decoded.CheckCritical(-65537); err != nil {
+		t.Errorf("Sign1Message.CheckCritical() error = %v", err)
+	}
+
+	var nilMsg *Sign1Message
+	if err := nilMsg.CheckCritical(); err == nil {
+		t.Error("Sign1Message.CheckCritical() error = nil, wantErr true")
+	}
+}
+
+func TestSign1Message_Countersign(t *testing.T) {
+	// generate keys and set up signers / verifiers
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(AlgorithmES256, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(AlgorithmES256, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	counterPub, counterKey := generateTestEd25519Key(t)
+	counterSigner, err := NewSigner(AlgorithmEd25519, counterKey)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	counterVerifier, err := NewVerifier(AlgorithmEd25519, counterPub)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	// sign and countersign
+	msg := NewSign1Message()
+	msg.Headers.Protected.SetAlgorithm(AlgorithmES256)
+	msg.Payload = []byte("hello world")
+	if err := msg.Countersign(rand.Reader, nil, counterSigner, NewCountersignatureV2()); err != ErrEmptySignature {
+		t.Errorf("Sign1Message.Countersign() error = %v, wantErr %v", err, ErrEmptySignature)
+	}
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		t.Fatalf("Sign1Message.Sign() error = %v", err)
+	}
+	if err := msg.VerifyCountersignatures(nil, counterVerifier); err != ErrNoCountersignatures {
+		t.Errorf("Sign1Message.VerifyCountersignatures() error = %v, wantErr %v", err, ErrNoCountersignatures)
+	}
+	for i := 0; i < 2; i++ {
+		countersig := NewCountersignatureV2()
+		countersig.Headers.Protected.SetAlgorithm(AlgorithmEd25519)
+		if err := msg.Countersign(rand.Reader, []byte("external"), counterSigner, countersig); err != nil {
+			t.Fatalf("Sign1Message.Countersign() error = %v", err)
+		}
+	}
+	if err := msg.Countersign0(rand.Reader, nil, counterSigner); err != nil {
+		t.Fatalf("Sign1Message.Countersign0() error = %v", err)
+	}
+	if err := msg.Countersign0(rand.Reader, nil, counterSigner); err == nil {
+		t.Error("Sign1Message.Countersign0() error = nil, wantErr true")
+	}
+
+	// round trip
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("Sign1Message.MarshalCBOR() error = %v", err)
+	}
+	var decoded Sign1Message
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("Sign1Message.UnmarshalCBOR() error = %v", err)
+	}
+	if err := decoded.Verify(nil, verifier); err != nil {
+		t.Errorf("Sign1Message.Verify() error = %v", err)
+	}
+	if err := decoded.VerifyCountersignatures([]byte("external"), counterVerifier, counterVerifier); err != nil {
+		t.Errorf("Sign1Message.VerifyCountersignatures() error = %v", err)
+	}
+	if err := decoded.VerifyCountersignature0(nil, counterVerifier); err != nil {
+		t.Errorf("Sign1Message.VerifyCountersignature0() error = %v", err)
+	}
+
+	// countersign a decoded message
+	countersig := NewCountersignatureV2()
+	countersig.Headers.Protected.SetAlgorithm(AlgorithmEd25519)
+	if err := decoded.Countersign(rand.Reader, []byte("external"), counterSigner, countersig); err != nil {
+		t.Fatalf("Sign1Message.Countersign() error = %v", err)
+	}
+	data, err = decoded.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("Sign1Message.MarshalCBOR() error = %v", err)
+	}
+	decoded = Sign1Message{}
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("Sign1Message.UnmarshalCBOR() error = %v", err)
+	}
+	if err := decoded.VerifyCountersignatures([]byte("external"), counterVerifier, counterVerifier, counterVerifier); err != nil {
+		t.Errorf("Sign1Message.VerifyCountersignatures() error = %v", err)
+	}
+
+	// verifier count mismatch
+	if err := decoded.VerifyCountersignatures([]byte("external"), counterVerifier); err == nil {
+		t.Error("Sign1Message.VerifyCountersignatures() error = nil, wantErr true")
+	}
+
+	// tampered signature
+	decoded.Signature[0]++
+	if err := decoded.VerifyCountersignatures([]byte("external"), counterVerifier, counterVerifier, counterVerifier); !errors.Is(err, ErrVerification) {
+		t.Errorf("Sign1Message.VerifyCountersignatures() error = %v, wantErr %v", err, ErrVerification)
+	}
+	if err := decoded.VerifyCountersignature0(nil, counterVerifier); err != ErrVerification {
+		t.Errorf("Sign1Message.VerifyCountersignature0() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// nil message
+	var nilMsg *Sign1Message
+	if err := nilMsg.Countersign(rand.Reader, nil, counterSigner, NewCountersignatureV2()); err == nil {
+		t.Error("Sign1Message.Countersign() error = nil, wantErr true")
+	}
+	if err := nilMsg.Countersign0(rand.Reader, nil, counterSigner); err == nil {
+		t.Error("Sign1Message.Countersign0() error = nil, wantErr true")
+	}
+	if err := nilMsg.VerifyCountersignatures(nil, counterVerifier); err == nil {
+		t.Error("Sign1Message.VerifyCountersignatures() error = nil, wantErr true")
+	}
+	if err := nilMsg.VerifyCountersignature0(nil, counterVerifier); err == nil {
+		t.Error("Sign1Message.VerifyCountersignature0() error = nil, wantErr true")
+	}
+}
+
+func TestUntaggedSign1Message_MarshalCBOR(t *testing.T) {
+	msg := &UntaggedSign1Message{
+		Headers: Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+			Unprotected: UnprotectedHeader{
+				HeaderLabelContentType: 42,
+			},
+		},
+		Payload:   []byte("foo"),
+		Signature: []byte("bar"),
+	}
+	want := []byte{
+		0x84,
+		0x43, 0xa1, 0x01, 0x26, // protected
+		0xa1, 0x03, 0x18, 0x2a, // unprotected
+		0x43, 0x66, 0x6f, 0x6f, // payload
+		0x43, 0x62, 0x61, 0x72, // signature
+	}
+	got, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("UntaggedSign1Message.MarshalCBOR() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("UntaggedSign1Message.MarshalCBOR() = %v, want %v", got, want)
+	}
+
+	// empty signature
+	msg.Signature = nil
+	if _, err := msg.MarshalCBOR(); err != ErrEmptySignature {
+		t.Errorf("UntaggedSign1Message.MarshalCBOR() error = %v, wantErr %v", err, ErrEmptySignature)
+	}
+
+	// nil message
+	var nilMsg *UntaggedSign1Message
+	if _, err := nilMsg.MarshalCBOR(); err == nil {
+		t.Error("UntaggedSign1Message.MarshalCBOR() error = nil, wantErr true")
+	}
+}
+
+func TestUntaggedSign1Message_UnmarshalCBOR(t *testing.T) {
+	data := []byte{
+		0x84,
+		0x43, 0xa1, 0x01, 0x26, // protected
+		0xa1, 0x03, 0x18, 0x2a, // unprotected
+		0x43, 0x66, 0x6f, 0x6f, // payload
+		0x43, 0x62, 0x61, 0x72, // signature
+	}
+	var got UntaggedSign1Message
+	if err := got.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("UntaggedSign1Message.UnmarshalCBOR() error = %v", err)
+	}
+	want := UntaggedSign1Message{
+		Headers: Headers{
+			RawProtected: []byte{0x43, 0xa1, 0x01, 0x26},
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+			RawUnprotected: []byte{0xa1, 0x03, 0x18, 0x2a},
+			Unprotected: UnprotectedHeader{
+				HeaderLabelContentType: int64(42),
+			},
+		},
+		Payload:   []byte("foo"),
+		Signature: []byte("bar"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UntaggedSign1Message.UnmarshalCBOR() = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "tagged message",
+			data: append([]byte{0xd2}, data...),
+		},
+		{
+			name: "empty signature",
+			data: []byte{
+				0x84,
+				0x43, 0xa1, 0x01, 0x26, // protected
+				0xa0,                   // unprotected
+				0x43, 0x66, 0x6f, 0x6f, // payload
+				0x40, // signature
+			},
+		},
+		{
+			name: "nested tag",
+			data: []byte{
+				0x84,
+				0x43, 0xa1, 0x01, 0x26, // protected
+				0xa0,                   // unprotected
+				0xd8, 0x18, 0x41, 0x66, // payload
+				0x43, 0x62, 0x61, 0x72, // signature
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg UntaggedSign1Message
+			if err := msg.UnmarshalCBOR(tt.data); err == nil {
+				t.Error("UntaggedSign1Message.UnmarshalCBOR() error = nil, wantErr true")
+			}
+		})
+	}
+
+	// tagged Sign1Message rejects the untagged message
+	var tagged Sign1Message
+	if err := tagged.UnmarshalCBOR(data); err == nil {
+		t.Error("Sign1Message.UnmarshalCBOR() error = nil, wantErr true")
+	}
+
+	// nil message
+	var nilMsg *UntaggedSign1Message
+	if err := nilMsg.UnmarshalCBOR(data); err == nil {
+		t.Error("UntaggedSign1Message.UnmarshalCBOR() error = nil, wantErr true")
+	}
+}
+
+func TestUntaggedSign1Message_Sign(t *testing.T) {
+	// generate key and set up signer / verifier
+	alg := AlgorithmES256
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(alg, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	headers := Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: alg,
+		},
+	}
+
+	// sign / verify round trip
+	data, err := Sign1Untagged(rand.Reader, signer, headers, []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("Sign1Untagged() error = %v", err)
+	}
+	if data[0] != 0x84 {
+		t.Errorf("Sign1Untagged() = %x, want untagged array", data)
+	}
+	var msg UntaggedSign1Message
+	if err := msg.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("UntaggedSign1Message.UnmarshalCBOR() error = %v", err)
+	}
+	if err := msg.Verify(nil, verifier); err != nil {
+		t.Errorf("UntaggedSign1Message.Verify() error = %v", err)
+	}
+
+	// the signature is the same as for the tagged message
+	tagged := (*Sign1Message)(&msg)
+	taggedData, err := tagged.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("Sign1Message.MarshalCBOR() error = %v", err)
+	}
+	if !bytes.Equal(taggedData[1:], data) {
+		t.Errorf("Sign1Message.MarshalCBOR() = %x, want tagged %x", taggedData, data)
+	}
+
+	// detached payload
+	detached := UntaggedSign1Message{Headers: headers}
+	if err := detached.SignDetached(rand.Reader, []byte("hello world"), nil, signer); err != nil {
+		t.Fatalf("UntaggedSign1Message.SignDetached() error = %v", err)
+	}
+	if err := detached.VerifyDetached([]byte("hello world"), nil, verifier); err != nil {
+		t.Errorf("UntaggedSign1Message.VerifyDetached() error = %v", err)
+	}
+	if err := detached.VerifyDetached([]byte("tampered"), nil, verifier); err != ErrVerification {
+		t.Errorf("UntaggedSign1Message.VerifyDetached() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// nil message
+	var nilMsg *UntaggedSign1Message
+	if err := nilMsg.Sign(rand.Reader, nil, signer); err == nil {
+		t.Error("UntaggedSign1Message.Sign() error = nil, wantErr true")
+	}
+	if err := nilMsg.Verify(nil, verifier); err == nil {
+		t.Error("UntaggedSign1Message.Verify() error = nil, wantErr true")
+	}
+}
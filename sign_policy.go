@@ -0,0 +1,192 @@
+package cose
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// SignaturePolicy specifies the signatures of a SignMessage required to be
+// verified by the VerifyWithPolicy methods.
+// The zero value requires all the signatures to be verified, as Verify does.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+type SignaturePolicy struct {
+	// Threshold is the minimum number of distinct signers whose signatures are
+	// required to be verified.
+	Threshold int
+
+	// RequiredKeyIDs is the list of the key identifiers whose signatures are
+	// required to be verified, in addition to Threshold.
+	// A signature counts for its key identifier only if it is verified by the
+	// key bound to that identifier, so RequiredKeyIDs is only supported by
+	// VerifyWithPolicyResolver with a resolver looking up keys by kid, such as
+	// KeyIDVerifiers, KeyIDResolver or KeySet.
+	RequiredKeyIDs [][]byte
+}
+
+// requireAll reports whether all the signatures are required to be verified.
+func (p *SignaturePolicy) requireAll() bool {
+	return p.Threshold <= 0 && len(p.RequiredKeyIDs) == 0
+}
+
+// SignatureResult is the verification result of a signature of a SignMessage.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+type SignatureResult struct {
+	// Index is the index of the signature in SignMessage.Signatures.
+	Index int
+
+	// KeyID is the key identifier of the signer, or nil if it is not known
+	// or not bound to the verifier.
+	KeyID []byte
+
+	// Err is nil if the signature is verified, or the reason why it is not.
+	Err error
+}
+
+// PolicyResult reports the verification of the signatures of a SignMessage
+// against a SignaturePolicy.
+//
+// # Experimental
+//
+// Notice: The COSE Sign API is EXPERIMENTAL and may be changed or removed in a
+// later release.
+type PolicyResult struct {
+	// Signatures contains the result of each signature, in the order of
+	// SignMessage.Signatures.
+	Signatures []SignatureResult
+
+	// Signers is the number of distinct signers whose signatures are verified.
+	// Multiple signatures of the same signer are counted once.
+	Signers int
+}
+
+// Verified returns the results of the verified signatures.
+func (r *PolicyResult) Verified() []SignatureResult {
+	var verified []SignatureResult
+	for _, sig := range r.Signatures {
+		if sig.Err == nil {
+			verified = append(verified, sig)
+		}
+	}
+	return verified
+}
+
+// check ensures the result satisfies the policy.
+func (r *PolicyResult) check(policy SignaturePolicy) error {
+	if policy.requireAll() {
+		for _, sig := range r.Signatures {
+			if sig.Err != nil {
+				return fmt.Errorf("signature %d: %w", sig.Index, sig.Err)
+			}
+		}
+		return nil
+	}
+	if r.Signers < policy.Threshold {
+		return fmt.Errorf("%w: %d of %d required signers verified", ErrVerification, r.Signers, policy.Threshold)
+	}
+	for _, kid := range policy.RequiredKeyIDs {
+		found := false
+		for _, sig := range r.Signatures {
+			if sig.Err == nil && sig.KeyID != nil && bytes.Equal(sig.KeyID, kid) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: no verified signature for kid %x", ErrVerification, kid)
+		}
+	}
+	return nil
+}
+
+// signatureVerification verifies the signature at the given index, and returns
+// the key identifier and the identity of its signer.
+type signatureVerification func(index int, sig *Signature, protected cbor.RawMessage, payload, external []byte) (kid []byte, signer interface{}, err error)
+
+// verifyWithPolicy verifies all the signatures of the SignMessage, and checks
+// the results against the policy.
+func (m *SignMessage) verifyWithPolicy(external []byte, policy SignaturePolicy, verify signatureVerification) (*PolicyResult, error) {
+	if m.Payload == nil {
+		return nil, ErrMissingPayload
+	}
+	if len(m.Signatures) == 0 {
+		return nil, ErrNoSignatures
+	}
+	var protected cbor.RawMessage
+	protected, err := m.Headers.MarshalProtected()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PolicyResult{
+		Signatures: make([]SignatureResult, len(m.Signatures)),
+	}
+	var signers []interface{}
+	for i, sig := range m.Signatures {
+		sigResult := SignatureResult{Index: i}
+		if sig == nil {
+			sigResult.Err = errors.New("verifying nil Signature")
+		} else {
+			var signer interface{}
+			sigResult.KeyID, signer, sigResult.Err = verify(i, sig, protected, m.Payload, external)
+			if sigResult.Err == nil && !containsSigner(signers, signer) {
+				signers = append(signers, signer)
+			}
+		}
+		result.Signatures[i] = sigResult
+	}
+	result.Signers = len(signers)
+	return result, result.check(policy)
+}
+
+// signatureIndex identifies a signer by the index of its signature, when the
+// signer cannot be identified otherwise.
+type signatureIndex int
+
+// resolvedSigner returns the identity of the signer of a signature verified by
+// the verifier resolved from its headers: the public key of the verifier if
+// provided and comparable, or the key identifier otherwise.
+func resolvedSigner(verifier Verifier, kid []byte, index int) interface{} {
+	if provider, ok := verifier.(interface{ PublicKey() crypto.PublicKey }); ok {
+		if key, ok := provider.PublicKey().(interface{ Equal(crypto.PublicKey) bool }); ok {
+			return key
+		}
+	}
+	if kid != nil {
+		return string(kid)
+	}
+	return signatureIndex(index)
+}
+
+// containsSigner reports whether the signer is in signers.
+func containsSigner(signers []interface{}, signer interface{}) bool {
+	for _, s := range signers {
+		if sameSigner(s, signer) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSigner reports whether a and b identify the same signer.
+func sameSigner(a, b interface{}) bool {
+	if key, ok := a.(interface{ Equal(crypto.PublicKey) bool }); ok {
+		return key.Equal(b)
+	}
+	if _, ok := b.(interface{ Equal(crypto.PublicKey) bool }); ok {
+		return false
+	}
+	// other identities are of comparable types
+	return a == b
+}
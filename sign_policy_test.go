@@ -0,0 +1,137 @@
+package cose
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPolicyResult_check(t *testing.T) {
+	failed := errors.New("failed")
+	result := &PolicyResult{
+		Signatures: []SignatureResult{
+			{Index: 0, KeyID: []byte("1")},
+			{Index: 1, KeyID: []byte("2"), Err: failed},
+			{Index: 2, KeyID: []byte("3")},
+			{Index: 3},
+		},
+		Signers: 3,
+	}
+	tests := []struct {
+		name    string
+		policy  SignaturePolicy
+		wantErr error
+	}{
+		{
+			name:    "all signatures required",
+			policy:  SignaturePolicy{},
+			wantErr: failed,
+		},
+		{
+			name:   "threshold met",
+			policy: SignaturePolicy{Threshold: 3},
+		},
+		{
+			name:    "threshold not met",
+			policy:  SignaturePolicy{Threshold: 4},
+			wantErr: ErrVerification,
+		},
+		{
+			name:   "required kids verified",
+			policy: SignaturePolicy{RequiredKeyIDs: [][]byte{[]byte("1"), []byte("3")}},
+		},
+		{
+			name:    "required kid not verified",
+			policy:  SignaturePolicy{RequiredKeyIDs: [][]byte{[]byte("1"), []byte("2")}},
+			wantErr: ErrVerification,
+		},
+		{
+			name:    "required kid absent",
+			policy:  SignaturePolicy{RequiredKeyIDs: [][]byte{[]byte("4")}},
+			wantErr: ErrVerification,
+		},
+		{
+			name:    "threshold met but required kid not verified",
+			policy:  SignaturePolicy{Threshold: 1, RequiredKeyIDs: [][]byte{[]byte("2")}},
+			wantErr: ErrVerification,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := result.check(tt.policy)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("PolicyResult.check() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PolicyResult.check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	want := []SignatureResult{result.Signatures[0], result.Signatures[2], result.Signatures[3]}
+	if got := result.Verified(); !reflect.DeepEqual(got, want) {
+		t.Errorf("PolicyResult.Verified() = %v, want %v", got, want)
+	}
+}
+
+func Test_sameSigner(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	otherKey := generateTestECDSAKey(t)
+	pub, _ := generateTestEd25519Key(t)
+	tests := []struct {
+		name string
+		a    interface{}
+		b    interface{}
+		want bool
+	}{
+		{
+			name: "same public key",
+			a:    key.Public(),
+			b:    &key.PublicKey,
+			want: true,
+		},
+		{
+			name: "different public keys",
+			a:    key.Public(),
+			b:    otherKey.Public(),
+		},
+		{
+			name: "same ed25519 public key",
+			a:    pub,
+			b:    pub,
+			want: true,
+		},
+		{
+			name: "public key and key identifier",
+			a:    "1",
+			b:    pub,
+		},
+		{
+			name: "same key identifier",
+			a:    "1",
+			b:    "1",
+			want: true,
+		},
+		{
+			name: "different signature indexes",
+			a:    signatureIndex(0),
+			b:    signatureIndex(1),
+		},
+		{
+			name: "same verifier index",
+			a:    1,
+			b:    1,
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameSigner(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameSigner() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
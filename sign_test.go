@@ -2,7 +2,9 @@ package cose
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/rand"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -1642,6 +1644,121 @@ func TestSignMessage_UnmarshalCBOR(t *testing.T) {
 	}
 }
 
+func TestSignMessage_UnmarshalCBORWithOptions(t *testing.T) {
+	// test nil pointer
+	t.Run("nil SignMessage pointer", func(t *testing.T) {
+		var msg *SignMessage
+		data := []byte{
+			0xd8, 0x62, 0x84, 0x40, 0xa0, 0xf6,
+			0x81, 0x83, 0x40, 0xa0, 0x41, 0x00,
+		}
+		if err := msg.UnmarshalCBORWithOptions(data, DecOptions{}); err == nil {
+			t.Errorf("want error on nil *SignMessage")
+		}
+	})
+
+	// test others
+	msg := &SignMessage{
+		Headers: Headers{
+			Unprotected: UnprotectedHeader{
+				HeaderLabelContentType: int64(42),
+			},
+		},
+		Payload: []byte("foo"),
+		Signatures: []*Signature{
+			{
+				Headers: Headers{
+					Protected: ProtectedHeader{
+						HeaderLabelAlgorithm: AlgorithmES256,
+					},
+					Unprotected: UnprotectedHeader{
+						HeaderLabelKeyID:       []byte("42"),
+						HeaderLabelContentType: int64(42),
+					},
+				},
+				Signature: []byte("bar"),
+			},
+		},
+	}
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("SignMessage.MarshalCBOR() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		opts    DecOptions
+		wantErr bool
+	}{
+		{
+			name: "default limits",
+			opts: DecOptions{},
+		},
+		{
+			name: "within limits",
+			opts: DecOptions{
+				MaxMessageSize: len(data),
+				MaxHeaders:     2,
+			},
+		},
+		{
+			name:    "message size exceeded",
+			opts:    DecOptions{MaxMessageSize: len(data) - 1},
+			wantErr: true,
+		},
+		{
+			name:    "signature headers exceeded",
+			opts:    DecOptions{MaxHeaders: 1},
+			wantErr: true,
+		},
+		{
+			name:    "invalid options",
+			opts:    DecOptions{MaxHeaders: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got SignMessage
+			err := got.UnmarshalCBORWithOptions(data, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SignMessage.UnmarshalCBORWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(got.Signatures) != 1 {
+				t.Errorf("SignMessage.UnmarshalCBORWithOptions() got %d signatures, want 1", len(got.Signatures))
+			}
+		})
+	}
+}
+
+func TestSignMessage_UnmarshalCBORWithOptions_Deterministic(t *testing.T) {
+	data := []byte{
+		0xd8, 0x62, // tag
+		0x84,
+		0x40,                   // protected
+		0xa0,                   // unprotected
+		0x43, 0x66, 0x6f, 0x6f, // payload
+		0x81, // signatures
+		0x83,
+		0x44, 0xa1, 0x01, 0x38, 0x06, // protected
+		0xa0,                   // unprotected
+		0x43, 0x62, 0x61, 0x72, // signature
+	}
+	var msg SignMessage
+	if err := msg.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("SignMessage.UnmarshalCBOR() error = %v", err)
+	}
+	err := msg.UnmarshalCBORWithOptions(data, DecOptions{Deterministic: true})
+	if !errors.Is(err, ErrNonDeterministicEncoding) {
+		t.Errorf("SignMessage.UnmarshalCBORWithOptions() error = %v, wantErr %v", err, ErrNonDeterministicEncoding)
+	}
+
+	// fix the signature protected header
+	data = append(data[:11], 0x43, 0xa1, 0x01, 0x26, 0xa0, 0x43, 0x62, 0x61, 0x72)
+	if err := msg.UnmarshalCBORWithOptions(data, DecOptions{Deterministic: true}); err != nil {
+		t.Errorf("SignMessage.UnmarshalCBORWithOptions() error = %v", err)
+	}
+}
+
 func TestSignMessage_Sign(t *testing.T) {
 	// generate key and set up signer / verifier
 	gen := func(alg Algorithm) (Signer, Verifier) {
@@ -2189,3 +2306,948 @@ func TestSignMessage_Verify(t *testing.T) {
 		}
 	})
 }
+
+func TestSignMessage_SignDetached(t *testing.T) {
+	// generate key and set up signer / verifier
+	gen := func(alg Algorithm) (Signer, Verifier) {
+		key := generateTestECDSAKey(t)
+		signer, err := NewSigner(alg, key)
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		verifier, err := NewVerifier(alg, key.Public())
+		if err != nil {
+			t.Fatalf("NewVerifier() error = %v", err)
+		}
+		return signer, verifier
+	}
+	algorithms := []Algorithm{AlgorithmES256, AlgorithmES512}
+	signers := make([]Signer, 2)
+	verifiers := make([]Verifier, 2)
+	for i, alg := range algorithms {
+		signers[i], verifiers[i] = gen(alg)
+	}
+	newMessage := func() *SignMessage {
+		msg := NewSignMessage()
+		for _, alg := range algorithms {
+			sig := NewSignature()
+			sig.Headers.Protected.SetAlgorithm(alg)
+			msg.Signatures = append(msg.Signatures, sig)
+		}
+		return msg
+	}
+	detached := []byte("hello world")
+
+	tests := []struct {
+		name             string
+		msg              *SignMessage
+		detached         []byte
+		externalOnSign   []byte
+		detachedOnVerify []byte
+		externalOnVerify []byte
+		wantSignErr      bool
+		wantVerifyErr    bool
+	}{
+		{
+			name:             "round trip",
+			msg:              newMessage(),
+			detached:         detached,
+			detachedOnVerify: detached,
+		},
+		{
+			name:             "round trip with external",
+			msg:              newMessage(),
+			detached:         detached,
+			externalOnSign:   []byte("foo"),
+			detachedOnVerify: detached,
+			externalOnVerify: []byte("foo"),
+		},
+		{
+			name:             "detached payload mismatch",
+			msg:              newMessage(),
+			detached:         detached,
+			detachedOnVerify: []byte("foo"),
+			wantVerifyErr:    true,
+		},
+		{
+			name:             "missing detached payload on verify",
+			msg:              newMessage(),
+			detached:         detached,
+			detachedOnVerify: nil,
+			wantVerifyErr:    true,
+		},
+		{
+			name:        "missing detached payload on sign",
+			msg:         newMessage(),
+			wantSignErr: true,
+		},
+		{
+			name: "attached payload",
+			msg: func() *SignMessage {
+				msg := newMessage()
+				msg.Payload = []byte("foo")
+				return msg
+			}(),
+			detached:    detached,
+			wantSignErr: true,
+		},
+		{
+			name:        "no signatures",
+			msg:         NewSignMessage(),
+			detached:    detached,
+			wantSignErr: true,
+		},
+		{
+			name:        "nil message",
+			msg:         nil,
+			detached:    detached,
+			wantSignErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.SignDetached(rand.Reader, tt.detached, tt.externalOnSign, signers...)
+			if (err != nil) != tt.wantSignErr {
+				t.Fatalf("SignMessage.SignDetached() error = %v, wantErr %v", err, tt.wantSignErr)
+			}
+			if tt.wantSignErr {
+				return
+			}
+
+			// round trip through CBOR
+			data, err := tt.msg.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("SignMessage.MarshalCBOR() error = %v", err)
+			}
+			var msg SignMessage
+			if err := msg.UnmarshalCBOR(data); err != nil {
+				t.Fatalf("SignMessage.UnmarshalCBOR() error = %v", err)
+			}
+			if msg.Payload != nil {
+				t.Fatalf("SignMessage.Payload = %v, want nil", msg.Payload)
+			}
+			if err := msg.Verify(tt.externalOnVerify, verifiers...); err != ErrMissingPayload {
+				t.Errorf("SignMessage.Verify() error = %v, wantErr %v", err, ErrMissingPayload)
+			}
+
+			err = msg.VerifyDetached(tt.detachedOnVerify, tt.externalOnVerify, verifiers...)
+			if (err != nil) != tt.wantVerifyErr {
+				t.Errorf("SignMessage.VerifyDetached() error = %v, wantErr %v", err, tt.wantVerifyErr)
+			}
+		})
+	}
+
+	// special cases
+	t.Run("attached payload on verify", func(t *testing.T) {
+		msg := newMessage()
+		msg.Payload = detached
+		if err := msg.Sign(rand.Reader, nil, signers...); err != nil {
+			t.Fatalf("SignMessage.Sign() error = %v", err)
+		}
+		if err := msg.VerifyDetached(detached, nil, verifiers...); err == nil {
+			t.Error("SignMessage.VerifyDetached() error = nil, wantErr true")
+		}
+	})
+}
+
+func TestSignMessage_VerifyWithResolver(t *testing.T) {
+	keys := []crypto.Signer{generateTestECDSAKey(t), generateTestECDSAKey(t)}
+	resolver := KeyIDResolver{}
+	msg := NewSignMessage()
+	msg.Payload = []byte("hello world")
+	signers := make([]Signer, len(keys))
+	for i, key := range keys {
+		kid := string(rune('a' + i))
+		resolver[kid] = key.Public()
+		signer, err := NewSigner(AlgorithmES256, key)
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		signers[i] = signer
+		sig := NewSignature()
+		sig.Headers.Protected.SetAlgorithm(AlgorithmES256)
+		sig.Headers.SetKeyID([]byte(kid))
+		msg.Signatures = append(msg.Signatures, sig)
+	}
+	if err := msg.Sign(rand.Reader, nil, signers...); err != nil {
+		t.Fatalf("SignMessage.Sign() error = %v", err)
+	}
+
+	if err := msg.VerifyWithResolver(nil, resolver); err != nil {
+		t.Errorf("SignMessage.VerifyWithResolver() error = %v", err)
+	}
+
+	// one key unknown
+	delete(resolver, "b")
+	if err := msg.VerifyWithResolver(nil, resolver); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("SignMessage.VerifyWithResolver() error = %v, wantErr %v", err, ErrKeyNotFound)
+	}
+
+	// one key wrong
+	resolver["b"] = keys[0].Public()
+	if err := msg.VerifyWithResolver(nil, resolver); err != ErrVerification {
+		t.Errorf("SignMessage.VerifyWithResolver() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// nil signature
+	msg.Signatures = append(msg.Signatures, nil)
+	if err := msg.VerifyWithResolver(nil, resolver); err == nil {
+		t.Error("SignMessage.VerifyWithResolver() error = nil, wantErr true")
+	}
+}
+
+func TestSignMessage_VerifyWithOptions(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(AlgorithmES256, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(AlgorithmES256, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	msg := NewSignMessage()
+	msg.Headers.Protected[HeaderLabelContentType] = "text/plain"
+	msg.Payload = []byte("hello world")
+	sig := NewSignature()
+	sig.Headers.Protected.SetAlgorithm(AlgorithmES256)
+	sig.Headers.Unprotected[HeaderLabelKeyID] = []byte("1")
+	msg.Signatures = append(msg.Signatures, sig)
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		t.Fatalf("SignMessage.Sign() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		opts      VerifyOptions
+		verifiers []Verifier
+		wantErr   bool
+	}{
+		{
+			name: "valid",
+			opts: VerifyOptions{
+				Algorithms:               []Algorithm{AlgorithmES256},
+				RequiredProtectedHeaders: []interface{}{HeaderLabelContentType, HeaderLabelAlgorithm},
+			},
+			verifiers: []Verifier{verifier},
+		},
+		{
+			name: "algorithm not allowed",
+			opts: VerifyOptions{
+				Algorithms: []Algorithm{AlgorithmPS256},
+			},
+			verifiers: []Verifier{verifier},
+			wantErr:   true,
+		},
+		{
+			name: "forbidden unprotected header in signature",
+			opts: VerifyOptions{
+				ForbiddenUnprotectedHeaders: []interface{}{HeaderLabelKeyID},
+			},
+			verifiers: []Verifier{verifier},
+			wantErr:   true,
+		},
+		{
+			name:      "verifiers mismatch",
+			opts:      VerifyOptions{},
+			verifiers: []Verifier{verifier, verifier},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := msg.VerifyWithOptions(nil, tt.opts, tt.verifiers...); (err != nil) != tt.wantErr {
+				t.Errorf("SignMessage.VerifyWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignMessage_CheckCritical(t *testing.T) {
+	newMessage := func(bodyCritical, signatureCritical interface{}) *SignMessage {
+		msg := NewSignMessage()
+		sig := NewSignature()
+		sig.Headers.Protected.SetAlgorithm(AlgorithmES256)
+		if bodyCritical != nil {
+			msg.Headers.Protected[HeaderLabelCritical] = []interface{}{bodyCritical}
+			msg.Headers.Protected[bodyCritical] = "foo"
+		}
+		if signatureCritical != nil {
+			sig.Headers.Protected[HeaderLabelCritical] = []interface{}{signatureCritical}
+			sig.Headers.Protected[signatureCritical] = "foo"
+		}
+		msg.Signatures = append(msg.Signatures, sig)
+		return msg
+	}
+	tests := []struct {
+		name       string
+		m          *SignMessage
+		understood []interface{}
+		wantLabels []interface{}
+	}{
+		{
+			name: "no critical headers",
+			m:    newMessage(nil, nil),
+		},
+		{
+			name:       "understood body and signature critical headers",
+			m:          newMessage("foo", "bar"),
+			understood: []interface{}{"foo", "bar"},
+		},
+		{
+			name:       "body critical header not understood",
+			m:          newMessage("foo", "bar"),
+			understood: []interface{}{"bar"},
+			wantLabels: []interface{}{"foo"},
+		},
+		{
+			name:       "signature critical header not understood",
+			m:          newMessage("foo", "bar"),
+			understood: []interface{}{"foo"},
+			wantLabels: []interface{}{"bar"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.m.CheckCritical(tt.understood...)
+			if tt.wantLabels == nil {
+				if err != nil {
+					t.Errorf("SignMessage.CheckCritical() error = %v", err)
+				}
+				return
+			}
+			var critErr *CriticalHeaderError
+			if !errors.As(err, &critErr) {
+				t.Fatalf("SignMessage.CheckCritical() error = %v, want *CriticalHeaderError", err)
+			}
+			if !reflect.DeepEqual(critErr.Labels, tt.wantLabels) {
+				t.Errorf("CriticalHeaderError.Labels = %v, want %v", critErr.Labels, tt.wantLabels)
+			}
+		})
+	}
+
+	var nilMsg *SignMessage
+	if err := nilMsg.CheckCritical(); err == nil {
+		t.Error("SignMessage.CheckCritical() error = nil, wantErr true")
+	}
+	var nilSig *Signature
+	if err := nilSig.CheckCritical(); err == nil {
+		t.Error("Signature.CheckCritical() error = nil, wantErr true")
+	}
+}
+
+func TestSignMessage_Countersign(t *testing.T) {
+	// generate keys and set up signers / verifiers
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(AlgorithmES256, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(AlgorithmES256, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	counterPub, counterKey := generateTestEd25519Key(t)
+	counterSigner, err := NewSigner(AlgorithmEd25519, counterKey)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	counterVerifier, err := NewVerifier(AlgorithmEd25519, counterPub)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	newCountersignature := func() *CountersignatureV2 {
+		countersig := NewCountersignatureV2()
+		countersig.Headers.Protected.SetAlgorithm(AlgorithmEd25519)
+		return countersig
+	}
+
+	// sign and countersign both the message and the signature
+	msg := NewSignMessage()
+	msg.Payload = []byte("hello world")
+	sig := NewSignature()
+	sig.Headers.Protected.SetAlgorithm(AlgorithmES256)
+	msg.Signatures = append(msg.Signatures, sig)
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		t.Fatalf("SignMessage.Sign() error = %v", err)
+	}
+	if err := msg.Countersign(rand.Reader, nil, counterSigner, newCountersignature()); err != nil {
+		t.Fatalf("SignMessage.Countersign() error = %v", err)
+	}
+	if err := msg.Countersign0(rand.Reader, nil, counterSigner); err != nil {
+		t.Fatalf("SignMessage.Countersign0() error = %v", err)
+	}
+	if err := sig.Countersign(rand.Reader, nil, counterSigner, newCountersignature()); err != nil {
+		t.Fatalf("Signature.Countersign() error = %v", err)
+	}
+	if err := sig.Countersign0(rand.Reader, nil, counterSigner); err != nil {
+		t.Fatalf("Signature.Countersign0() error = %v", err)
+	}
+
+	// round trip
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("SignMessage.MarshalCBOR() error = %v", err)
+	}
+	var decoded SignMessage
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("SignMessage.UnmarshalCBOR() error = %v", err)
+	}
+	if err := decoded.Verify(nil, verifier); err != nil {
+		t.Errorf("SignMessage.Verify() error = %v", err)
+	}
+	if err := decoded.VerifyCountersignatures(nil, counterVerifier); err != nil {
+		t.Errorf("SignMessage.VerifyCountersignatures() error = %v", err)
+	}
+	if err := decoded.VerifyCountersignature0(nil, counterVerifier); err != nil {
+		t.Errorf("SignMessage.VerifyCountersignature0() error = %v", err)
+	}
+	decodedSig := decoded.Signatures[0]
+	if err := decodedSig.VerifyCountersignatures(nil, counterVerifier); err != nil {
+		t.Errorf("Signature.VerifyCountersignatures() error = %v", err)
+	}
+	if err := decodedSig.VerifyCountersignature0(nil, counterVerifier); err != nil {
+		t.Errorf("Signature.VerifyCountersignature0() error = %v", err)
+	}
+
+	// the message countersignature does not cover the signatures, while the
+	// signature countersignature does
+	decodedSig.Signature[0]++
+	if err := decoded.VerifyCountersignatures(nil, counterVerifier); err != nil {
+		t.Errorf("SignMessage.VerifyCountersignatures() error = %v", err)
+	}
+	if err := decodedSig.VerifyCountersignatures(nil, counterVerifier); !errors.Is(err, ErrVerification) {
+		t.Errorf("Signature.VerifyCountersignatures() error = %v, wantErr %v", err, ErrVerification)
+	}
+	if err := decodedSig.VerifyCountersignature0(nil, counterVerifier); err != ErrVerification {
+		t.Errorf("Signature.VerifyCountersignature0() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// tampered payload
+	decoded.Payload[0]++
+	if err := decoded.VerifyCountersignatures(nil, counterVerifier); !errors.Is(err, ErrVerification) {
+		t.Errorf("SignMessage.VerifyCountersignatures() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// nil message and signature
+	var nilMsg *SignMessage
+	if err := nilMsg.Countersign(rand.Reader, nil, counterSigner, newCountersignature()); err == nil {
+		t.Error("SignMessage.Countersign() error = nil, wantErr true")
+	}
+	if err := nilMsg.VerifyCountersignature0(nil, counterVerifier); err == nil {
+		t.Error("SignMessage.VerifyCountersignature0() error = nil, wantErr true")
+	}
+	var nilSig *Signature
+	if err := nilSig.Countersign0(rand.Reader, nil, counterSigner); err == nil {
+		t.Error("Signature.Countersign0() error = nil, wantErr true")
+	}
+	if err := nilSig.VerifyCountersignatures(nil, counterVerifier); err == nil {
+		t.Error("Signature.VerifyCountersignatures() error = nil, wantErr true")
+	}
+}
+
+func TestSignMessage_VerifyWithPolicy(t *testing.T) {
+	// generate keys and sign a message with the first two keys, and twice with
+	// the second key.
+	var signers []Signer
+	var verifiers []Verifier
+	for i := 0; i < 3; i++ {
+		key := generateTestECDSAKey(t)
+		signer, err := NewSigner(AlgorithmES256, key)
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		verifier, err := NewVerifier(AlgorithmES256, key.Public())
+		if err != nil {
+			t.Fatalf("NewVerifier() error = %v", err)
+		}
+		signers = append(signers, signer)
+		verifiers = append(verifiers, verifier)
+	}
+	msg := NewSignMessage()
+	msg.Payload = []byte("hello world")
+	for _, kid := range []string{"0", "1", "1"} {
+		sig := NewSignature()
+		sig.Headers.Protected.SetAlgorithm(AlgorithmES256)
+		sig.Headers.Protected[HeaderLabelKeyID] = []byte(kid)
+		msg.Signatures = append(msg.Signatures, sig)
+	}
+	if err := msg.Sign(rand.Reader, nil, signers[0], signers[1], signers[1]); err != nil {
+		t.Fatalf("SignMessage.Sign() error = %v", err)
+	}
+
+	// verifiers in any order
+	result, err := msg.VerifyWithPolicy(nil, SignaturePolicy{Threshold: 2}, verifiers[2], verifiers[1], verifiers[0])
+	if err != nil {
+		t.Fatalf("SignMessage.VerifyWithPolicy() error = %v", err)
+	}
+	if result.Signers != 2 {
+		t.Errorf("PolicyResult.Signers = %d, want 2", result.Signers)
+	}
+	for i, sig := range result.Signatures {
+		if sig.Index != i || sig.Err != nil {
+			t.Errorf("PolicyResult.Signatures[%d] = %+v", i, sig)
+		}
+	}
+
+	// the same signer is counted once
+	if _, err := msg.VerifyWithPolicy(nil, SignaturePolicy{Threshold: 3}, verifiers...); !errors.Is(err, ErrVerification) {
+		t.Errorf("SignMessage.VerifyWithPolicy() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// unknown signer
+	result, err = msg.VerifyWithPolicy(nil, SignaturePolicy{Threshold: 1}, verifiers[1])
+	if err != nil {
+		t.Fatalf("SignMessage.VerifyWithPolicy() error = %v", err)
+	}
+	if got := result.Signatures[0].Err; !errors.Is(got, ErrVerification) {
+		t.Errorf("PolicyResult.Signatures[0].Err = %v, want %v", got, ErrVerification)
+	}
+	if got := len(result.Verified()); got != 2 {
+		t.Errorf("PolicyResult.Verified() = %d signatures, want 2", got)
+	}
+	for _, sig := range result.Verified() {
+		if sig.KeyID != nil {
+			t.Errorf("PolicyResult.Signatures[%d].KeyID = %s, want nil", sig.Index, sig.KeyID)
+		}
+	}
+
+	// all signatures required by default
+	if _, err := msg.VerifyWithPolicy(nil, SignaturePolicy{}, verifiers[1]); !errors.Is(err, ErrVerification) {
+		t.Errorf("SignMessage.VerifyWithPolicy() error = %v, wantErr %v", err, ErrVerification)
+	}
+	if _, err := msg.VerifyWithPolicy(nil, SignaturePolicy{}, verifiers...); err != nil {
+		t.Errorf("SignMessage.VerifyWithPolicy() error = %v", err)
+	}
+
+	// required kid not bound to the verifiers
+	if _, err := msg.VerifyWithPolicy(nil, SignaturePolicy{RequiredKeyIDs: [][]byte{[]byte("1")}}, verifiers...); err == nil {
+		t.Error("SignMessage.VerifyWithPolicy() error = nil, wantErr true")
+	}
+
+	// invalid messages
+	if _, err := msg.VerifyWithPolicy(nil, SignaturePolicy{}); err == nil {
+		t.Error("SignMessage.VerifyWithPolicy() error = nil, wantErr true")
+	}
+	if _, err := (&SignMessage{Payload: []byte("foo")}).VerifyWithPolicy(nil, SignaturePolicy{}, verifiers...); err != ErrNoSignatures {
+		t.Errorf("SignMessage.VerifyWithPolicy() error = %v, wantErr %v", err, ErrNoSignatures)
+	}
+	if _, err := (&SignMessage{Signatures: msg.Signatures}).VerifyWithPolicy(nil, SignaturePolicy{}, verifiers...); err != ErrMissingPayload {
+		t.Errorf("SignMessage.VerifyWithPolicy() error = %v, wantErr %v", err, ErrMissingPayload)
+	}
+	var nilMsg *SignMessage
+	if _, err := nilMsg.VerifyWithPolicy(nil, SignaturePolicy{}, verifiers...); err == nil {
+		t.Error("SignMessage.VerifyWithPolicy() error = nil, wantErr true")
+	}
+}
+
+func TestSignMessage_VerifyWithPolicyResolver(t *testing.T) {
+	// generate keys and sign a message with each key, where the last signature
+	// is by the first key under another kid.
+	resolver := KeyIDResolver{}
+	var signers []Signer
+	for _, kid := range []string{"0", "1", "2"} {
+		key := generateTestECDSAKey(t)
+		signer, err := NewSigner(AlgorithmES256, key)
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		resolver[kid] = key.Public()
+		signers = append(signers, signer)
+	}
+	resolver["alias"] = resolver["0"]
+	msg := NewSignMessage()
+	msg.Payload = []byte("hello world")
+	for _, kid := range []string{"0", "1", "unknown", "alias"} {
+		sig := NewSignature()
+		sig.Headers.Protected.SetAlgorithm(AlgorithmES256)
+		sig.Headers.Unprotected[HeaderLabelKeyID] = []byte(kid)
+		msg.Signatures = append(msg.Signatures, sig)
+	}
+	if err := msg.Sign(rand.Reader, nil, signers[0], signers[1], signers[2], signers[0]); err != nil {
+		t.Fatalf("SignMessage.Sign() error = %v", err)
+	}
+
+	result, err := msg.VerifyWithPolicyResolver(nil, SignaturePolicy{Threshold: 2, RequiredKeyIDs: [][]byte{[]byte("1")}}, resolver)
+	if err != nil {
+		t.Fatalf("SignMessage.VerifyWithPolicyResolver() error = %v", err)
+	}
+	if result.Signers != 2 {
+		t.Errorf("PolicyResult.Signers = %d, want 2", result.Signers)
+	}
+	if got := result.Signatures[2].Err; !errors.Is(got, ErrKeyNotFound) {
+		t.Errorf("PolicyResult.Signatures[2].Err = %v, want %v", got, ErrKeyNotFound)
+	}
+	if got := result.Signatures[3].KeyID; !bytes.Equal(got, []byte("alias")) {
+		t.Errorf("PolicyResult.Signatures[3].KeyID = %s, want alias", got)
+	}
+
+	// the same public key is counted once
+	if _, err := msg.VerifyWithPolicyResolver(nil, SignaturePolicy{Threshold: 3}, resolver); !errors.Is(err, ErrVerification) {
+		t.Errorf("SignMessage.VerifyWithPolicyResolver() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// all signatures required by default
+	if _, err := msg.VerifyWithPolicyResolver(nil, SignaturePolicy{}, resolver); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("SignMessage.VerifyWithPolicyResolver() error = %v, wantErr %v", err, ErrKeyNotFound)
+	}
+
+	// wrong key for a kid
+	resolver["1"] = resolver["2"]
+	if _, err := msg.VerifyWithPolicyResolver(nil, SignaturePolicy{RequiredKeyIDs: [][]byte{[]byte("1")}}, resolver); !errors.Is(err, ErrVerification) {
+		t.Errorf("SignMessage.VerifyWithPolicyResolver() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// required kid with a resolver not bound to key identifiers
+	resolverFunc := VerifierResolverFunc(resolver.ResolveVerifier)
+	if _, err := msg.VerifyWithPolicyResolver(nil, SignaturePolicy{RequiredKeyIDs: [][]byte{[]byte("0")}}, resolverFunc); err == nil {
+		t.Error("SignMessage.VerifyWithPolicyResolver() error = nil, wantErr true")
+	}
+	if _, err := msg.VerifyWithPolicyResolver(nil, SignaturePolicy{Threshold: 1}, resolverFunc); err != nil {
+		t.Errorf("SignMessage.VerifyWithPolicyResolver() error = %v", err)
+	}
+
+	var nilMsg *SignMessage
+	if _, err := nilMsg.VerifyWithPolicyResolver(nil, SignaturePolicy{}, resolver); err == nil {
+		t.Error("SignMessage.VerifyWithPolicyResolver() error = nil, wantErr true")
+	}
+}
+
+func TestSignMessage_VerifyWithPolicyResolver_KeyIDVerifiers(t *testing.T) {
+	// sign a message with the key of "bob" claiming the kid of "alice" in the
+	// protected header.
+	verifiers := KeyIDVerifiers{}
+	var signers []Signer
+	for _, kid := range []string{"alice", "bob"} {
+		key := generateTestECDSAKey(t)
+		signer, err := NewSigner(AlgorithmES256, key)
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		verifier, err := NewVerifier(AlgorithmES256, key.Public())
+		if err != nil {
+			t.Fatalf("NewVerifier() error = %v", err)
+		}
+		verifiers[kid] = verifier
+		signers = append(signers, signer)
+	}
+	msg := NewSignMessage()
+	msg.Payload = []byte("hello world")
+	sig := NewSignature()
+	sig.Headers.Protected.SetAlgorithm(AlgorithmES256)
+	sig.Headers.Protected[HeaderLabelKeyID] = []byte("alice")
+	msg.Signatures = append(msg.Signatures, sig)
+	if err := msg.Sign(rand.Reader, nil, signers[1]); err != nil {
+		t.Fatalf("SignMessage.Sign() error = %v", err)
+	}
+
+	// wrong kid
+	policy := SignaturePolicy{RequiredKeyIDs: [][]byte{[]byte("alice")}}
+	result, err := msg.VerifyWithPolicyResolver(nil, policy, verifiers)
+	if !errors.Is(err, ErrVerification) {
+		t.Errorf("SignMessage.VerifyWithPolicyResolver() error = %v, wantErr %v", err, ErrVerification)
+	}
+	if got := result.Signatures[0].Err; !errors.Is(got, ErrVerification) {
+		t.Errorf("PolicyResult.Signatures[0].Err = %v, want %v", got, ErrVerification)
+	}
+
+	// right kid
+	msg.Signatures = []*Signature{NewSignature()}
+	msg.Signatures[0].Headers.Protected.SetAlgorithm(AlgorithmES256)
+	msg.Signatures[0].Headers.Protected[HeaderLabelKeyID] = []byte("alice")
+	if err := msg.Sign(rand.Reader, nil, signers[0]); err != nil {
+		t.Fatalf("SignMessage.Sign() error = %v", err)
+	}
+	if _, err := msg.VerifyWithPolicyResolver(nil, policy, verifiers); err != nil {
+		t.Errorf("SignMessage.VerifyWithPolicyResolver() error = %v", err)
+	}
+}
+
+func TestSignMessage_AddSignature(t *testing.T) {
+	// generate keys and set up signers / verifiers
+	var signers []Signer
+	var verifiers []Verifier
+	for i := 0; i < 3; i++ {
+		key := generateTestECDSAKey(t)
+		signer, err := NewSigner(AlgorithmES256, key)
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		verifier, err := NewVerifier(AlgorithmES256, key.Public())
+		if err != nil {
+			t.Fatalf("NewVerifier() error = %v", err)
+		}
+		signers = append(signers, signer)
+		verifiers = append(verifiers, verifier)
+	}
+	newHeaders := func(kid string) Headers {
+		return Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmES256,
+			},
+			Unprotected: UnprotectedHeader{
+				HeaderLabelKeyID: []byte(kid),
+			},
+		}
+	}
+
+	// first site signs with a body protected header not encoded in the
+	// canonical order
+	msg := NewSignMessage()
+	msg.Headers.Protected[HeaderLabelContentType] = "x"
+	msg.Headers.Protected[HeaderLabelAlgorithm] = AlgorithmES256
+	msg.Headers.RawProtected = []byte{0x46, 0xa2, 0x03, 0x61, 0x78, 0x01, 0x26}
+	msg.Payload = []byte("hello world")
+	if err := msg.AddSignature(rand.Reader, nil, signers[0], newHeaders("0")); err != nil {
+		t.Fatalf("SignMessage.AddSignature() error = %v", err)
+	}
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("SignMessage.MarshalCBOR() error = %v", err)
+	}
+
+	// second site co-signs the decoded message against its RawProtected
+	var decoded SignMessage
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("SignMessage.UnmarshalCBOR() error = %v", err)
+	}
+	if !bytes.Equal(decoded.Headers.RawProtected, msg.Headers.RawProtected) {
+		t.Fatalf("SignMessage.Headers.RawProtected = %x, want %x", decoded.Headers.RawProtected, msg.Headers.RawProtected)
+	}
+	firstSignature := decoded.Signatures[0].Signature
+	if err := decoded.AddSignature(rand.Reader, nil, signers[1], newHeaders("1")); err != nil {
+		t.Fatalf("SignMessage.AddSignature() error = %v", err)
+	}
+	if got := decoded.Signatures[0].Signature; !bytes.Equal(got, firstSignature) {
+		t.Error("SignMessage.AddSignature() modified an existing signature")
+	}
+	data, err = decoded.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("SignMessage.MarshalCBOR() error = %v", err)
+	}
+	decoded = SignMessage{}
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("SignMessage.UnmarshalCBOR() error = %v", err)
+	}
+	if err := decoded.Verify(nil, verifiers[0], verifiers[1]); err != nil {
+		t.Errorf("SignMessage.Verify() error = %v", err)
+	}
+
+	// replace the second signature
+	if err := decoded.ReplaceSignature(1, rand.Reader, nil, signers[2], newHeaders("2")); err != nil {
+		t.Fatalf("SignMessage.ReplaceSignature() error = %v", err)
+	}
+	if err := decoded.Verify(nil, verifiers[0], verifiers[2]); err != nil {
+		t.Errorf("SignMessage.Verify() error = %v", err)
+	}
+
+	// remove the first signature without affecting the shared array
+	signatures := decoded.Signatures
+	if err := decoded.RemoveSignature(0); err != nil {
+		t.Fatalf("SignMessage.RemoveSignature() error = %v", err)
+	}
+	if err := decoded.Verify(nil, verifiers[2]); err != nil {
+		t.Errorf("SignMessage.Verify() error = %v", err)
+	}
+	if len(signatures) != 2 || signatures[0].Signature == nil || !bytes.Equal(signatures[0].Signature, firstSignature) {
+		t.Error("SignMessage.RemoveSignature() modified the previous signatures slice")
+	}
+
+	// invalid index
+	for _, index := range []int{-1, 1} {
+		if err := decoded.RemoveSignature(index); err == nil {
+			t.Errorf("SignMessage.RemoveSignature(%d) error = nil, wantErr true", index)
+		}
+		if err := decoded.ReplaceSignature(index, rand.Reader, nil, signers[0], newHeaders("0")); err == nil {
+			t.Errorf("SignMessage.ReplaceSignature(%d) error = nil, wantErr true", index)
+		}
+	}
+
+	// algorithm mismatch
+	if err := decoded.AddSignature(rand.Reader, nil, signers[0], Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: AlgorithmES512,
+		},
+	}); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Errorf("SignMessage.AddSignature() error = %v, wantErr %v", err, ErrAlgorithmMismatch)
+	}
+	if len(decoded.Signatures) != 1 {
+		t.Errorf("SignMessage.AddSignature() added a signature on error")
+	}
+
+	// missing payload
+	if err := NewSignMessage().AddSignature(rand.Reader, nil, signers[0], newHeaders("0")); err != ErrMissingPayload {
+		t.Errorf("SignMessage.AddSignature() error = %v, wantErr %v", err, ErrMissingPayload)
+	}
+
+	// nil message
+	var nilMsg *SignMessage
+	if err := nilMsg.AddSignature(rand.Reader, nil, signers[0], newHeaders("0")); err == nil {
+		t.Error("SignMessage.AddSignature() error = nil, wantErr true")
+	}
+	if err := nilMsg.ReplaceSignature(0, rand.Reader, nil, signers[0], newHeaders("0")); err == nil {
+		t.Error("SignMessage.ReplaceSignature() error = nil, wantErr true")
+	}
+	if err := nilMsg.RemoveSignature(0); err == nil {
+		t.Error("SignMessage.RemoveSignature() error = nil, wantErr true")
+	}
+}
+
+func TestSignMessage_AddSignatureDetached(t *testing.T) {
+	// generate keys and set up signers / verifiers
+	var signers []Signer
+	var verifiers []Verifier
+	for i := 0; i < 3; i++ {
+		key := generateTestECDSAKey(t)
+		signer, err := NewSigner(AlgorithmES256, key)
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		verifier, err := NewVerifier(AlgorithmES256, key.Public())
+		if err != nil {
+			t.Fatalf("NewVerifier() error = %v", err)
+		}
+		signers = append(signers, signer)
+		verifiers = append(verifiers, verifier)
+	}
+	headers := Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm: AlgorithmES256,
+		},
+	}
+	detached := []byte("hello world")
+	external := []byte("external")
+
+	// co-sign a message with a detached payload
+	msg := NewSignMessage()
+	for _, signer := range signers[:2] {
+		if err := msg.AddSignatureDetached(rand.Reader, detached, external, signer, headers); err != nil {
+			t.Fatalf("SignMessage.AddSignatureDetached() error = %v", err)
+		}
+	}
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("SignMessage.MarshalCBOR() error = %v", err)
+	}
+	var decoded SignMessage
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("SignMessage.UnmarshalCBOR() error = %v", err)
+	}
+	if decoded.Payload != nil {
+		t.Fatalf("SignMessage.Payload = %v, want nil", decoded.Payload)
+	}
+	if err := decoded.VerifyDetached(detached, external, verifiers[0], verifiers[1]); err != nil {
+		t.Errorf("SignMessage.VerifyDetached() error = %v", err)
+	}
+
+	// replace the second signature
+	if err := decoded.ReplaceSignatureDetached(1, rand.Reader, detached, external, signers[2], headers); err != nil {
+		t.Fatalf("SignMessage.ReplaceSignatureDetached() error = %v", err)
+	}
+	if err := decoded.VerifyDetached(detached, external, verifiers[0], verifiers[2]); err != nil {
+		t.Errorf("SignMessage.VerifyDetached() error = %v", err)
+	}
+	if err := decoded.VerifyDetached([]byte("foo"), external, verifiers[0], verifiers[2]); err != ErrVerification {
+		t.Errorf("SignMessage.VerifyDetached() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// invalid index
+	for _, index := range []int{-1, 2} {
+		if err := decoded.ReplaceSignatureDetached(index, rand.Reader, detached, nil, signers[0], headers); err == nil {
+			t.Errorf("SignMessage.ReplaceSignatureDetached(%d) error = nil, wantErr true", index)
+		}
+	}
+
+	// missing detached payload
+	if err := decoded.AddSignatureDetached(rand.Reader, nil, nil, signers[0], headers); err != ErrMissingPayload {
+		t.Errorf("SignMessage.AddSignatureDetached() error = %v, wantErr %v", err, ErrMissingPayload)
+	}
+	if err := decoded.ReplaceSignatureDetached(0, rand.Reader, nil, nil, signers[0], headers); err != ErrMissingPayload {
+		t.Errorf("SignMessage.ReplaceSignatureDetached() error = %v, wantErr %v", err, ErrMissingPayload)
+	}
+
+	// attached payload
+	attached := NewSignMessage()
+	attached.Payload = detached
+	if err := attached.AddSignatureDetached(rand.Reader, detached, nil, signers[0], headers); err == nil {
+		t.Error("SignMessage.AddSignatureDetached() error = nil, wantErr true")
+	}
+	if err := attached.ReplaceSignatureDetached(0, rand.Reader, detached, nil, signers[0], headers); err == nil {
+		t.Error("SignMessage.ReplaceSignatureDetached() error = nil, wantErr true")
+	}
+
+	// nil message
+	var nilMsg *SignMessage
+	if err := nilMsg.AddSignatureDetached(rand.Reader, detached, nil, signers[0], headers); err == nil {
+		t.Error("SignMessage.AddSignatureDetached() error = nil, wantErr true")
+	}
+	if err := nilMsg.ReplaceSignatureDetached(0, rand.Reader, detached, nil, signers[0], headers); err == nil {
+		t.Error("SignMessage.ReplaceSignatureDetached() error = nil, wantErr true")
+	}
+}
+
+func TestUntaggedSignMessage(t *testing.T) {
+	// generate key and set up signer / verifier
+	alg := AlgorithmES256
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(alg, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	// sign / verify round trip
+	sig := NewSignature()
+	sig.Headers.Protected.SetAlgorithm(alg)
+	msg := &UntaggedSignMessage{
+		Payload:    []byte("hello world"),
+		Signatures: []*Signature{sig},
+	}
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		t.Fatalf("UntaggedSignMessage.Sign() error = %v", err)
+	}
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("UntaggedSignMessage.MarshalCBOR() error = %v", err)
+	}
+	taggedData, err := (*SignMessage)(msg).MarshalCBOR()
+	if err != nil {
+		t.Fatalf("SignMessage.MarshalCBOR() error = %v", err)
+	}
+	if !bytes.Equal(taggedData[2:], data) {
+		t.Errorf("UntaggedSignMessage.MarshalCBOR() = %x, want untagged %x", data, taggedData)
+	}
+	var decoded UntaggedSignMessage
+	if err := decoded.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("UntaggedSignMessage.UnmarshalCBOR() error = %v", err)
+	}
+	if err := decoded.Verify(nil, verifier); err != nil {
+		t.Errorf("UntaggedSignMessage.Verify() error = %v", err)
+	}
+
+	// tagged message rejected
+	if err := decoded.UnmarshalCBOR(taggedData); err == nil {
+		t.Error("UntaggedSignMessage.UnmarshalCBOR() error = nil, wantErr true")
+	}
+	var tagged SignMessage
+	if err := tagged.UnmarshalCBOR(data); err == nil {
+		t.Error("SignMessage.UnmarshalCBOR() error = nil, wantErr true")
+	}
+
+	// no signatures
+	if _, err := (&UntaggedSignMessage{Payload: []byte("foo")}).MarshalCBOR(); err != ErrNoSignatures {
+		t.Errorf("UntaggedSignMessage.MarshalCBOR() error = %v, wantErr %v", err, ErrNoSignatures)
+	}
+
+	// nil message
+	var nilMsg *UntaggedSignMessage
+	if _, err := nilMsg.MarshalCBOR(); err == nil {
+		t.Error("UntaggedSignMessage.MarshalCBOR() error = nil, wantErr true")
+	}
+	if err := nilMsg.UnmarshalCBOR(data); err == nil {
+		t.Error("UntaggedSignMessage.UnmarshalCBOR() error = nil, wantErr true")
+	}
+}
@@ -2,10 +2,6 @@ package cose
 
 import (
 	"crypto"
-	"crypto/ecdsa"
-	"crypto/ed25519"
-	"crypto/rsa"
-	"errors"
 	"fmt"
 	"io"
 )
@@ -23,6 +19,25 @@ type Signer interface {
 	Sign(rand io.Reader, content []byte) ([]byte, error)
 }
 
+// DigestSigner is an interface for private keys to sign digested COSE
+// signatures.
+//
+// The built-in ECDSA and RSASSA-PSS signers returned by NewSigner implement
+// DigestSigner. EdDSA signers do not since PureEdDSA signs the message content
+// rather than its digest.
+type DigestSigner interface {
+	// Algorithm returns the signing algorithm associated with the private key.
+	Algorithm() Algorithm
+
+	// SignDigest signs message digest with the private key, possibly using
+	// entropy from rand.
+	// The digest is computed by the hash function of the signing algorithm.
+	// The resulting signature should follow RFC 8152 section 8.
+	//
+	// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8
+	SignDigest(rand io.Reader, digest []byte) ([]byte, error)
+}
+
 // NewSigner returns a signer with a given signing key.
 // The signing key can be a golang built-in crypto private key, a key in HSM, or
 // a remote KMS.
@@ -32,50 +47,36 @@ type Signer interface {
 //
 // All signing keys implementing `crypto.Signer` with `Public()` returning a
 // public key of type `*rsa.PublicKey`, `*ecdsa.PublicKey`, or
-// `ed25519.PublicKey` are accepted.
+// `ed25519.PublicKey` are accepted by the built-in algorithms.
+// Algorithms registered by RegisterAlgorithm define their own accepted keys.
 //
 // Note: `*rsa.PrivateKey`, `*ecdsa.PrivateKey`, and `ed25519.PrivateKey`
 // implement `crypto.Signer`.
 func NewSigner(alg Algorithm, key crypto.Signer) (Signer, error) {
-	switch alg {
-	case AlgorithmPS256, AlgorithmPS384, AlgorithmPS512:
-		vk, ok := key.Public().(*rsa.PublicKey)
-		if !ok {
-			return nil, fmt.Errorf("%v: %w", alg, ErrAlgorithmMismatch)
-		}
-		// RFC 8230 6.1 requires RSA keys having a minimum size of 2048 bits.
-		// Reference: https://www.rfc-editor.org/rfc/rfc8230.html#section-6.1
-		if vk.N.BitLen() < 2048 {
-			return nil, errors.New("RSA key must be at least 2048 bits long")
-		}
-		return &rsaSigner{
-			alg: alg,
-			key: key,
-		}, nil
-	case AlgorithmES256, AlgorithmES384, AlgorithmES512:
-		vk, ok := key.Public().(*ecdsa.PublicKey)
-		if !ok {
-			return nil, fmt.Errorf("%v: %w", alg, ErrAlgorithmMismatch)
-		}
-		if sk, ok := key.(*ecdsa.PrivateKey); ok {
-			return &ecdsaKeySigner{
-				alg: alg,
-				key: sk,
-			}, nil
+	info, ok := lookupAlgorithm(alg)
+	if !ok || info.NewSigner == nil {
+		return nil, ErrAlgorithmNotSupported
+	}
+	if info.CheckKey != nil {
+		if err := info.CheckKey(key.Public()); err != nil {
+			return nil, fmt.Errorf("%v: %w", alg, err)
 		}
-		return &ecdsaCryptoSigner{
-			alg:    alg,
-			key:    vk,
-			signer: key,
-		}, nil
-	case AlgorithmEd25519:
-		if _, ok := key.Public().(ed25519.PublicKey); !ok {
-			return nil, fmt.Errorf("%v: %w", alg, ErrAlgorithmMismatch)
+	}
+	return info.NewSigner(alg, key)
+}
+
+// signContent signs content using the provided Signer.
+// If signer implements DigestSigner and the signing algorithm has a hash
+// function, the digest of content is computed and signed instead.
+func signContent(rand io.Reader, signer Signer, content []byte) ([]byte, error) {
+	if ds, ok := signer.(DigestSigner); ok {
+		if hash := signer.Algorithm().hashFunc(); hash != 0 {
+			digest, err := computeHash(hash, content)
+			if err != nil {
+				return nil, err
+			}
+			return ds.SignDigest(rand, digest)
 		}
-		return &ed25519Signer{
-			key: key,
-		}, nil
-	default:
-		return nil, ErrAlgorithmNotSupported
 	}
+	return signer.Sign(rand, content)
 }
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/hex"
+	"errors"
 	"io"
 	"reflect"
 	"testing"
@@ -163,3 +164,99 @@ func (m *mockSigner) Sign(rand io.Reader, content []byte) ([]byte, error) {
 	}
 	return sig, nil
 }
+
+// digestOnlySigner is a DigestSigner backed by a remote service that only
+// signs digests.
+type digestOnlySigner struct {
+	DigestSigner
+}
+
+func (digestOnlySigner) Sign(rand io.Reader, content []byte) ([]byte, error) {
+	return nil, errors.New("digestOnlySigner: signing content not supported")
+}
+
+func Test_signContent(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(AlgorithmES256, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(AlgorithmES256, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	content := []byte("hello world")
+
+	// digest signer
+	sig, err := signContent(rand.Reader, digestOnlySigner{signer.(DigestSigner)}, content)
+	if err != nil {
+		t.Fatalf("signContent() error = %v", err)
+	}
+	if err := verifier.Verify(content, sig); err != nil {
+		t.Errorf("Verifier.Verify() error = %v", err)
+	}
+
+	// signer without digest support
+	mock := newMockSigner(t)
+	mock.setup(content, []byte("sig"))
+	sig, err = signContent(rand.Reader, mock, content)
+	if err != nil {
+		t.Fatalf("signContent() error = %v", err)
+	}
+	if want := []byte("sig"); !reflect.DeepEqual(sig, want) {
+		t.Errorf("signContent() = %v, want %v", sig, want)
+	}
+
+	// digest signer of an algorithm without hash function
+	_, ed25519Key := generateTestEd25519Key(t)
+	ed25519Signer, err := NewSigner(AlgorithmEd25519, ed25519Key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	if _, err := signContent(rand.Reader, digestOnlySigner{&ed25519DigestSigner{ed25519Signer}}, content); err == nil {
+		t.Error("signContent() error = nil, wantErr true")
+	}
+}
+
+// ed25519DigestSigner is an invalid DigestSigner for Ed25519, which signs the
+// message content rather than its digest.
+type ed25519DigestSigner struct {
+	Signer
+}
+
+func (s *ed25519DigestSigner) SignDigest(rand io.Reader, digest []byte) ([]byte, error) {
+	return nil, errors.New("ed25519DigestSigner: digest signing not supported")
+}
+
+func TestSign1Message_Sign_DigestSigner(t *testing.T) {
+	key := generateTestRSAKey(t)
+	signer, err := NewSigner(AlgorithmPS256, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(AlgorithmPS256, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	// Sign1Message
+	msg := NewSign1Message()
+	msg.Payload = []byte("hello world")
+	if err := msg.Sign(rand.Reader, nil, digestOnlySigner{signer.(DigestSigner)}); err != nil {
+		t.Fatalf("Sign1Message.Sign() error = %v", err)
+	}
+	if err := msg.Verify(nil, digestOnlyVerifier{verifier.(DigestVerifier)}); err != nil {
+		t.Errorf("Sign1Message.Verify() error = %v", err)
+	}
+
+	// SignMessage
+	signMsg := NewSignMessage()
+	signMsg.Payload = []byte("hello world")
+	signMsg.Signatures = []*Signature{NewSignature()}
+	if err := signMsg.Sign(rand.Reader, nil, digestOnlySigner{signer.(DigestSigner)}); err != nil {
+		t.Fatalf("SignMessage.Sign() error = %v", err)
+	}
+	if err := signMsg.Verify(nil, digestOnlyVerifier{verifier.(DigestVerifier)}); err != nil {
+		t.Errorf("SignMessage.Verify() error = %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package cose
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Decoder reads and decodes tagged COSE messages from an input stream, such as
+// a CBOR sequence of messages.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8742
+type Decoder struct {
+	r   *limitedReader
+	dec *cbor.Decoder
+}
+
+// NewDecoder returns a new decoder that reads from r.
+//
+// The limits set by SetDecOptions are captured when NewDecoder is called, and
+// apply to reading each message from r: MaxMessageSize bounds the bytes
+// buffered for a message, so that a hostile stream cannot grow the buffer
+// without limit. A later call to SetDecOptions does not affect reading, but
+// applies to decoding the messages read, as Unmarshal does.
+//
+// The decoder introduces its own buffering and may read data from r beyond the
+// messages decoded.
+func NewDecoder(r io.Reader) *Decoder {
+	lr := &limitedReader{
+		r:   r,
+		max: defaultDecoder.maxMessageSize,
+	}
+	return &Decoder{
+		r:   lr,
+		dec: defaultDecoder.mode.NewDecoder(lr),
+	}
+}
+
+// Decode reads the next tagged COSE message from the input, and decodes it as
+// Unmarshal does.
+//
+// Decode returns io.EOF at the end of the input, and io.ErrUnexpectedEOF if the
+// input ends in the middle of a message. If a well-formed CBOR data item is
+// read but cannot be decoded into a message, the error is returned and the
+// following messages can still be decoded. An error is returned if a message
+// exceeds MaxMessageSize, after which the input cannot be decoded any further.
+func (d *Decoder) Decode() (Message, error) {
+	d.r.start = d.dec.NumBytesRead()
+	var data cbor.RawMessage
+	if err := d.dec.Decode(&data); err != nil {
+		if err == io.EOF && d.r.n > d.dec.NumBytesRead() {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// limitedReader counts the bytes read from the underlying reader, and stops
+// reading more than max bytes from the start of the message being decoded if
+// max is positive.
+type limitedReader struct {
+	r     io.Reader
+	n     int
+	start int
+	max   int
+}
+
+// Read implements the io.Reader interface.
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.max > 0 {
+		remaining := r.start + r.max - r.n
+		if remaining <= 0 {
+			return 0, fmt.Errorf("cbor: object size exceeds limit %d", r.max)
+		}
+		if len(p) > remaining {
+			p = p[:remaining]
+		}
+	}
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
+// Encoder encodes and writes tagged COSE messages to an output stream, forming
+// a CBOR sequence of messages.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc8742
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: w,
+	}
+}
+
+// Encode writes the tagged COSE message to the output.
+// Nothing is written if the message cannot be encoded.
+func (e *Encoder) Encode(msg Message) error {
+	if msg == nil {
+		return errors.New("encoding nil message")
+	}
+	data, err := msg.MarshalCBOR()
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
@@ -0,0 +1,207 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestEncoder_Decoder(t *testing.T) {
+	// generate key and set up signer / verifier
+	alg := AlgorithmES256
+	key := generateTestECDSAKey(t)
+	signer, err := NewSigner(alg, key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	// encode a sequence of messages
+	var msgs []Message
+	for _, payload := range []string{"foo", "bar", "baz"} {
+		msg := NewSign1Message()
+		msg.Headers.Protected.SetAlgorithm(alg)
+		msg.Payload = []byte(payload)
+		if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+			t.Fatalf("Sign1Message.Sign() error = %v", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	msgs = append(msgs, &Mac0Message{
+		Headers: Headers{
+			Protected: ProtectedHeader{
+				HeaderLabelAlgorithm: AlgorithmHMAC256_64,
+			},
+			Unprotected: UnprotectedHeader{},
+		},
+		Payload: []byte("foo"),
+		Tag:     []byte("bar"),
+	})
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			t.Fatalf("Encoder.Encode() error = %v", err)
+		}
+	}
+
+	// decode the sequence
+	dec := NewDecoder(&buf)
+	for i, want := range msgs {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("Decoder.Decode() error = %v", err)
+		}
+		if reflect.TypeOf(got) != reflect.TypeOf(want) {
+			t.Fatalf("Decoder.Decode() = %T, want %T", got, want)
+		}
+		if msg, ok := got.(*Sign1Message); ok {
+			if err := msg.Verify(nil, verifier); err != nil {
+				t.Errorf("Sign1Message.Verify() error = %v", err)
+			}
+			if !bytes.Equal(msg.Payload, want.(*Sign1Message).Payload) {
+				t.Errorf("message %d: Payload = %s, want %s", i, msg.Payload, want.(*Sign1Message).Payload)
+			}
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Decoder.Decode() error = %v, wantErr %v", err, io.EOF)
+	}
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	sign1 := []byte{
+		0xd2, // tag
+		0x84,
+		0x43, 0xa1, 0x01, 0x26, // protected
+		0xa0,                   // unprotected
+		0x43, 0x66, 0x6f, 0x6f, // payload
+		0x43, 0x62, 0x61, 0x72, // signature
+	}
+	concat := func(items ...[]byte) []byte {
+		return bytes.Join(items, nil)
+	}
+	tests := []struct {
+		name     string
+		data     []byte
+		wantErrs []error
+	}{
+		{
+			name:     "empty input",
+			data:     nil,
+			wantErrs: []error{io.EOF},
+		},
+		{
+			name:     "truncated message",
+			data:     concat(sign1, sign1[:len(sign1)-1]),
+			wantErrs: []error{nil, io.ErrUnexpectedEOF},
+		},
+		{
+			name:     "unsupported message",
+			data:     concat([]byte{0xd8, 0x64, 0x80}, sign1),
+			wantErrs: []error{ErrMessageNotSupported, nil, io.EOF},
+		},
+		{
+			name:     "untagged message",
+			data:     concat(sign1[1:], sign1),
+			wantErrs: []error{errors.New("cbor: require tag type"), nil, io.EOF},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(bytes.NewReader(tt.data))
+			for i, wantErr := range tt.wantErrs {
+				_, err := dec.Decode()
+				switch {
+				case wantErr == nil:
+					if err != nil {
+						t.Fatalf("Decoder.Decode() #%d error = %v", i, err)
+					}
+				case errors.Is(err, wantErr):
+				case err == nil || err.Error() != wantErr.Error():
+					t.Fatalf("Decoder.Decode() #%d error = %v, wantErr %v", i, err, wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestDecoder_Decode_MaxMessageSize(t *testing.T) {
+	sign1 := []byte{
+		0xd2, // tag
+		0x84,
+		0x43, 0xa1, 0x01, 0x26, // protected
+		0xa0,                   // unprotected
+		0x43, 0x66, 0x6f, 0x6f, // payload
+		0x43, 0x62, 0x61, 0x72, // signature
+	}
+	t.Cleanup(func() {
+		if err := SetDecOptions(DecOptions{}); err != nil {
+			t.Fatalf("SetDecOptions() error = %v", err)
+		}
+	})
+	if err := SetDecOptions(DecOptions{MaxMessageSize: len(sign1)}); err != nil {
+		t.Fatalf("SetDecOptions() error = %v", err)
+	}
+
+	// a hostile stream sends a never ending byte string after two messages
+	r := &countingZeroReader{}
+	dec := NewDecoder(io.MultiReader(
+		bytes.NewReader(sign1),
+		bytes.NewReader(sign1),
+		bytes.NewReader([]byte{0x5b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}),
+		r,
+	))
+
+	// limits set later do not affect reading
+	if err := SetDecOptions(DecOptions{}); err != nil {
+		t.Fatalf("SetDecOptions() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := dec.Decode(); err != nil {
+			t.Fatalf("Decoder.Decode() #%d error = %v", i, err)
+		}
+	}
+	wantErr := fmt.Sprintf("cbor: object size exceeds limit %d", len(sign1))
+	if _, err := dec.Decode(); err == nil || err.Error() != wantErr {
+		t.Fatalf("Decoder.Decode() error = %v, wantErr %v", err, wantErr)
+	}
+	if r.n > len(sign1) {
+		t.Errorf("Decoder.Decode() read %d bytes of the hostile item, want at most %d", r.n, len(sign1))
+	}
+}
+
+// countingZeroReader reads zeros without end, and counts the bytes read.
+type countingZeroReader struct {
+	n int
+}
+
+// Read implements the io.Reader interface.
+func (r *countingZeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	r.n += len(p)
+	return len(p), nil
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode(nil); err == nil {
+		t.Error("Encoder.Encode() error = nil, wantErr true")
+	}
+	if err := enc.Encode(&Sign1Message{}); err != ErrEmptySignature {
+		t.Errorf("Encoder.Encode() error = %v, wantErr %v", err, ErrEmptySignature)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Encoder.Encode() wrote %x, want nothing", buf.Bytes())
+	}
+}
@@ -2,10 +2,6 @@ package cose
 
 import (
 	"crypto"
-	"crypto/ecdsa"
-	"crypto/ed25519"
-	"crypto/rsa"
-	"errors"
 	"fmt"
 )
 
@@ -22,43 +18,53 @@ type Verifier interface {
 	Verify(content, signature []byte) error
 }
 
+// DigestVerifier is an interface for public keys to verify digested COSE
+// signatures.
+//
+// The built-in ECDSA and RSASSA-PSS verifiers returned by NewVerifier implement
+// DigestVerifier.
+type DigestVerifier interface {
+	// Algorithm returns the signing algorithm associated with the public key.
+	Algorithm() Algorithm
+
+	// VerifyDigest verifies message digest with the public key, returning nil
+	// for success.
+	// Otherwise, it returns ErrVerification.
+	//
+	// Reference: https://datatracker.ietf.org/doc/html/rfc8152#section-8
+	VerifyDigest(digest, signature []byte) error
+}
+
 // NewVerifier returns a verifier with a given public key.
 // Only golang built-in crypto public keys of type `*rsa.PublicKey`,
-// `*ecdsa.PublicKey`, and `ed25519.PublicKey` are accepted.
+// `*ecdsa.PublicKey`, and `ed25519.PublicKey` are accepted by the built-in
+// algorithms.
+// Algorithms registered by RegisterAlgorithm define their own accepted keys.
 func NewVerifier(alg Algorithm, key crypto.PublicKey) (Verifier, error) {
-	switch alg {
-	case AlgorithmPS256, AlgorithmPS384, AlgorithmPS512:
-		vk, ok := key.(*rsa.PublicKey)
-		if !ok {
-			return nil, fmt.Errorf("%v: %w", alg, ErrAlgorithmMismatch)
-		}
-		// RFC 8230 6.1 requires RSA keys having a minimun size of 2048 bits.
-		// Reference: https://www.rfc-editor.org/rfc/rfc8230.html#section-6.1
-		if vk.N.BitLen() < 2048 {
-			return nil, errors.New("RSA key must be at least 2048 bits long")
-		}
-		return &rsaVerifier{
-			alg: alg,
-			key: vk,
-		}, nil
-	case AlgorithmES256, AlgorithmES384, AlgorithmES512:
-		vk, ok := key.(*ecdsa.PublicKey)
-		if !ok {
-			return nil, fmt.Errorf("%v: %w", alg, ErrAlgorithmMismatch)
+	info, ok := lookupAlgorithm(alg)
+	if !ok || info.NewVerifier == nil {
+		return nil, ErrAlgorithmNotSupported
+	}
+	if info.CheckKey != nil {
+		if err := info.CheckKey(key); err != nil {
+			return nil, fmt.Errorf("%v: %w", alg, err)
 		}
-		return &ecdsaVerifier{
-			alg: alg,
-			key: vk,
-		}, nil
-	case AlgorithmEd25519:
-		vk, ok := key.(ed25519.PublicKey)
-		if !ok {
-			return nil, fmt.Errorf("%v: %w", alg, ErrAlgorithmMismatch)
+	}
+	return info.NewVerifier(alg, key)
+}
+
+// verifyContent verifies the signature of content using the provided Verifier.
+// If verifier implements DigestVerifier and the signing algorithm has a hash
+// function, the digest of content is computed and verified instead.
+func verifyContent(verifier Verifier, content, signature []byte) error {
+	if dv, ok := verifier.(DigestVerifier); ok {
+		if hash := verifier.Algorithm().hashFunc(); hash != 0 {
+			digest, err := computeHash(hash, content)
+			if err != nil {
+				return err
+			}
+			return dv.VerifyDigest(digest, signature)
 		}
-		return &ed25519Verifier{
-			key: vk,
-		}, nil
-	default:
-		return nil, ErrAlgorithmNotSupported
 	}
+	return verifier.Verify(content, signature)
 }
@@ -5,6 +5,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -102,3 +103,42 @@ func TestNewVerifier(t *testing.T) {
 		})
 	}
 }
+
+// digestOnlyVerifier is a DigestVerifier backed by a remote service that only
+// verifies digests.
+type digestOnlyVerifier struct {
+	DigestVerifier
+}
+
+func (digestOnlyVerifier) Verify(content, signature []byte) error {
+	return errors.New("digestOnlyVerifier: verifying content not supported")
+}
+
+func Test_verifyContent(t *testing.T) {
+	alg := AlgorithmES256
+	key := generateTestECDSAKey(t)
+	content, sig := signTestData(t, alg, key)
+	verifier, err := NewVerifier(alg, key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	// digest verifier
+	if err := verifyContent(digestOnlyVerifier{verifier.(DigestVerifier)}, content, sig); err != nil {
+		t.Errorf("verifyContent() error = %v", err)
+	}
+	if err := verifyContent(digestOnlyVerifier{verifier.(DigestVerifier)}, []byte("foo"), sig); err != ErrVerification {
+		t.Errorf("verifyContent() error = %v, wantErr %v", err, ErrVerification)
+	}
+
+	// verifier without digest support
+	_, ed25519Key := generateTestEd25519Key(t)
+	content, sig = signTestData(t, AlgorithmEd25519, ed25519Key)
+	ed25519Verifier, err := NewVerifier(AlgorithmEd25519, ed25519Key.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	if err := verifyContent(ed25519Verifier, content, sig); err != nil {
+		t.Errorf("verifyContent() error = %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package cose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+)
+
+// VerifyOptions specifies the verification policy enforced by the
+// VerifyWithOptions methods in addition to the signature verification.
+// The zero value enforces no policy.
+type VerifyOptions struct {
+	// Algorithms is the list of the allowed signing algorithms.
+	// All algorithms are allowed if empty.
+	Algorithms []Algorithm
+
+	// MinRSAKeySize is the minimum size in bits of the RSA public keys.
+	// No constraint is applied if zero, other than the minimum of 2048 bits
+	// required by RFC 8230.
+	MinRSAKeySize int
+
+	// MinECKeySize is the minimum size in bits of the curves of the ECDSA public
+	// keys.
+	// No constraint is applied if zero.
+	MinECKeySize int
+
+	// RequiredProtectedHeaders is the list of the header labels required to be
+	// present in the protected header.
+	RequiredProtectedHeaders []interface{}
+
+	// ForbiddenUnprotectedHeaders is the list of the header labels required to
+	// be absent from the unprotected header.
+	ForbiddenUnprotectedHeaders []interface{}
+
+	// CriticalHeaders is the list of the header labels understood by the
+	// application, in addition to the ones understood by this library as
+	// described in ProtectedHeader.CheckCritical.
+	// Any other label listed in the crit header parameter is rejected.
+	//
+	// Reference: https://datatracker.ietf.org/doc/html/rfc9052#section-3.1
+	CriticalHeaders []interface{}
+}
+
+// check enforces the verification policy on the verifier and on the headers
+// of a signature.
+// The minimum key sizes require the verifier to provide its public key through
+// a `PublicKey() crypto.PublicKey` method, as the built-in verifiers do.
+func (o *VerifyOptions) check(verifier Verifier, headers ...*Headers) error {
+	if err := o.checkAlgorithm(verifier.Algorithm()); err != nil {
+		return err
+	}
+	if err := o.checkKey(verifier); err != nil {
+		return err
+	}
+	for _, label := range o.RequiredProtectedHeaders {
+		found := false
+		for _, h := range headers {
+			if hasNormalizedLabel(h.Protected, label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("missing required protected header: %v", label)
+		}
+	}
+	for _, label := range o.ForbiddenUnprotectedHeaders {
+		for _, h := range headers {
+			if hasNormalizedLabel(h.Unprotected, label) {
+				return fmt.Errorf("forbidden unprotected header: %v", label)
+			}
+		}
+	}
+	for _, h := range headers {
+		if err := h.Protected.CheckCritical(o.CriticalHeaders...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAlgorithm ensures the algorithm is allowed.
+func (o *VerifyOptions) checkAlgorithm(alg Algorithm) error {
+	if len(o.Algorithms) == 0 {
+		return nil
+	}
+	for _, allowed := range o.Algorithms {
+		if alg == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrAlgorithmNotAllowed, alg)
+}
+
+// checkKey ensures the public key of the verifier meets the minimum key sizes.
+func (o *VerifyOptions) checkKey(verifier Verifier) error {
+	if o.MinRSAKeySize <= 0 && o.MinECKeySize <= 0 {
+		return nil
+	}
+	provider, ok := verifier.(interface{ PublicKey() crypto.PublicKey })
+	if !ok {
+		return errors.New("unable to check the key size: verifier does not provide its public key")
+	}
+	switch key := provider.PublicKey().(type) {
+	case *rsa.PublicKey:
+		if size := key.N.BitLen(); size < o.MinRSAKeySize {
+			return fmt.Errorf("RSA key must be at least %d bits long, got %d", o.MinRSAKeySize, size)
+		}
+	case *ecdsa.PublicKey:
+		if size := key.Curve.Params().BitSize; size < o.MinECKeySize {
+			return fmt.Errorf("EC key must be at least %d bits long, got %d", o.MinECKeySize, size)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package cose
+
+import (
+	"crypto"
+	"errors"
+	"testing"
+)
+
+// opaqueVerifier hides the public key of the wrapped Verifier.
+type opaqueVerifier struct {
+	Verifier
+}
+
+func TestVerifyOptions_check(t *testing.T) {
+	ecKey := generateTestECDSAKey(t)
+	ecVerifier, err := NewVerifier(AlgorithmES256, ecKey.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	rsaKey := generateTestRSAKey(t)
+	rsaVerifier, err := NewVerifier(AlgorithmPS256, rsaKey.Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	edKey, _ := generateTestEd25519Key(t)
+	edVerifier, err := NewVerifier(AlgorithmEd25519, edKey)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	headers := &Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelAlgorithm:   AlgorithmES256,
+			HeaderLabelContentType: "text/plain",
+			HeaderLabelCritical:    []interface{}{HeaderLabelContentType, int64(-65537)},
+			int64(-65537):          "foo",
+		},
+		Unprotected: UnprotectedHeader{
+			HeaderLabelKeyID: []byte("1"),
+		},
+	}
+	tests := []struct {
+		name     string
+		opts     VerifyOptions
+		verifier Verifier
+		headers  []*Headers
+		wantErr  bool
+	}{
+		{
+			name:     "allowed algorithm",
+			opts:     VerifyOptions{Algorithms: []Algorithm{AlgorithmES384, AlgorithmES256}, CriticalHeaders: []interface{}{-65537}},
+			verifier: ecVerifier,
+			headers:  []*Headers{headers},
+		},
+		{
+			name:     "algorithm not allowed",
+			opts:     VerifyOptions{Algorithms: []Algorithm{AlgorithmES384}, CriticalHeaders: []interface{}{-65537}},
+			verifier: ecVerifier,
+			headers:  []*Headers{headers},
+			wantErr:  true,
+		},
+		{
+			name:     "EC key size",
+			opts:     VerifyOptions{MinECKeySize: 256, MinRSAKeySize: 3072},
+			verifier: ecVerifier,
+			headers:  []*Headers{{}},
+		},
+		{
+			name:     "EC key too small",
+			opts:     VerifyOptions{MinECKeySize: 384},
+			verifier: ecVerifier,
+			headers:  []*Headers{{}},
+			wantErr:  true,
+		},
+		{
+			name:     "RSA key size",
+			opts:     VerifyOptions{MinRSAKeySize: 2048, MinECKeySize: 521},
+			verifier: rsaVerifier,
+			headers:  []*Headers{{}},
+		},
+		{
+			name:     "RSA key too small",
+			opts:     VerifyOptions{MinRSAKeySize: 3072},
+			verifier: rsaVerifier,
+			headers:  []*Headers{{}},
+			wantErr:  true,
+		},
+		{
+			name:     "Ed25519 key with key size constraints",
+			opts:     VerifyOptions{MinRSAKeySize: 3072, MinECKeySize: 384},
+			verifier: edVerifier,
+			headers:  []*Headers{{}},
+		},
+		{
+			name:     "verifier without public key",
+			opts:     VerifyOptions{MinECKeySize: 256},
+			verifier: opaqueVerifier{ecVerifier},
+			headers:  []*Headers{{}},
+			wantErr:  true,
+		},
+		{
+			name: "required protected headers",
+			opts: VerifyOptions{
+				RequiredProtectedHeaders: []interface{}{HeaderLabelContentType, 1},
+				CriticalHeaders:          []interface{}{int64(-65537)},
+			},
+			verifier: ecVerifier,
+			headers:  []*Headers{headers},
+		},
+		{
+			name: "required protected header in another headers",
+			opts: VerifyOptions{
+				RequiredProtectedHeaders: []interface{}{HeaderLabelContentType},
+			},
+			verifier: ecVerifier,
+			headers: []*Headers{
+				{Protected: ProtectedHeader{HeaderLabelContentType: "text/plain"}},
+				{Protected: ProtectedHeader{HeaderLabelAlgorithm: AlgorithmES256}},
+			},
+		},
+		{
+			name: "missing required protected header",
+			opts: VerifyOptions{
+				RequiredProtectedHeaders: []interface{}{HeaderLabelKeyID},
+				CriticalHeaders:          []interface{}{int64(-65537)},
+			},
+			verifier: ecVerifier,
+			headers:  []*Headers{headers},
+			wantErr:  true,
+		},
+		{
+			name: "forbidden unprotected header",
+			opts: VerifyOptions{
+				ForbiddenUnprotectedHeaders: []interface{}{4},
+				CriticalHeaders:             []interface{}{int64(-65537)},
+			},
+			verifier: ecVerifier,
+			headers:  []*Headers{headers},
+			wantErr:  true,
+		},
+		{
+			name: "absent forbidden unprotected header",
+			opts: VerifyOptions{
+				ForbiddenUnprotectedHeaders: []interface{}{HeaderLabelIV},
+				CriticalHeaders:             []interface{}{int64(-65537)},
+			},
+			verifier: ecVerifier,
+			headers:  []*Headers{headers},
+		},
+		{
+			name:     "critical header not understood",
+			opts:     VerifyOptions{},
+			verifier: ecVerifier,
+			headers:  []*Headers{headers},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid critical header",
+			opts:     VerifyOptions{},
+			verifier: ecVerifier,
+			headers: []*Headers{
+				{Protected: ProtectedHeader{HeaderLabelCritical: []interface{}{"foo"}}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.check(tt.verifier, tt.headers...); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyOptions.check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyOptions_check_AlgorithmNotAllowed(t *testing.T) {
+	verifier, err := NewVerifier(AlgorithmES256, generateTestECDSAKey(t).Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	opts := VerifyOptions{
+		Algorithms: []Algorithm{AlgorithmEd25519},
+	}
+	if err := opts.check(verifier, &Headers{}); !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Errorf("VerifyOptions.check() error = %v, wantErr %v", err, ErrAlgorithmNotAllowed)
+	}
+}
+
+func Test_verifierPublicKey(t *testing.T) {
+	ecKey := generateTestECDSAKey(t)
+	rsaKey := generateTestRSAKey(t)
+	edKey, _ := generateTestEd25519Key(t)
+	tests := []struct {
+		alg Algorithm
+		key crypto.PublicKey
+	}{
+		{AlgorithmES256, ecKey.Public()},
+		{AlgorithmPS256, rsaKey.Public()},
+		{AlgorithmEd25519, edKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg.String(), func(t *testing.T) {
+			verifier, err := NewVerifier(tt.alg, tt.key)
+			if err != nil {
+				t.Fatalf("NewVerifier() error = %v", err)
+			}
+			provider, ok := verifier.(interface{ PublicKey() crypto.PublicKey })
+			if !ok {
+				t.Fatal("Verifier does not provide its public key")
+			}
+			if got := provider.PublicKey(); !tt.key.(interface{ Equal(crypto.PublicKey) bool }).Equal(got) {
+				t.Errorf("Verifier.PublicKey() = %v, want %v", got, tt.key)
+			}
+		})
+	}
+}
+
+func TestVerifyOptions_check_CriticalHeaderError(t *testing.T) {
+	verifier, err := NewVerifier(AlgorithmES256, generateTestECDSAKey(t).Public())
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	headers := &Headers{
+		Protected: ProtectedHeader{
+			HeaderLabelCritical: []interface{}{"foo"},
+			"foo":               "bar",
+		},
+	}
+	var opts VerifyOptions
+	var critErr *CriticalHeaderError
+	if err := opts.check(verifier, headers); !errors.As(err, &critErr) {
+		t.Errorf("VerifyOptions.check() error = %v, want *CriticalHeaderError", err)
+	}
+	opts.CriticalHeaders = []interface{}{"foo"}
+	if err := opts.check(verifier, headers); err != nil {
+		t.Errorf("VerifyOptions.check() error = %v", err)
+	}
+}
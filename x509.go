@@ -0,0 +1,178 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// hashSizes contains the size in bytes of the hash values of the hash
+// algorithms supported for certificate thumbprints.
+var hashSizes = map[Algorithm]int{
+	AlgorithmSHA256_64:  8,
+	AlgorithmSHA256:     32,
+	AlgorithmSHA512_256: 32,
+	AlgorithmSHA384:     48,
+	AlgorithmSHA512:     64,
+}
+
+// SetCertificateChain sets the certificate chain to the x5chain header
+// parameter of the protected header, and removes it from the unprotected
+// header.
+// The certificate containing the end-entity key is required to be the first
+// one, with each following certificate certifying the previous one.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) SetCertificateChain(chain []*x509.Certificate) {
+	certs := make([][]byte, len(chain))
+	for i, cert := range chain {
+		certs[i] = cert.Raw
+	}
+	h.SetX5Chain(certs)
+}
+
+// CertificateChain parses the certificate chain from the x5chain header
+// parameter.
+// It returns nil if the parameter is not present.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) CertificateChain() ([]*x509.Certificate, error) {
+	certs, err := h.X5Chain()
+	if err != nil || certs == nil {
+		return nil, err
+	}
+	return parseCertificates(certs, "x5chain")
+}
+
+// VerifyCertificateChain parses the certificate chain from the x5chain header
+// parameter and verifies the end-entity certificate, which is the first one of
+// the chain, using the provided options.
+// If opts.Intermediates is nil, the other certificates of the chain and the
+// certificates of the x5bag header parameter are used as intermediates.
+// As with x509.Certificate.Verify, opts.KeyUsages defaults to server
+// authentication and should be set accordingly.
+//
+// If the x5t header parameter is present, the thumbprint is required to match
+// the end-entity certificate.
+//
+// On success, the end-entity certificate is returned along with the verified
+// chains.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) VerifyCertificateChain(opts x509.VerifyOptions) (*x509.Certificate, [][]*x509.Certificate, error) {
+	chain, err := h.CertificateChain()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(chain) == 0 {
+		return nil, nil, errors.New("x5chain: missing certificate chain")
+	}
+	leaf := chain[0]
+	if opts.Intermediates == nil {
+		bag, err := h.X5Bag()
+		if err != nil {
+			return nil, nil, err
+		}
+		extra, err := parseCertificates(bag, "x5bag")
+		if err != nil {
+			return nil, nil, err
+		}
+		opts.Intermediates = x509.NewCertPool()
+		for _, cert := range chain[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		for _, cert := range extra {
+			opts.Intermediates.AddCert(cert)
+		}
+	}
+	if _, ok := h.lookup(HeaderLabelX5T); ok {
+		if err := h.VerifyThumbprint(leaf); err != nil {
+			return nil, nil, err
+		}
+	}
+	chains, err := leaf.Verify(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("x5chain: %w", err)
+	}
+	return leaf, chains, nil
+}
+
+// SetThumbprint computes the thumbprint of the certificate using the hash
+// algorithm alg, and sets it to the x5t header parameter of the protected
+// header.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) SetThumbprint(alg Algorithm, cert *x509.Certificate) error {
+	hash, err := thumbprint(alg, cert)
+	if err != nil {
+		return err
+	}
+	h.SetX5T(alg, hash)
+	return nil
+}
+
+// VerifyThumbprint verifies that the thumbprint of the x5t header parameter
+// matches the certificate.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func (h *Headers) VerifyThumbprint(cert *x509.Certificate) error {
+	alg, hash, err := h.X5T()
+	if err != nil {
+		return err
+	}
+	if hash == nil {
+		return errors.New("x5t: missing thumbprint")
+	}
+	expected, err := thumbprint(alg, cert)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, expected) {
+		return errors.New("x5t: thumbprint mismatch")
+	}
+	return nil
+}
+
+// NewCertificateChainVerifier verifies the certificate chain of the x5chain
+// header parameter as VerifyCertificateChain does, and returns a Verifier for
+// the end-entity public key and the algorithm of the protected header.
+//
+// Reference: https://datatracker.ietf.org/doc/html/rfc9360#section-2
+func NewCertificateChainVerifier(headers *Headers, opts x509.VerifyOptions) (Verifier, error) {
+	alg, err := headers.Protected.Algorithm()
+	if err != nil {
+		return nil, err
+	}
+	leaf, _, err := headers.VerifyCertificateChain(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(alg, leaf.PublicKey)
+}
+
+// thumbprint computes the hash of the DER encoded certificate.
+func thumbprint(alg Algorithm, cert *x509.Certificate) ([]byte, error) {
+	size, ok := hashSizes[alg]
+	if !ok {
+		return nil, fmt.Errorf("x5t: hash algorithm %v: %w", alg, ErrAlgorithmNotSupported)
+	}
+	hash, err := alg.computeHash(cert.Raw)
+	if err != nil {
+		return nil, err
+	}
+	return hash[:size], nil
+}
+
+// parseCertificates parses DER encoded certificates.
+func parseCertificates(certs [][]byte, name string) ([]*x509.Certificate, error) {
+	parsed := make([]*x509.Certificate, len(certs))
+	for i, cert := range certs {
+		c, err := x509.ParseCertificate(cert)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		parsed[i] = c
+	}
+	return parsed, nil
+}
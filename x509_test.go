@@ -0,0 +1,326 @@
+package cose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCertificate is a certificate along with its private key.
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCertificate issues a P-256 certificate signed by the parent, or a
+// self-signed certificate if parent is nil.
+func newTestCertificate(t *testing.T, name string, isCA bool, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("rand.Int() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	issuer, signer := template, crypto.Signer(key)
+	if parent != nil {
+		issuer, signer = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), signer)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate() error = %v", err)
+	}
+	return &testCertificate{cert: cert, key: key}
+}
+
+func TestHeaders_CertificateChain(t *testing.T) {
+	root := newTestCertificate(t, "root", true, nil)
+	leaf := newTestCertificate(t, "leaf", false, root)
+
+	for _, chain := range [][]*x509.Certificate{
+		{leaf.cert},
+		{leaf.cert, root.cert},
+	} {
+		var h Headers
+		h.SetCertificateChain(chain)
+		got, err := h.CertificateChain()
+		if err != nil {
+			t.Fatalf("Headers.CertificateChain() error = %v", err)
+		}
+		if len(got) != len(chain) {
+			t.Fatalf("Headers.CertificateChain() = %d certificates, want %d", len(got), len(chain))
+		}
+		for i := range got {
+			if !got[i].Equal(chain[i]) {
+				t.Errorf("Headers.CertificateChain()[%d] = %v, want %v", i, got[i].Subject, chain[i].Subject)
+			}
+		}
+	}
+
+	// absent
+	var h Headers
+	got, err := h.CertificateChain()
+	if err != nil || got != nil {
+		t.Errorf("Headers.CertificateChain() = %v, %v, want nil", got, err)
+	}
+
+	// invalid certificate
+	h.SetX5Chain([][]byte{{0x01}})
+	if _, err := h.CertificateChain(); err == nil {
+		t.Error("Headers.CertificateChain() error = nil, wantErr true")
+	}
+}
+
+func TestHeaders_VerifyCertificateChain(t *testing.T) {
+	root := newTestCertificate(t, "root", true, nil)
+	intermediate := newTestCertificate(t, "intermediate", true, root)
+	leaf := newTestCertificate(t, "leaf", false, intermediate)
+	other := newTestCertificate(t, "other", true, nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	opts := x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	tests := []struct {
+		name    string
+		h       func() Headers
+		opts    x509.VerifyOptions
+		wantErr bool
+	}{
+		{
+			name: "valid chain",
+			h: func() Headers {
+				var h Headers
+				h.SetCertificateChain([]*x509.Certificate{leaf.cert, intermediate.cert})
+				return h
+			},
+			opts: opts,
+		},
+		{
+			name: "intermediate in x5bag",
+			h: func() Headers {
+				var h Headers
+				h.SetCertificateChain([]*x509.Certificate{leaf.cert})
+				h.Unprotected = UnprotectedHeader{
+					HeaderLabelX5Bag: intermediate.cert.Raw,
+				}
+				return h
+			},
+			opts: opts,
+		},
+		{
+			name: "matching thumbprint",
+			h: func() Headers {
+				var h Headers
+				h.SetCertificateChain([]*x509.Certificate{leaf.cert, intermediate.cert})
+				if err := h.SetThumbprint(AlgorithmSHA256, leaf.cert); err != nil {
+					t.Fatalf("Headers.SetThumbprint() error = %v", err)
+				}
+				return h
+			},
+			opts: opts,
+		},
+		{
+			name: "mismatched thumbprint",
+			h: func() Headers {
+				var h Headers
+				h.SetCertificateChain([]*x509.Certificate{leaf.cert, intermediate.cert})
+				if err := h.SetThumbprint(AlgorithmSHA256, intermediate.cert); err != nil {
+					t.Fatalf("Headers.SetThumbprint() error = %v", err)
+				}
+				return h
+			},
+			opts:    opts,
+			wantErr: true,
+		},
+		{
+			name: "missing intermediate",
+			h: func() Headers {
+				var h Headers
+				h.SetCertificateChain([]*x509.Certificate{leaf.cert})
+				return h
+			},
+			opts:    opts,
+			wantErr: true,
+		},
+		{
+			name: "provided intermediates",
+			h: func() Headers {
+				var h Headers
+				h.SetCertificateChain([]*x509.Certificate{leaf.cert, intermediate.cert})
+				return h
+			},
+			opts: x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: x509.NewCertPool(),
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+			},
+			wantErr: true,
+		},
+		{
+			name: "untrusted root",
+			h: func() Headers {
+				var h Headers
+				h.SetCertificateChain([]*x509.Certificate{other.cert})
+				return h
+			},
+			opts:    opts,
+			wantErr: true,
+		},
+		{
+			name: "missing chain",
+			h: func() Headers {
+				return Headers{}
+			},
+			opts:    opts,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.h()
+			got, chains, err := h.VerifyCertificateChain(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Headers.VerifyCertificateChain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.Equal(leaf.cert) {
+				t.Errorf("Headers.VerifyCertificateChain() = %v, want %v", got.Subject, leaf.cert.Subject)
+			}
+			if len(chains) != 1 || len(chains[0]) != 3 {
+				t.Errorf("Headers.VerifyCertificateChain() chains = %v, want 1 chain of 3 certificates", chains)
+			}
+		})
+	}
+}
+
+func TestHeaders_SetThumbprint(t *testing.T) {
+	cert := newTestCertificate(t, "leaf", false, nil).cert
+	sum := sha256.Sum256(cert.Raw)
+
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "SHA-256",
+			alg:  AlgorithmSHA256,
+			want: sum[:],
+		},
+		{
+			name: "SHA-256/64",
+			alg:  AlgorithmSHA256_64,
+			want: sum[:8],
+		},
+		{
+			name:    "not a hash algorithm",
+			alg:     AlgorithmES256,
+			wantErr: ErrAlgorithmNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h Headers
+			err := h.SetThumbprint(tt.alg, cert)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Headers.SetThumbprint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			alg, hash, err := h.X5T()
+			if err != nil {
+				t.Fatalf("Headers.X5T() error = %v", err)
+			}
+			if alg != tt.alg || string(hash) != string(tt.want) {
+				t.Errorf("Headers.X5T() = %v, %x, want %v, %x", alg, hash, tt.alg, tt.want)
+			}
+			if err := h.VerifyThumbprint(cert); err != nil {
+				t.Errorf("Headers.VerifyThumbprint() error = %v", err)
+			}
+		})
+	}
+
+	// missing thumbprint
+	var h Headers
+	if err := h.VerifyThumbprint(cert); err == nil {
+		t.Error("Headers.VerifyThumbprint() error = nil, wantErr true")
+	}
+}
+
+func TestNewCertificateChainVerifier(t *testing.T) {
+	root := newTestCertificate(t, "root", true, nil)
+	leaf := newTestCertificate(t, "leaf", false, root)
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	opts := x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	signer, err := NewSigner(AlgorithmES256, leaf.key)
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	msg := NewSign1Message()
+	msg.Headers.Protected.SetAlgorithm(AlgorithmES256)
+	msg.Headers.SetCertificateChain([]*x509.Certificate{leaf.cert})
+	msg.Payload = []byte("hello world")
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		t.Fatalf("Sign1Message.Sign() error = %v", err)
+	}
+
+	verifier, err := NewCertificateChainVerifier(&msg.Headers, opts)
+	if err != nil {
+		t.Fatalf("NewCertificateChainVerifier() error = %v", err)
+	}
+	if err := msg.Verify(nil, verifier); err != nil {
+		t.Errorf("Sign1Message.Verify() error = %v", err)
+	}
+
+	// missing algorithm
+	headers := Headers{}
+	headers.SetCertificateChain([]*x509.Certificate{leaf.cert})
+	if _, err := NewCertificateChainVerifier(&headers, opts); err != ErrAlgorithmNotFound {
+		t.Errorf("NewCertificateChainVerifier() error = %v, wantErr %v", err, ErrAlgorithmNotFound)
+	}
+
+	// untrusted chain
+	headers.Protected.SetAlgorithm(AlgorithmES256)
+	if _, err := NewCertificateChainVerifier(&headers, x509.VerifyOptions{}); err == nil {
+		t.Error("NewCertificateChainVerifier() error = nil, wantErr true")
+	}
+}